@@ -0,0 +1,123 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+// APIKeyHandler represent the httphandler for API key
+type APIKeyHandler struct {
+	KUsecase domain.APIKeyUsecase
+}
+
+// NewAPIKeyHandler will initialize the API key admin endpoint
+func NewAPIKeyHandler(e *echo.Echo, us domain.APIKeyUsecase, auth echo.MiddlewareFunc) {
+	handler := &APIKeyHandler{
+		KUsecase: us,
+	}
+	e.GET("/admin/api-key", handler.Fetch, auth)
+	e.POST("/admin/api-key", handler.Issue, auth)
+	e.GET("/admin/api-key/:apiKeyId", handler.GetByID, auth)
+	e.DELETE("/admin/api-key/:apiKeyId", handler.Revoke, auth)
+}
+
+// Fetch will fetch every API key
+func (k *APIKeyHandler) Fetch(c echo.Context) error {
+	ctx := c.Request().Context()
+	keys, err := k.KUsecase.Fetch(ctx)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: keys})
+}
+
+// Issue will generate an API key by given request body, the key is only shown in this response
+func (k *APIKeyHandler) Issue(c echo.Context) (err error) {
+	var key domain.APIKey
+	err = c.Bind(&key)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&key); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	err = k.KUsecase.Issue(ctx, &key)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusCreated, response.ResponseSuccess{Success: true, Data: key})
+}
+
+// GetByID will get API key by given id
+func (k *APIKeyHandler) GetByID(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("apiKeyId"))
+	ctx := c.Request().Context()
+	key, err := k.KUsecase.GetByID(ctx, id)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: key})
+}
+
+// Revoke will revoke API key by given id
+func (k *APIKeyHandler) Revoke(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("apiKeyId"))
+	ctx := c.Request().Context()
+	err := k.KUsecase.Revoke(ctx, id)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: nil})
+}
+
+func isRequestValid(m interface{}) (bool, error) {
+	validate := validator.New()
+	err := validate.Struct(m)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// failed write the error response of err, a denied permission is described with a problem detail
+func failed(c echo.Context, err error) error {
+	status := getStatusCode(err)
+	if status == http.StatusForbidden {
+		c.Response().Header().Set(echo.HeaderContentType, response.ProblemContentType)
+		return c.JSON(status, response.NewProblem(status, err.Error()))
+	}
+	return c.JSON(status, response.ResponseFailed{Success: false, Message: err.Error()})
+}
+
+func getStatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	logrus.Error(err)
+	switch {
+	case errors.Is(err, domain.ErrInternalServerError):
+		return http.StatusInternalServerError
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrBadParamInput):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
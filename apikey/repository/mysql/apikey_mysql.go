@@ -0,0 +1,125 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// mysqlAPIKeyRepository represent the connection database struct
+type mysqlAPIKeyRepository struct {
+	Conn *sql.DB
+}
+
+// NewMysqlAPIKeyRepository will create an object that represent the apikey.Repository interface
+func NewMysqlAPIKeyRepository(Conn *sql.DB) domain.APIKeyRepository {
+	return &mysqlAPIKeyRepository{Conn: Conn}
+}
+
+const selectAPIKey = `SELECT id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_by, created_at FROM api_key`
+
+func (m *mysqlAPIKeyRepository) fetch(ctx context.Context, query string, args ...interface{}) (result []domain.APIKey, err error) {
+	rows, err := m.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.APIKey, 0)
+	for rows.Next() {
+		t := domain.APIKey{}
+		var scopes string
+		var expiresAt, lastUsedAt, revokedAt sql.NullTime
+		err = rows.Scan(
+			&t.ID,
+			&t.Name,
+			&t.Prefix,
+			&t.KeyHash,
+			&scopes,
+			&expiresAt,
+			&lastUsedAt,
+			&revokedAt,
+			&t.CreatedBy,
+			&t.CreatedAt,
+		)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		t.Scopes = strings.Split(scopes, ",")
+		t.ExpiresAt = nullTime(expiresAt)
+		t.LastUsedAt = nullTime(lastUsedAt)
+		t.RevokedAt = nullTime(revokedAt)
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func (m *mysqlAPIKeyRepository) getOne(ctx context.Context, query string, args ...interface{}) (res domain.APIKey, err error) {
+	list, err := m.fetch(ctx, query, args...)
+	if err != nil {
+		return
+	}
+	if len(list) == 0 {
+		return res, domain.ErrNotFound
+	}
+	return list[0], nil
+}
+
+func (m *mysqlAPIKeyRepository) Fetch(ctx context.Context) (res []domain.APIKey, err error) {
+	res, err = m.fetch(ctx, selectAPIKey+` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (m *mysqlAPIKeyRepository) Store(ctx context.Context, k *domain.APIKey) (err error) {
+	query := `INSERT INTO api_key (name, prefix, key_hash, scopes, expires_at, created_by, created_at) VALUES(?,?,?,?,?,?,?)`
+	result, err := m.Conn.ExecContext(ctx, query, k.Name, k.Prefix, k.KeyHash, strings.Join(k.Scopes, ","), k.ExpiresAt, k.CreatedBy, k.CreatedAt)
+	if err != nil {
+		return
+	}
+	lastID, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+	k.ID = int(lastID)
+	return
+}
+
+func (m *mysqlAPIKeyRepository) GetByID(ctx context.Context, id int) (domain.APIKey, error) {
+	return m.getOne(ctx, selectAPIKey+` WHERE id=?`, id)
+}
+
+func (m *mysqlAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (domain.APIKey, error) {
+	return m.getOne(ctx, selectAPIKey+` WHERE key_hash=?`, keyHash)
+}
+
+func (m *mysqlAPIKeyRepository) Revoke(ctx context.Context, id int, at time.Time) (err error) {
+	query := `UPDATE api_key SET revoked_at=? WHERE id=? AND revoked_at IS NULL`
+	_, err = m.Conn.ExecContext(ctx, query, at, id)
+	return
+}
+
+func (m *mysqlAPIKeyRepository) UpdateLastUsed(ctx context.Context, id int, at time.Time) (err error) {
+	query := `UPDATE api_key SET last_used_at=? WHERE id=?`
+	_, err = m.Conn.ExecContext(ctx, query, at, id)
+	return
+}
@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+const (
+	// keyPrefix make the API keys recognizable, e.g. by secret scanners
+	keyPrefix = "bkh_"
+	// displayPrefixLength is the number of leading characters of a key kept in clear to identify it
+	displayPrefixLength = 12
+	// lastUsedResolution bound how often the last use of a key is written
+	lastUsedResolution = time.Minute
+)
+
+// APIKeyUsecase represent the API key use case struct
+type APIKeyUsecase struct {
+	apiKeyRepo     domain.APIKeyRepository
+	contextTimeout time.Duration
+}
+
+// NewAPIKeyUsecase will create new an API key usecase object representation of domain.APIKeyUsecase interface
+func NewAPIKeyUsecase(k domain.APIKeyRepository, timeout time.Duration) domain.APIKeyUsecase {
+	return &APIKeyUsecase{
+		apiKeyRepo:     k,
+		contextTimeout: timeout,
+	}
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Fetch will get every API key, the keys themselves are never returned
+func (a *APIKeyUsecase) Fetch(c context.Context) (res []domain.APIKey, err error) {
+	if err = domain.Authorize(c, domain.PermissionAPIKeyManage); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+	res, err = a.apiKeyRepo.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Issue will generate a new API key, the clear key is only available on the returned value
+func (a *APIKeyUsecase) Issue(c context.Context, k *domain.APIKey) (err error) {
+	if err = domain.Authorize(c, domain.PermissionAPIKeyManage); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	for _, scope := range k.Scopes {
+		if !domain.IsValidScope(scope) {
+			return domain.ErrBadParamInput
+		}
+	}
+	now := time.Now()
+	if k.ExpiresAt != nil && !k.ExpiresAt.After(now) {
+		return domain.ErrBadParamInput
+	}
+
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+	principal, _ := domain.PrincipalFromContext(ctx)
+	k.Key = keyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	k.Prefix = k.Key[:displayPrefixLength]
+	k.KeyHash = hashKey(k.Key)
+	k.CreatedBy = principal.Subject
+	k.CreatedAt = now
+	k.LastUsedAt = nil
+	k.RevokedAt = nil
+	err = a.apiKeyRepo.Store(ctx, k)
+	return
+}
+
+// GetByID will get API key by given id
+func (a *APIKeyUsecase) GetByID(c context.Context, id int) (res domain.APIKey, err error) {
+	if err = domain.Authorize(c, domain.PermissionAPIKeyManage); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+	res, err = a.apiKeyRepo.GetByID(ctx, id)
+	return
+}
+
+// Revoke will make the API key unusable
+func (a *APIKeyUsecase) Revoke(c context.Context, id int) (err error) {
+	if err = domain.Authorize(c, domain.PermissionAPIKeyManage); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+	if _, err = a.apiKeyRepo.GetByID(ctx, id); err != nil {
+		return
+	}
+	err = a.apiKeyRepo.Revoke(ctx, id, time.Now())
+	return
+}
+
+// Authenticate will resolve a clear API key into a principal holding its scopes
+func (a *APIKeyUsecase) Authenticate(c context.Context, key string) (p domain.Principal, err error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	k, err := a.apiKeyRepo.GetByHash(ctx, hashKey(key))
+	if err == domain.ErrNotFound {
+		return p, domain.ErrUnauthorized
+	}
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	if k.RevokedAt != nil || (k.ExpiresAt != nil && now.After(*k.ExpiresAt)) {
+		return p, domain.ErrUnauthorized
+	}
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= lastUsedResolution {
+		if errUpdate := a.apiKeyRepo.UpdateLastUsed(ctx, k.ID, now); errUpdate != nil {
+			logrus.Error(errUpdate)
+		}
+	}
+	p = domain.Principal{
+		Subject:    "apikey:" + strconv.Itoa(k.ID),
+		Scopes:     k.Scopes,
+		AuthMethod: "apikey",
+	}
+	return p, nil
+}
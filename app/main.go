@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"net"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -14,12 +18,92 @@ import (
 
 	_productHttpDelivery "github.com/wdwiramadhan/bookhub-api/product/delivery/http"
 	_productHttpDeliveryMiddleware "github.com/wdwiramadhan/bookhub-api/product/delivery/http/middleware"
+	_productCacheRepo "github.com/wdwiramadhan/bookhub-api/product/repository/cache"
 	_productRepo "github.com/wdwiramadhan/bookhub-api/product/repository/mysql"
+	_productNotifyRepo "github.com/wdwiramadhan/bookhub-api/product/repository/notify"
 	_productUcase "github.com/wdwiramadhan/bookhub-api/product/usecase"
+	_productAuditUcase "github.com/wdwiramadhan/bookhub-api/product/usecase/audit"
 
 	_authorHttDelivery "github.com/wdwiramadhan/bookhub-api/author/delivery/http"
+	_authorCacheRepo "github.com/wdwiramadhan/bookhub-api/author/repository/cache"
 	_authorRepo "github.com/wdwiramadhan/bookhub-api/author/repository/mysql"
+	_authorNotifyRepo "github.com/wdwiramadhan/bookhub-api/author/repository/notify"
 	_authorUcase "github.com/wdwiramadhan/bookhub-api/author/usecase"
+	_authorAuditUcase "github.com/wdwiramadhan/bookhub-api/author/usecase/audit"
+
+	_orderHttpDelivery "github.com/wdwiramadhan/bookhub-api/order/delivery/http"
+	_orderRepo "github.com/wdwiramadhan/bookhub-api/order/repository/mysql"
+	_orderUcase "github.com/wdwiramadhan/bookhub-api/order/usecase"
+
+	_localMailer "github.com/wdwiramadhan/bookhub-api/mailer/local"
+	_userHttpDelivery "github.com/wdwiramadhan/bookhub-api/user/delivery/http"
+	_userRepo "github.com/wdwiramadhan/bookhub-api/user/repository/mysql"
+	_userUcase "github.com/wdwiramadhan/bookhub-api/user/usecase"
+
+	_apiKeyHttpDelivery "github.com/wdwiramadhan/bookhub-api/apikey/delivery/http"
+	_apiKeyRepo "github.com/wdwiramadhan/bookhub-api/apikey/repository/mysql"
+	_apiKeyUcase "github.com/wdwiramadhan/bookhub-api/apikey/usecase"
+
+	_searchHttpDelivery "github.com/wdwiramadhan/bookhub-api/search/delivery/http"
+	_searchMemoryRepo "github.com/wdwiramadhan/bookhub-api/search/repository/memory"
+	_searchMysqlRepo "github.com/wdwiramadhan/bookhub-api/search/repository/mysql"
+	_searchUcase "github.com/wdwiramadhan/bookhub-api/search/usecase"
+	_suggestHttpDelivery "github.com/wdwiramadhan/bookhub-api/suggest/delivery/http"
+	_suggestMemoryRepo "github.com/wdwiramadhan/bookhub-api/suggest/repository/memory"
+	_suggestUcase "github.com/wdwiramadhan/bookhub-api/suggest/usecase"
+
+	_localBlob "github.com/wdwiramadhan/bookhub-api/blob/local"
+	_s3Blob "github.com/wdwiramadhan/bookhub-api/blob/s3"
+	_coverHttpDelivery "github.com/wdwiramadhan/bookhub-api/cover/delivery/http"
+	_coverUcase "github.com/wdwiramadhan/bookhub-api/cover/usecase"
+
+	_reviewHttpDelivery "github.com/wdwiramadhan/bookhub-api/review/delivery/http"
+	_reviewRepo "github.com/wdwiramadhan/bookhub-api/review/repository/mysql"
+	_reviewUcase "github.com/wdwiramadhan/bookhub-api/review/usecase"
+
+	_wishlistHttpDelivery "github.com/wdwiramadhan/bookhub-api/wishlist/delivery/http"
+	_wishlistNotifier "github.com/wdwiramadhan/bookhub-api/wishlist/notifier"
+	_wishlistRepo "github.com/wdwiramadhan/bookhub-api/wishlist/repository/mysql"
+	_wishlistUcase "github.com/wdwiramadhan/bookhub-api/wishlist/usecase"
+
+	_promotionHttpDelivery "github.com/wdwiramadhan/bookhub-api/promotion/delivery/http"
+	_promotionRepo "github.com/wdwiramadhan/bookhub-api/promotion/repository/mysql"
+	_promotionUcase "github.com/wdwiramadhan/bookhub-api/promotion/usecase"
+
+	_currencyHttpDelivery "github.com/wdwiramadhan/bookhub-api/currency/delivery/http"
+	_currencyRepo "github.com/wdwiramadhan/bookhub-api/currency/repository/mysql"
+	_currencyUcase "github.com/wdwiramadhan/bookhub-api/currency/usecase"
+
+	_priceHttpDelivery "github.com/wdwiramadhan/bookhub-api/price/delivery/http"
+	_priceRepo "github.com/wdwiramadhan/bookhub-api/price/repository/mysql"
+	_priceUcase "github.com/wdwiramadhan/bookhub-api/price/usecase"
+
+	_taxHttpDelivery "github.com/wdwiramadhan/bookhub-api/tax/delivery/http"
+	_taxRepo "github.com/wdwiramadhan/bookhub-api/tax/repository/mysql"
+	_taxUcase "github.com/wdwiramadhan/bookhub-api/tax/usecase"
+
+	_auditHttpDelivery "github.com/wdwiramadhan/bookhub-api/audit/delivery/http"
+	_auditRepo "github.com/wdwiramadhan/bookhub-api/audit/repository/mysql"
+	_auditUcase "github.com/wdwiramadhan/bookhub-api/audit/usecase"
+
+	_eventMemoryBroker "github.com/wdwiramadhan/bookhub-api/event/broker/memory"
+	_eventHttpDelivery "github.com/wdwiramadhan/bookhub-api/event/delivery/http"
+	_eventRepo "github.com/wdwiramadhan/bookhub-api/event/repository/mysql"
+	_eventUcase "github.com/wdwiramadhan/bookhub-api/event/usecase"
+
+	_webhookHttpDelivery "github.com/wdwiramadhan/bookhub-api/webhook/delivery/http"
+	_webhookRepo "github.com/wdwiramadhan/bookhub-api/webhook/repository/mysql"
+	_webhookUcase "github.com/wdwiramadhan/bookhub-api/webhook/usecase"
+
+	_feedHttpDelivery "github.com/wdwiramadhan/bookhub-api/feed/delivery/http"
+	_feedUcase "github.com/wdwiramadhan/bookhub-api/feed/usecase"
+
+	_graphqlHttpDelivery "github.com/wdwiramadhan/bookhub-api/graphql/delivery/http"
+	_graphqlSchema "github.com/wdwiramadhan/bookhub-api/graphql/schema"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/cache"
+	"github.com/wdwiramadhan/bookhub-api/helper/sqltx"
 )
 
 func main() {
@@ -63,15 +147,279 @@ func main() {
 	}()
 
 	e := echo.New()
-	middL := _productHttpDeliveryMiddleware.InitMiddleware()
-	e.Use(middL.CORS)
 	pr := _productRepo.NewMysqlProductRepository(dbConn)
 	ar := _authorRepo.NewMysqlAuthorRepository(dbConn)
+	or := _orderRepo.NewMysqlOrderRepository(dbConn)
+	ur := _userRepo.NewMysqlUserRepository(dbConn)
+	kr := _apiKeyRepo.NewMysqlAPIKeyRepository(dbConn)
+	mailer := _localMailer.NewLocalMailer(os.Getenv("MAIL_OUTPUT_FILE"))
+
+	// the in-memory indexes read the catalog straight from MySQL and are kept
+	// up to date by the listeners notified of every catalog mutation
+	var catalogListeners []domain.CatalogListener
+	var sr domain.SearchRepository
+	if os.Getenv("SEARCH_BACKEND") == "mysql" {
+		sr = _searchMysqlRepo.NewMysqlSearchRepository(dbConn)
+	} else {
+		searchIndex := _searchMemoryRepo.NewMemorySearchRepository(pr, ar)
+		if err := searchIndex.Rebuild(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+		catalogListeners = append(catalogListeners, searchIndex)
+		sr = searchIndex
+	}
+	suggestIndex := _suggestMemoryRepo.NewMemorySuggestRepository(pr, ar)
+	if err := suggestIndex.Rebuild(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	catalogListeners = append(catalogListeners, suggestIndex)
+	// the products are priced in the base currency, the reads convert them
+	baseCurrency := os.Getenv("BASE_CURRENCY")
+	if baseCurrency == "" {
+		baseCurrency = "IDR"
+	}
+	// the wishlist owners are emailed when a product of their lists gets cheaper
+	wr := _wishlistRepo.NewMysqlWishlistRepository(dbConn)
+	priceDropNotifier := _wishlistNotifier.NewMailPriceDropNotifier(ur, mailer, baseCurrency)
+	priceDropWatcher := _wishlistUcase.NewPriceDropWatcher(wr, pr, priceDropNotifier, time.Minute)
+	go priceDropWatcher.Run(context.Background())
+	catalogListeners = append(catalogListeners, priceDropWatcher)
+
+	// lookups by id are served from a read-through cache, the cached products
+	// are invalidated when the author they embed changes
+	cacheSize := envInt("CACHE_SIZE", 10000)
+	cacheTTL := time.Duration(envInt("CACHE_TTL_SECONDS", 60)) * time.Second
+	// a miss is loaded once for all of its lookups, with a deadline of its own
+	cacheLoadTimeout := 2 * time.Second
+	productCache := _productCacheRepo.NewCacheProductRepository(pr, cache.New(cacheSize, cacheTTL, cacheLoadTimeout))
+	catalogListeners = append(catalogListeners, productCache)
+	pr = productCache
+	ar = _authorCacheRepo.NewCacheAuthorRepository(ar, cache.New(cacheSize, cacheTTL, cacheLoadTimeout))
+	pr = _productNotifyRepo.NewNotifyProductRepository(pr, catalogListeners...)
+	ar = _authorNotifyRepo.NewNotifyAuthorRepository(ar, catalogListeners...)
 
 	timeoutContext := time.Duration(2) * time.Second
-	pu := _productUcase.NewProductUsecase(pr, timeoutContext)
-	_productHttpDelivery.NewProductHandler(e, pu)
-	au := _authorUcase.NewAuthorUsecase(ar, timeoutContext)
-	_authorHttDelivery.NewAuthorHandler(e, au)
+	transactor := sqltx.NewTransactor(dbConn)
+	uu := _userUcase.NewUserUsecase(ur, mailer, transactor, timeoutContext)
+	ku := _apiKeyUcase.NewAPIKeyUsecase(kr, timeoutContext)
+
+	middlewareOpts := []_productHttpDeliveryMiddleware.Option{
+		_productHttpDeliveryMiddleware.WithSessionAuthenticator(uu),
+		_productHttpDeliveryMiddleware.WithAPIKeyAuthenticator(ku),
+	}
+	if os.Getenv("JWT_SECRET") != "" || os.Getenv("JWT_JWKS_FILE") != "" {
+		jwtVerifier, err := _productHttpDeliveryMiddleware.NewJWTVerifier(_productHttpDeliveryMiddleware.JWTConfig{
+			Issuer:   os.Getenv("JWT_ISSUER"),
+			Audience: os.Getenv("JWT_AUDIENCE"),
+			Secret:   os.Getenv("JWT_SECRET"),
+			JWKSFile: os.Getenv("JWT_JWKS_FILE"),
+			Leeway:   30 * time.Second,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		middlewareOpts = append(middlewareOpts, _productHttpDeliveryMiddleware.WithJWTVerifier(jwtVerifier))
+	}
+	middL := _productHttpDeliveryMiddleware.InitMiddleware(middlewareOpts...)
+	e.IPExtractor = ipExtractor(os.Getenv("TRUSTED_PROXIES"))
+	e.Use(middL.RequestID)
+	e.Use(middL.CORS)
+	// the credentials are checked once, the rate limits and the routes reuse the principal
+	e.Use(middL.Authenticate)
+
+	// the catalog reads may be cached by browsers and CDNs, revalidating with
+	// Last-Modified/ETag once stale, every other response is private to the client
+	catalogPolicy := "public, max-age=" + strconv.Itoa(envInt("CACHE_MAX_AGE_SECONDS", 60))
+	e.Use(middL.CacheControl(_productHttpDeliveryMiddleware.CacheControlConfig{
+		Default: "no-store",
+		Routes: map[string]string{
+			"GET /product":            catalogPolicy,
+			"GET /product/:productId": catalogPolicy,
+			"GET /author":             catalogPolicy,
+			"GET /author/:authorId":   catalogPolicy,
+			// newly approved reviews show up once the cached list is stale
+			"GET /product/:productId/review": catalogPolicy,
+			// image URLs are derived from their content
+			"GET /image/*": "public, max-age=31536000, immutable",
+		},
+		Vary: []string{"Accept-Encoding", "Accept-Currency"},
+	}))
+
+	writePolicy := _productHttpDeliveryMiddleware.RateLimitPolicy{
+		Rate:  envFloat("RATE_LIMIT_WRITE_RPS", 0.5),
+		Burst: envInt("RATE_LIMIT_WRITE_BURST", 5),
+	}
+	e.Use(middL.RateLimit(_productHttpDeliveryMiddleware.RateLimitConfig{
+		Store: _productHttpDeliveryMiddleware.NewMemoryRateLimitStore(),
+		Default: _productHttpDeliveryMiddleware.RateLimitPolicy{
+			Rate:  envFloat("RATE_LIMIT_RPS", 10),
+			Burst: envInt("RATE_LIMIT_BURST", 20),
+		},
+		Routes: map[string]_productHttpDeliveryMiddleware.RateLimitPolicy{
+			"POST /product": writePolicy,
+			"POST /author":  writePolicy,
+		},
+	}))
+	// the cover uploads are the largest bodies posted, with room for their multipart envelope
+	coverMaxBytes := envInt("COVER_MAX_BYTES", 5<<20)
+	e.Use(middL.Idempotency(_productHttpDeliveryMiddleware.IdempotencyConfig{
+		Store:        _productHttpDeliveryMiddleware.NewMemoryIdempotencyStore(),
+		TTL:          24 * time.Hour,
+		MaxBodyBytes: int64(coverMaxBytes) + 64<<10,
+		// the issued API key is only shown once, it is not kept to be replayed
+		Exclude: map[string]bool{"POST /admin/api-key": true},
+	}))
+
+	// the reads convert the prices from the base currency
+	cyu := _currencyUcase.NewCurrencyUsecase(_currencyRepo.NewMysqlExchangeRateRepository(dbConn), baseCurrency, timeoutContext)
+	_currencyHttpDelivery.NewCurrencyHandler(e, cyu, middL.Auth)
+
+	// the catalog mutations record their events in the outbox table within their
+	// transaction, the relay publishes them to the broker once committed
+	eventRepo := _eventRepo.NewMysqlEventRepository(dbConn)
+	eventBroker := _eventMemoryBroker.NewMemoryBroker()
+	eventRelay := _eventUcase.NewOutboxRelay(eventRepo, eventBroker,
+		time.Duration(envInt("EVENT_RELAY_INTERVAL_SECONDS", 5))*time.Second)
+	go eventRelay.Run(context.Background())
+	emitter := _eventUcase.NewOutboxEmitter(eventRepo, eventRelay)
+	// the dashboards follow the events live, resuming from the latest ones kept in memory
+	eventStream := _eventUcase.NewEventStream(envInt("EVENT_REPLAY_SIZE", 1000))
+	eventBroker.Subscribe("event-stream", eventStream.Handle)
+	_eventHttpDelivery.NewEventHandler(e, eventStream, middL.Auth)
+	// the storefronts follow the price of the products they show, from their
+	// own pages or the ones of the allowed origins
+	productFeed := _feedUcase.NewProductFeed(pr, envInt("PRODUCT_FEED_MAX_CONNECTIONS", 10000),
+		envInt("PRODUCT_FEED_MAX_CONNECTIONS_PER_CLIENT", 10), envInt("PRODUCT_FEED_MAX_SUBSCRIPTIONS", 100), timeoutContext)
+	eventBroker.Subscribe("product-feed", productFeed.Handle)
+	_feedHttpDelivery.NewFeedHandler(e, productFeed, cyu, envList("PRODUCT_FEED_ALLOWED_ORIGINS"))
+
+	// the events are posted to the subscribed webhooks, retried with an
+	// exponential backoff until they are acknowledged or dead
+	webhookRepo := _webhookRepo.NewMysqlWebhookRepository(dbConn)
+	webhookClient := _webhookUcase.NewWebhookClient(10 * time.Second)
+	_webhookHttpDelivery.NewWebhookHandler(e, _webhookUcase.NewWebhookUsecase(webhookRepo, webhookClient, timeoutContext), middL.Auth)
+	webhookDispatcher := _webhookUcase.NewWebhookDispatcher(_webhookUcase.NewWebhookUsecase(webhookRepo, webhookClient, timeoutContext),
+		time.Duration(envInt("WEBHOOK_DISPATCH_INTERVAL_SECONDS", 10))*time.Second)
+	eventBroker.Subscribe("webhook-dispatcher", webhookDispatcher.Handle)
+	go webhookDispatcher.Run(context.Background())
+
+	// the catalog mutations going through the usecases are audited
+	auu := _auditUcase.NewAuditUsecase(_auditRepo.NewMysqlAuditRepository(dbConn), timeoutContext)
+	_auditHttpDelivery.NewAuditHandler(e, auu, middL.Auth)
+	pu := _productAuditUcase.NewAuditProductUsecase(_productUcase.NewProductUsecase(pr, transactor, emitter, timeoutContext), pr, auu, transactor)
+	_productHttpDelivery.NewProductHandler(e, pu, cyu, middL.Auth, middL.RequirePermission)
+	au := _authorAuditUcase.NewAuditAuthorUsecase(_authorUcase.NewAuthorUsecase(ar, transactor, emitter, timeoutContext), ar, auu, transactor)
+	_authorHttDelivery.NewAuthorHandler(e, au, middL.Auth, middL.RequirePermission)
+	// the catalog graph resolves the relations in batches, the queries too
+	// deep or too costly are rejected before they run. The schema is only
+	// introspectable outside of production unless told otherwise
+	graphqlIntrospection := os.Getenv("APP_ENV") != "production"
+	if v := os.Getenv("GRAPHQL_INTROSPECTION"); v != "" {
+		graphqlIntrospection = v == "true"
+	}
+	graphqlSchema, err := _graphqlSchema.NewSchema(pu, au, cyu, _graphqlSchema.Limits{
+		MaxDepth:      envInt("GRAPHQL_MAX_DEPTH", 8),
+		MaxComplexity: envInt("GRAPHQL_MAX_COMPLEXITY", 1000),
+		Introspection: graphqlIntrospection,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	_graphqlHttpDelivery.NewGraphQLHandler(e, graphqlSchema, cyu, os.Getenv("APP_ENV") != "production", middL.OptionalAuth)
+	// shop prices are tax inclusive unless told otherwise
+	pricesIncludeTax := os.Getenv("PRICES_INCLUDE_TAX") != "false"
+	tu := _taxUcase.NewTaxUsecase(_taxRepo.NewMysqlTaxRepository(dbConn), pricesIncludeTax, timeoutContext)
+	_taxHttpDelivery.NewTaxHandler(e, tu, middL.Auth)
+	mu := _promotionUcase.NewPromotionUsecase(_promotionRepo.NewMysqlPromotionRepository(dbConn), pr, tu, timeoutContext)
+	_promotionHttpDelivery.NewPromotionHandler(e, mu, middL.Auth)
+	ou := _orderUcase.NewOrderUsecase(or, pr, mu, timeoutContext)
+	_orderHttpDelivery.NewOrderHandler(e, ou, middL.Auth, middL.RequirePermission)
+	_userHttpDelivery.NewUserHandler(e, uu)
+	_apiKeyHttpDelivery.NewAPIKeyHandler(e, ku, middL.Auth)
+	su := _searchUcase.NewSearchUsecase(sr, timeoutContext)
+	_searchHttpDelivery.NewSearchHandler(e, su, cyu)
+	gu := _suggestUcase.NewSuggestUsecase(suggestIndex, timeoutContext)
+	_suggestHttpDelivery.NewSuggestHandler(e, gu)
+
+	var blobs domain.BlobStorage
+	if os.Getenv("BLOB_BACKEND") == "s3" {
+		blobs = _s3Blob.NewS3BlobStorage(_s3Blob.Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		})
+	} else {
+		blobDir := os.Getenv("BLOB_LOCAL_DIR")
+		if blobDir == "" {
+			blobDir = "storage"
+		}
+		blobs = _localBlob.NewLocalBlobStorage(blobDir)
+	}
+	cu := _coverUcase.NewCoverUsecase(pr, blobs, coverMaxBytes, 30*time.Second)
+	_coverHttpDelivery.NewCoverHandler(e, cu, middL.Auth, coverMaxBytes)
+	// the rating aggregates live on the product, its listeners learn about rating changes
+	rr := _reviewRepo.NewMysqlReviewRepository(dbConn)
+	ru := _reviewUcase.NewReviewUsecase(rr, pr, timeoutContext, catalogListeners...)
+	_reviewHttpDelivery.NewReviewHandler(e, ru, middL.Auth)
+	wu := _wishlistUcase.NewWishlistUsecase(wr, pr, timeoutContext)
+	_wishlistHttpDelivery.NewWishlistHandler(e, wu, cyu, middL.Auth, middL.OptionalAuth)
+	priceRepo := _priceRepo.NewMysqlPriceRepository(dbConn)
+	_priceHttpDelivery.NewPriceHandler(e, _priceUcase.NewPriceUsecase(priceRepo, pr, transactor, emitter, timeoutContext), middL.Auth)
+	// a run may apply a whole batch of changes, it gets more time than a request
+	priceScheduler := _priceUcase.NewPriceScheduler(_priceUcase.NewPriceUsecase(priceRepo, pr, transactor, emitter, time.Minute),
+		time.Duration(envInt("PRICE_SCHEDULER_INTERVAL_SECONDS", 30))*time.Second)
+	go priceScheduler.Run(context.Background())
 	e.Logger.Fatal(e.Start(":" + Port))
 }
+
+// ipExtractor tell the client IP from the connection, or from X-Forwarded-For
+// when the connection comes from one of the trusted proxies, comma separated CIDRs
+func ipExtractor(trustedProxies string) echo.IPExtractor {
+	if trustedProxies == "" {
+		return echo.ExtractIPDirect()
+	}
+	opts := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, cidr := range strings.Split(trustedProxies, ",") {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, echo.TrustIPRange(ipNet))
+	}
+	return echo.ExtractIPFromXFFHeader(opts...)
+}
+
+// envList read a comma separated environment variable, empty when unset
+func envList(key string) []string {
+	values := []string{}
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// envFloat read a float environment variable, falling back to def when unset or invalid
+func envFloat(key string, def float64) float64 {
+	v, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// envInt read an int environment variable, falling back to def when unset or invalid
+func envInt(key string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
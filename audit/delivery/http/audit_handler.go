@@ -0,0 +1,84 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+)
+
+// AuditHandler represent the httphandler for the audit log
+type AuditHandler struct {
+	AUsecase domain.AuditUsecase
+}
+
+// NewAuditHandler will initialize the audit endpoint
+func NewAuditHandler(e *echo.Echo, us domain.AuditUsecase, auth echo.MiddlewareFunc) {
+	handler := &AuditHandler{
+		AUsecase: us,
+	}
+	e.GET("/audit", handler.Fetch, auth)
+}
+
+// Fetch will fetch a page of the audit entries of the entity and id query
+// params, the latest first. The next page starts before the next cursor,
+// passed back in the before query param, and an empty page ends the log
+func (a *AuditHandler) Fetch(c echo.Context) error {
+	filter := domain.AuditFilter{
+		EntityType: c.QueryParam("entity"),
+		EntityID:   c.QueryParam("id"),
+	}
+	var err error
+	if v := c.QueryParam("before"); v != "" {
+		if filter.Before, err = strconv.Atoi(v); err != nil {
+			return failed(c, domain.ErrBadParamInput)
+		}
+	}
+	if v := c.QueryParam("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			return failed(c, domain.ErrBadParamInput)
+		}
+	}
+	ctx := c.Request().Context()
+	entries, err := a.AUsecase.Fetch(ctx, filter)
+	if err != nil {
+		return failed(c, err)
+	}
+	res := response.ResponsePaginated{Success: true, Data: entries}
+	if len(entries) > 0 {
+		res.Next = strconv.Itoa(entries[len(entries)-1].ID)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// failed write the error response of err, a denied permission is described with a problem detail
+func failed(c echo.Context, err error) error {
+	status := getStatusCode(err)
+	if status == http.StatusForbidden {
+		c.Response().Header().Set(echo.HeaderContentType, response.ProblemContentType)
+		return c.JSON(status, response.NewProblem(status, err.Error()))
+	}
+	return c.JSON(status, response.ResponseFailed{Success: false, Message: err.Error()})
+}
+
+func getStatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	logrus.Error(err)
+	switch {
+	case errors.Is(err, domain.ErrBadParamInput):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,94 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/sqltx"
+)
+
+// mysqlAuditRepository represent the connection database struct
+type mysqlAuditRepository struct {
+	Conn *sql.DB
+}
+
+// NewMysqlAuditRepository will create an object that represent the audit Repository interface
+func NewMysqlAuditRepository(Conn *sql.DB) domain.AuditRepository {
+	return &mysqlAuditRepository{Conn: Conn}
+}
+
+func (m *mysqlAuditRepository) Fetch(ctx context.Context, filter domain.AuditFilter) (result []domain.AuditEntry, err error) {
+	query := `SELECT id, actor, action, entity_type, entity_id, changes, request_id, created_at FROM audit_log`
+	conds := []string{}
+	args := []interface{}{}
+	if filter.EntityType != "" {
+		conds = append(conds, "entity_type=?")
+		args = append(args, filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		conds = append(conds, "entity_id=?")
+		args = append(args, filter.EntityID)
+	}
+	if filter.Before > 0 {
+		conds = append(conds, "id<?")
+		args = append(args, filter.Before)
+	}
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, filter.Limit)
+
+	rows, err := m.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.AuditEntry, 0)
+	for rows.Next() {
+		t := domain.AuditEntry{}
+		var changes []byte
+		err = rows.Scan(&t.ID, &t.Actor, &t.Action, &t.EntityType, &t.EntityID, &changes, &t.RequestID, &t.CreatedAt)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		if err = json.Unmarshal(changes, &t.Changes); err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+func (m *mysqlAuditRepository) Store(ctx context.Context, e *domain.AuditEntry) error {
+	changes, err := json.Marshal(e.Changes)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO audit_log (actor, action, entity_type, entity_id, changes, request_id, created_at) VALUES(?,?,?,?,?,?,?)`
+	now := time.Now()
+	result, err := sqltx.From(ctx, m.Conn).ExecContext(ctx, query, e.Actor, e.Action, e.EntityType, e.EntityID, changes, e.RequestID, now)
+	if err != nil {
+		return err
+	}
+	lastID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	e.ID = int(lastID)
+	e.CreatedAt = now
+	return nil
+}
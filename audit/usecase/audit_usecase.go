@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+const (
+	// defaultAuditLimit is the page size when none is asked
+	defaultAuditLimit = 50
+	// maxAuditLimit bound the page size
+	maxAuditLimit = 200
+)
+
+// AuditUsecase represent the audit use case struct
+type AuditUsecase struct {
+	auditRepo      domain.AuditRepository
+	contextTimeout time.Duration
+}
+
+// NewAuditUsecase will create new an audit usecase object representation of domain.AuditUsecase interface
+func NewAuditUsecase(a domain.AuditRepository, timeout time.Duration) domain.AuditUsecase {
+	return &AuditUsecase{
+		auditRepo:      a,
+		contextTimeout: timeout,
+	}
+}
+
+// Fetch will get a page of the audit entries matching the filter, the latest first
+func (u *AuditUsecase) Fetch(c context.Context, filter domain.AuditFilter) (res []domain.AuditEntry, err error) {
+	if err = domain.Authorize(c, domain.PermissionAuditRead); err != nil {
+		return
+	}
+	if filter.EntityID != "" && filter.EntityType == "" {
+		return nil, domain.ErrBadParamInput
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	return u.auditRepo.Fetch(ctx, filter)
+}
+
+// Record will append the entry of a mutation, the actor and the request id are taken from ctx
+func (u *AuditUsecase) Record(c context.Context, action domain.AuditAction, entityType string, entityID string, before interface{}, after interface{}) error {
+	changes, err := diff(before, after)
+	if err != nil {
+		return err
+	}
+	principal, _ := domain.PrincipalFromContext(c)
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	return u.auditRepo.Store(ctx, &domain.AuditEntry{
+		Actor:      principal.Subject,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changes,
+		RequestID:  domain.RequestIDFromContext(c),
+	})
+}
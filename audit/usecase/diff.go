@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// ignoredFields are bookkeeping or derived fields, they change along with the
+// entity without being part of what was mutated
+var ignoredFields = map[string]bool{
+	"updated_at":     true,
+	"created_at":     true,
+	"author":         true,
+	"thumbnails":     true,
+	"rating_average": true,
+	"rating_count":   true,
+	"currency":       true,
+}
+
+// fields turn an entity into its JSON fields, nil having none
+func fields(v interface{}) (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	if v == nil {
+		return m, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// diff compare the JSON fields of an entity before and after a mutation and
+// return those that changed
+func diff(before interface{}, after interface{}) (map[string]domain.AuditChange, error) {
+	from, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+	changes := map[string]domain.AuditChange{}
+	for name, value := range from {
+		if !ignoredFields[name] && !reflect.DeepEqual(value, to[name]) {
+			changes[name] = domain.AuditChange{Before: value, After: to[name]}
+		}
+	}
+	for name, value := range to {
+		if _, ok := from[name]; !ok && !ignoredFields[name] {
+			changes[name] = domain.AuditChange{Before: nil, After: value}
+		}
+	}
+	return changes, nil
+}
@@ -3,32 +3,32 @@ package http
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/conditional"
 	"github.com/wdwiramadhan/bookhub-api/helper/response"
 	"gopkg.in/go-playground/validator.v9"
 )
 
-var successResponse response.ResponseSuccess = response.ResponseSuccess{Success: true, Data: nil}
-var failedResponse response.ResponseFailed = response.ResponseFailed{Success: false, Message: ""}
-
 // AuthorHandler represent the httphandler for product
 type AuthorHandler struct {
 	AUsecase domain.AuthorUsecase
 }
 
-// NewAuthorHandler will initialize the author endpoint
-func NewAuthorHandler(e *echo.Echo, us domain.AuthorUsecase) {
+// NewAuthorHandler will initialize the author endpoint, the writes require
+// their permission on top of auth
+func NewAuthorHandler(e *echo.Echo, us domain.AuthorUsecase, auth echo.MiddlewareFunc, require func(domain.Permission) echo.MiddlewareFunc) {
 	handler := &AuthorHandler{
 		AUsecase: us,
 	}
 	e.GET("/author", handler.Fetch)
-	e.POST("/author", handler.Store)
+	e.POST("/author", handler.Store, auth, require(domain.PermissionAuthorWrite))
 	e.GET("/author/:authorId", handler.GetAuthorById)
-	e.PUT("/author/:authorId", handler.UpdateAuthorById)
-	e.DELETE("/author/:authorId", handler.DeleteAuthorById)
+	e.PUT("/author/:authorId", handler.UpdateAuthorById, auth, require(domain.PermissionAuthorWrite))
+	e.DELETE("/author/:authorId", handler.DeleteAuthorById, auth, require(domain.PermissionAuthorDelete))
 }
 
 // Fetch will fetch the author based on given params
@@ -36,11 +36,16 @@ func (a *AuthorHandler) Fetch(c echo.Context) error {
 	ctx := c.Request().Context()
 	authors, err := a.AUsecase.Fetch(ctx)
 	if err != nil {
-		failedResponse.Message = err.Error()
-		return c.JSON(getStatusCode(err), failedResponse)
+		return failed(c, err)
+	}
+	var modified time.Time
+	for _, author := range authors {
+		modified = conditional.Latest(modified, author.UpdatedAt)
 	}
-	successResponse.Data = authors
-	return c.JSON(http.StatusOK, successResponse)
+	if conditional.NotModified(c, modified, len(authors)) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: authors})
 }
 
 func (a *AuthorHandler) Store(c echo.Context) (err error) {
@@ -56,11 +61,10 @@ func (a *AuthorHandler) Store(c echo.Context) (err error) {
 	ctx := c.Request().Context()
 	err = a.AUsecase.Store(ctx, &author)
 	if err != nil {
-		failedResponse.Message = err.Error()
-		return c.JSON(getStatusCode(err), failedResponse)
+		return failed(c, err)
 	}
 
-	return c.JSON(http.StatusCreated, successResponse)
+	return c.JSON(http.StatusCreated, response.ResponseSuccess{Success: true})
 }
 
 func (a *AuthorHandler) GetAuthorById(c echo.Context) (err error) {
@@ -68,11 +72,12 @@ func (a *AuthorHandler) GetAuthorById(c echo.Context) (err error) {
 	authorId, _ := strconv.Atoi(c.Param("authorId"))
 	author, err := a.AUsecase.GetAuthorById(ctx, authorId)
 	if err != nil {
-		failedResponse.Message = err.Error()
-		return c.JSON(getStatusCode(err), failedResponse)
+		return failed(c, err)
+	}
+	if conditional.NotModified(c, author.UpdatedAt, 1) {
+		return c.NoContent(http.StatusNotModified)
 	}
-	successResponse.Data = author
-	return c.JSON(http.StatusOK, successResponse)
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: author})
 }
 
 func (a *AuthorHandler) UpdateAuthorById(c echo.Context) (err error) {
@@ -85,10 +90,9 @@ func (a *AuthorHandler) UpdateAuthorById(c echo.Context) (err error) {
 	ctx := c.Request().Context()
 	err = a.AUsecase.UpdateAuthorById(ctx, authorId, &author)
 	if err != nil {
-		failedResponse.Message = err.Error()
-		return c.JSON(getStatusCode(err), failedResponse)
+		return failed(c, err)
 	}
-	return c.JSON(http.StatusCreated, successResponse)
+	return c.JSON(http.StatusCreated, response.ResponseSuccess{Success: true})
 }
 
 func (a *AuthorHandler) DeleteAuthorById(c echo.Context) (err error) {
@@ -96,10 +100,9 @@ func (a *AuthorHandler) DeleteAuthorById(c echo.Context) (err error) {
 	ctx := c.Request().Context()
 	err = a.AUsecase.DeleteAuthorById(ctx, authorId)
 	if err != nil {
-		failedResponse.Message = err.Error()
-		return c.JSON(getStatusCode(err), failedResponse)
+		return failed(c, err)
 	}
-	return c.JSON(http.StatusCreated, successResponse)
+	return c.JSON(http.StatusCreated, response.ResponseSuccess{Success: true})
 }
 
 func isRequestValid(m *domain.Author) (bool, error) {
@@ -111,6 +114,16 @@ func isRequestValid(m *domain.Author) (bool, error) {
 	return true, nil
 }
 
+// failed write the error response of err, a denied permission is described with a problem detail
+func failed(c echo.Context, err error) error {
+	status := getStatusCode(err)
+	if status == http.StatusForbidden {
+		c.Response().Header().Set(echo.HeaderContentType, response.ProblemContentType)
+		return c.JSON(status, response.NewProblem(status, err.Error()))
+	}
+	return c.JSON(status, response.ResponseFailed{Success: false, Message: err.Error()})
+}
+
 func getStatusCode(err error) int {
 	if err == nil {
 		return http.StatusOK
@@ -124,6 +137,10 @@ func getStatusCode(err error) int {
 		return http.StatusNotFound
 	case domain.ErrConflict:
 		return http.StatusConflict
+	case domain.ErrUnauthorized:
+		return http.StatusUnauthorized
+	case domain.ErrForbidden:
+		return http.StatusForbidden
 	default:
 		return http.StatusInternalServerError
 	}
@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/cache"
+	"github.com/wdwiramadhan/bookhub-api/helper/sqltx"
+)
+
+// cacheAuthorRepository serve the author lookups by id from an in-process cache
+type cacheAuthorRepository struct {
+	domain.AuthorRepository
+	cache *cache.Cache
+}
+
+// NewCacheAuthorRepository will wrap a domain.AuthorRepository with a read-through cache
+func NewCacheAuthorRepository(next domain.AuthorRepository, c *cache.Cache) domain.AuthorRepository {
+	return &cacheAuthorRepository{AuthorRepository: next, cache: c}
+}
+
+func key(id int) string {
+	return strconv.Itoa(id)
+}
+
+// GetAuthorById serve the author from the cache, unless in a transaction
+// where the author is read back from the database along with the uncommitted changes
+func (c *cacheAuthorRepository) GetAuthorById(ctx context.Context, authorId int) (domain.Author, error) {
+	if _, ok := sqltx.Tx(ctx); ok {
+		return c.AuthorRepository.GetAuthorById(ctx, authorId)
+	}
+	v, err := c.cache.GetOrLoad(ctx, key(authorId), func(ctx context.Context) (interface{}, error) {
+		return c.AuthorRepository.GetAuthorById(ctx, authorId)
+	})
+	if err != nil {
+		return domain.Author{}, err
+	}
+	return v.(domain.Author), nil
+}
+
+// invalidate drop the cached author, again once the transaction of ctx
+// commits as it may be loaded back meanwhile
+func (c *cacheAuthorRepository) invalidate(ctx context.Context, authorId int) {
+	c.cache.Delete(key(authorId))
+	sqltx.AfterCommit(ctx, func() {
+		c.cache.Delete(key(authorId))
+	})
+}
+
+func (c *cacheAuthorRepository) UpdateAuthorById(ctx context.Context, authorId int, dataAuthor *domain.Author) error {
+	defer c.invalidate(ctx, authorId)
+	return c.AuthorRepository.UpdateAuthorById(ctx, authorId, dataAuthor)
+}
+
+func (c *cacheAuthorRepository) DeleteAuthorById(ctx context.Context, authorId int) error {
+	defer c.invalidate(ctx, authorId)
+	return c.AuthorRepository.DeleteAuthorById(ctx, authorId)
+}
@@ -3,11 +3,13 @@ package mysql
 import (
 	"context"
 	"database/sql"
-	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/sqltx"
 )
 
 // mysqlAuthorRepository represent the connection database struct
@@ -21,7 +23,7 @@ func NewMysqlAuthorRepository(Conn *sql.DB) domain.AuthorRepository {
 }
 
 func (m *mysqlAuthorRepository) fetch(ctx context.Context, query string, args ...interface{}) (result []domain.Author, err error) {
-	rows, err := m.Conn.QueryContext(ctx, query, args...)
+	rows, err := sqltx.From(ctx, m.Conn).QueryContext(ctx, query, args...)
 	if err != nil {
 		logrus.Error(err)
 		return nil, err
@@ -60,18 +62,38 @@ func (m *mysqlAuthorRepository) Fetch(ctx context.Context) (res []domain.Author,
 	return
 }
 
+func (m *mysqlAuthorRepository) FetchByIDs(ctx context.Context, ids []int) (res []domain.Author, err error) {
+	if len(ids) == 0 {
+		return []domain.Author{}, nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	query := `SELECT id, name, date_of_birth, updated_at, created_at FROM author WHERE id IN (?` + strings.Repeat(",?", len(ids)-1) + `)`
+	res, err = m.fetch(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
 func (m *mysqlAuthorRepository) Store(ctx context.Context, dataAuthor *domain.Author) (err error) {
 	query := `INSERT INTO author (name, date_of_birth, updated_at, created_at) VALUES(?,?,?,?)`
-	stmt, err := m.Conn.PrepareContext(ctx, query)
-	fmt.Println(err)
+	stmt, err := sqltx.From(ctx, m.Conn).PrepareContext(ctx, query)
 	if err != nil {
 		return err
 	}
 	dateOfBirth, _ := time.Parse("2006-01-02", dataAuthor.DateOfBirth)
-	_, err = stmt.ExecContext(ctx, dataAuthor.Name, dateOfBirth, time.Now(), time.Now())
+	result, err := stmt.ExecContext(ctx, dataAuthor.Name, dateOfBirth, time.Now(), time.Now())
+	if err != nil {
+		return
+	}
+	lastID, err := result.LastInsertId()
 	if err != nil {
 		return
 	}
+	dataAuthor.ID = strconv.FormatInt(lastID, 10)
 	return
 }
 
@@ -89,9 +111,20 @@ func (m *mysqlAuthorRepository) GetAuthorById(ctx context.Context, authorId int)
 	return
 }
 
+func (m *mysqlAuthorRepository) GetAuthorByIdForUpdate(ctx context.Context, authorId int) (res domain.Author, err error) {
+	list, err := m.fetch(ctx, `SELECT id, name, date_of_birth, updated_at, created_at FROM author WHERE id=? FOR UPDATE`, authorId)
+	if err != nil {
+		return
+	}
+	if len(list) == 0 {
+		return res, domain.ErrNotFound
+	}
+	return list[0], nil
+}
+
 func (m *mysqlAuthorRepository) UpdateAuthorById(ctx context.Context, authorId int, dataAuthor *domain.Author) (err error) {
 	query := `UPDATE author SET name=?, date_of_birth=?, updated_at=? WHERE id=?`
-	stmt, err := m.Conn.PrepareContext(ctx, query)
+	stmt, err := sqltx.From(ctx, m.Conn).PrepareContext(ctx, query)
 	if err != nil {
 		return
 	}
@@ -104,7 +137,7 @@ func (m *mysqlAuthorRepository) UpdateAuthorById(ctx context.Context, authorId i
 
 func (m *mysqlAuthorRepository) DeleteAuthorById(ctx context.Context, authorId int) (err error) {
 	query := `DElETE FROM author WHERE id=?`
-	stmt, err := m.Conn.PrepareContext(ctx, query)
+	stmt, err := sqltx.From(ctx, m.Conn).PrepareContext(ctx, query)
 	if err != nil {
 		return
 	}
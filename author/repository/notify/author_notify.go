@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/sqltx"
+)
+
+// notifyAuthorRepository notify the catalog listeners of every successful author mutation
+type notifyAuthorRepository struct {
+	domain.AuthorRepository
+	listeners []domain.CatalogListener
+}
+
+// NewNotifyAuthorRepository will wrap a domain.AuthorRepository so that the listeners are notified of its mutations
+func NewNotifyAuthorRepository(next domain.AuthorRepository, listeners ...domain.CatalogListener) domain.AuthorRepository {
+	return &notifyAuthorRepository{AuthorRepository: next, listeners: listeners}
+}
+
+// notify tell the listeners about the change once it is committed, the
+// listeners reading the catalog back must not see it before
+func (n *notifyAuthorRepository) notify(ctx context.Context, id int) {
+	sqltx.AfterCommit(ctx, func() {
+		for _, l := range n.listeners {
+			l.AuthorChanged(ctx, id)
+		}
+	})
+}
+
+func (n *notifyAuthorRepository) Store(ctx context.Context, dataAuthor *domain.Author) (err error) {
+	if err = n.AuthorRepository.Store(ctx, dataAuthor); err != nil {
+		return
+	}
+	id, _ := strconv.Atoi(dataAuthor.ID)
+	n.notify(ctx, id)
+	return
+}
+
+func (n *notifyAuthorRepository) UpdateAuthorById(ctx context.Context, authorId int, dataAuthor *domain.Author) (err error) {
+	if err = n.AuthorRepository.UpdateAuthorById(ctx, authorId, dataAuthor); err != nil {
+		return
+	}
+	n.notify(ctx, authorId)
+	return
+}
+
+func (n *notifyAuthorRepository) DeleteAuthorById(ctx context.Context, authorId int) (err error) {
+	if err = n.AuthorRepository.DeleteAuthorById(ctx, authorId); err != nil {
+		return
+	}
+	n.notify(ctx, authorId)
+	return
+}
@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// auditAuthorUsecase record an audit entry of every successful author mutation
+type auditAuthorUsecase struct {
+	domain.AuthorUsecase
+	authorRepo domain.AuthorRepository
+	audit      domain.AuditUsecase
+	transactor domain.Transactor
+}
+
+// NewAuditAuthorUsecase will wrap a domain.AuthorUsecase so that its mutations are audited,
+// the entry being written in the transaction of the mutation. The author is
+// read and locked through r before the mutation, so that no other writer
+// changes it between the read and the mutation
+func NewAuditAuthorUsecase(next domain.AuthorUsecase, r domain.AuthorRepository, a domain.AuditUsecase, t domain.Transactor) domain.AuthorUsecase {
+	return &auditAuthorUsecase{AuthorUsecase: next, authorRepo: r, audit: a, transactor: t}
+}
+
+func (u *auditAuthorUsecase) record(ctx context.Context, action domain.AuditAction, id string, before interface{}, after interface{}) error {
+	return u.audit.Record(ctx, action, domain.EntityAuthor, id, before, after)
+}
+
+func (u *auditAuthorUsecase) Store(c context.Context, dataAuthor *domain.Author) error {
+	return u.transactor.WithinTransaction(c, func(ctx context.Context) error {
+		if err := u.AuthorUsecase.Store(ctx, dataAuthor); err != nil {
+			return err
+		}
+		id, err := strconv.Atoi(dataAuthor.ID)
+		if err != nil {
+			return err
+		}
+		after, err := u.AuthorUsecase.GetAuthorById(ctx, id)
+		if err != nil {
+			return err
+		}
+		return u.record(ctx, domain.AuditCreate, dataAuthor.ID, nil, after)
+	})
+}
+
+func (u *auditAuthorUsecase) UpdateAuthorById(c context.Context, authorId int, dataAuthor *domain.Author) error {
+	return u.transactor.WithinTransaction(c, func(ctx context.Context) error {
+		before, err := u.authorRepo.GetAuthorByIdForUpdate(ctx, authorId)
+		if err != nil {
+			return err
+		}
+		if err = u.AuthorUsecase.UpdateAuthorById(ctx, authorId, dataAuthor); err != nil {
+			return err
+		}
+		after, err := u.AuthorUsecase.GetAuthorById(ctx, authorId)
+		if err != nil {
+			return err
+		}
+		return u.record(ctx, domain.AuditUpdate, strconv.Itoa(authorId), before, after)
+	})
+}
+
+func (u *auditAuthorUsecase) DeleteAuthorById(c context.Context, authorId int) error {
+	return u.transactor.WithinTransaction(c, func(ctx context.Context) error {
+		before, err := u.authorRepo.GetAuthorByIdForUpdate(ctx, authorId)
+		if err != nil {
+			return err
+		}
+		if err = u.AuthorUsecase.DeleteAuthorById(ctx, authorId); err != nil {
+			return err
+		}
+		return u.record(ctx, domain.AuditDelete, strconv.Itoa(authorId), before, nil)
+	})
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"github.com/wdwiramadhan/bookhub-api/domain"
@@ -10,17 +11,31 @@ import (
 // AuthorUsecase represent the author use case struct
 type AuthorUsecase struct {
 	authorRepo     domain.AuthorRepository
+	transactor     domain.Transactor
+	events         domain.EventEmitter
 	contextTimeout time.Duration
 }
 
-// NewAuthorUsecase will create new an author usecase object representation of domain.AuthorUsecase interface
-func NewAuthorUsecase(a domain.AuthorRepository, timeout time.Duration) domain.AuthorUsecase {
+// NewAuthorUsecase will create new an author usecase object representation of domain.AuthorUsecase interface,
+// the mutations emit their events in the transaction making them
+func NewAuthorUsecase(a domain.AuthorRepository, tx domain.Transactor, events domain.EventEmitter, timeout time.Duration) domain.AuthorUsecase {
 	return &AuthorUsecase{
 		authorRepo:     a,
+		transactor:     tx,
+		events:         events,
 		contextTimeout: timeout,
 	}
 }
 
+// emit record an event about the author, in the transaction of ctx
+func (a *AuthorUsecase) emit(ctx context.Context, eventType string, id string, payload interface{}) error {
+	e, err := domain.NewEvent(eventType, domain.EntityAuthor, id, payload)
+	if err != nil {
+		return err
+	}
+	return a.events.Emit(ctx, e)
+}
+
 // Fetch will get authors
 func (a *AuthorUsecase) Fetch(c context.Context) (res []domain.Author, err error) {
 	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
@@ -32,17 +47,42 @@ func (a *AuthorUsecase) Fetch(c context.Context) (res []domain.Author, err error
 	return
 }
 
-// Store will create new author
-func (a *AuthorUsecase) Store(c context.Context, dataAuthor *domain.Author) (err error) {
+// FetchByIDs will get the authors of the ids in one query
+func (a *AuthorUsecase) FetchByIDs(c context.Context, ids []int) (res []domain.Author, err error) {
 	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
 	defer cancel()
-	err = a.authorRepo.Store(ctx, dataAuthor)
+	res, err = a.authorRepo.FetchByIDs(ctx, ids)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	return
 }
 
+// Store will create new author
+func (a *AuthorUsecase) Store(c context.Context, dataAuthor *domain.Author) (err error) {
+	if err = domain.Authorize(c, domain.PermissionAuthorWrite); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+	return a.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := a.authorRepo.Store(ctx, dataAuthor); err != nil {
+			return err
+		}
+		id, err := strconv.Atoi(dataAuthor.ID)
+		if err != nil {
+			return err
+		}
+		// the author is read back as stored, so that the event carries all of it
+		stored, err := a.authorRepo.GetAuthorById(ctx, id)
+		if err != nil {
+			return err
+		}
+		*dataAuthor = stored
+		return a.emit(ctx, domain.EventAuthorCreated, dataAuthor.ID, dataAuthor)
+	})
+}
+
 func (a *AuthorUsecase) GetAuthorById(c context.Context, authorId int) (res domain.Author, err error) {
 	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
 	defer cancel()
@@ -54,21 +94,39 @@ func (a *AuthorUsecase) GetAuthorById(c context.Context, authorId int) (res doma
 }
 
 func (a *AuthorUsecase) UpdateAuthorById(c context.Context, authorId int, dataAuthor *domain.Author) (err error) {
-	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
-	defer cancel()
-	err = a.authorRepo.UpdateAuthorById(ctx, authorId, dataAuthor)
-	if err != nil {
+	if err = domain.Authorize(c, domain.PermissionAuthorWrite); err != nil {
 		return
 	}
-	return
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+	return a.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := a.authorRepo.UpdateAuthorById(ctx, authorId, dataAuthor); err != nil {
+			return err
+		}
+		// the author is read back as stored, so that the event carries all of it
+		stored, err := a.authorRepo.GetAuthorById(ctx, authorId)
+		if err != nil {
+			return err
+		}
+		*dataAuthor = stored
+		return a.emit(ctx, domain.EventAuthorUpdated, dataAuthor.ID, dataAuthor)
+	})
 }
 
 func (a *AuthorUsecase) DeleteAuthorById(c context.Context, authorId int) (err error) {
+	if err = domain.Authorize(c, domain.PermissionAuthorDelete); err != nil {
+		return
+	}
 	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
 	defer cancel()
-	err = a.authorRepo.DeleteAuthorById(ctx, authorId)
+	existing, err := a.authorRepo.GetAuthorById(ctx, authorId)
 	if err != nil {
 		return
 	}
-	return
+	return a.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := a.authorRepo.DeleteAuthorById(ctx, authorId); err != nil {
+			return err
+		}
+		return a.emit(ctx, domain.EventAuthorDeleted, existing.ID, existing)
+	})
 }
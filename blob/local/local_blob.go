@@ -0,0 +1,103 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// localBlobStorage keep the blobs as files under a root directory
+type localBlobStorage struct {
+	root string
+}
+
+// NewLocalBlobStorage will create an object that represent the domain.BlobStorage interface
+func NewLocalBlobStorage(root string) domain.BlobStorage {
+	return &localBlobStorage{root: root}
+}
+
+// path map a key to its file, keys escaping the root are rejected
+func (l *localBlobStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || clean != "/"+key || strings.Contains(key, "\\") {
+		return "", domain.ErrBadParamInput
+	}
+	return filepath.Join(l.root, filepath.FromSlash(clean)), nil
+}
+
+func (l *localBlobStorage) Put(ctx context.Context, key string, contentType string, data []byte) (err error) {
+	name, err := l.path(key)
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return
+	}
+	// the blob is written aside then renamed so that readers never see a partial file
+	f, err := ioutil.TempFile(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+	if _, err = bytes.NewReader(data).WriteTo(f); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	if err = os.Chmod(f.Name(), 0644); err != nil {
+		return
+	}
+	return os.Rename(f.Name(), name)
+}
+
+func (l *localBlobStorage) Get(ctx context.Context, key string) (res domain.Blob, err error) {
+	name, err := l.path(key)
+	if err != nil {
+		return
+	}
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return res, domain.ErrNotFound
+	}
+	if err != nil {
+		return
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return
+	}
+	if info.IsDir() {
+		f.Close()
+		return res, domain.ErrNotFound
+	}
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return domain.Blob{Body: f, ContentType: contentType, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+func (l *localBlobStorage) Delete(ctx context.Context, key string) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
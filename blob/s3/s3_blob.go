@@ -0,0 +1,162 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// emptyPayloadHash is the hash of the body of the requests without a body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// Config represent the settings of an S3-compatible bucket
+type Config struct {
+	// Endpoint is the base URL of the service, e.g. https://s3.eu-west-1.amazonaws.com or http://localhost:9000
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// s3BlobStorage keep the blobs in an S3-compatible bucket, addressed path-style
+// and authenticated with AWS Signature Version 4
+type s3BlobStorage struct {
+	cfg    Config
+	client *http.Client
+}
+
+// NewS3BlobStorage will create an object that represent the domain.BlobStorage interface
+func NewS3BlobStorage(cfg Config) domain.BlobStorage {
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	return &s3BlobStorage{cfg: cfg, client: &http.Client{Timeout: time.Minute}}
+}
+
+func (s *s3BlobStorage) do(ctx context.Context, method, key string, contentType string, body []byte) (*http.Response, error) {
+	payloadHash := emptyPayloadHash
+	if body != nil {
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.cfg.Endpoint+"/"+escapePath(s.cfg.Bucket+"/"+key), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, payloadHash, time.Now().UTC())
+	return s.client.Do(req)
+}
+
+// sign add the Signature Version 4 authorization of the request
+func (s *s3BlobStorage) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		"host:" + req.URL.Host + "\nx-amz-content-sha256:" + payloadHash + "\nx-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.cfg.Region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), date)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// escapePath URI-encode every byte of the path but the unreserved characters and the slashes
+func escapePath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// responseError turn an unexpected response into an error, consuming its body
+func responseError(res *http.Response) error {
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return domain.ErrNotFound
+	}
+	msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("s3: %s: %s", res.Status, msg)
+}
+
+func (s *s3BlobStorage) Put(ctx context.Context, key string, contentType string, data []byte) error {
+	res, err := s.do(ctx, http.MethodPut, key, contentType, data)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return responseError(res)
+	}
+	return res.Body.Close()
+}
+
+func (s *s3BlobStorage) Get(ctx context.Context, key string) (domain.Blob, error) {
+	res, err := s.do(ctx, http.MethodGet, key, "", nil)
+	if err != nil {
+		return domain.Blob{}, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return domain.Blob{}, responseError(res)
+	}
+	size, _ := strconv.ParseInt(res.Header.Get("Content-Length"), 10, 64)
+	modTime, _ := http.ParseTime(res.Header.Get("Last-Modified"))
+	return domain.Blob{
+		Body:        res.Body,
+		ContentType: res.Header.Get("Content-Type"),
+		Size:        size,
+		ModTime:     modTime,
+	}, nil
+}
+
+func (s *s3BlobStorage) Delete(ctx context.Context, key string) error {
+	res, err := s.do(ctx, http.MethodDelete, key, "", nil)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return responseError(res)
+	}
+	return res.Body.Close()
+}
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/conditional"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+)
+
+// multipartOverhead is the room left for the multipart framing around the file
+const multipartOverhead = 64 * 1024
+
+// CoverHandler represent the httphandler for cover images
+type CoverHandler struct {
+	CUsecase domain.CoverUsecase
+	maxBytes int
+}
+
+// NewCoverHandler will initialize the cover endpoints, uploads are limited to maxBytes
+func NewCoverHandler(e *echo.Echo, us domain.CoverUsecase, auth echo.MiddlewareFunc, maxBytes int) {
+	handler := &CoverHandler{
+		CUsecase: us,
+		maxBytes: maxBytes,
+	}
+	e.POST("/product/:productId/cover", handler.Upload, auth)
+	e.GET(domain.ImageURLPrefix+"*", handler.Serve)
+}
+
+// Upload will store the cover sent as the "cover" file of a multipart form
+func (h *CoverHandler) Upload(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("productId"))
+	limit := int64(h.maxBytes + multipartOverhead)
+	req := c.Request()
+	if req.ContentLength > limit {
+		return failed(c, domain.ErrTooLarge)
+	}
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, limit)
+	file, err := c.FormFile("cover")
+	if err != nil {
+		return failed(c, domain.ErrBadParamInput)
+	}
+	src, err := file.Open()
+	if err != nil {
+		return failed(c, err)
+	}
+	defer src.Close()
+	// one byte past the limit is enough to tell the upload is too large
+	data, err := ioutil.ReadAll(io.LimitReader(src, int64(h.maxBytes)+1))
+	if err != nil {
+		return failed(c, err)
+	}
+
+	product, err := h.CUsecase.Upload(req.Context(), id, data)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusCreated, response.ResponseSuccess{Success: true, Data: product})
+}
+
+// Serve will stream a stored image, its URL changes with its content
+func (h *CoverHandler) Serve(c echo.Context) error {
+	blob, err := h.CUsecase.Open(c.Request().Context(), c.Param("*"))
+	if err != nil {
+		return failed(c, err)
+	}
+	defer blob.Body.Close()
+
+	if conditional.NotModified(c, blob.ModTime, 1) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	header := c.Response().Header()
+	header.Set("X-Content-Type-Options", "nosniff")
+	if blob.Size > 0 {
+		header.Set(echo.HeaderContentLength, strconv.FormatInt(blob.Size, 10))
+	}
+	return c.Stream(http.StatusOK, blob.ContentType, blob.Body)
+}
+
+func failed(c echo.Context, err error) error {
+	status := getStatusCode(err)
+	if status == http.StatusForbidden {
+		c.Response().Header().Set(echo.HeaderContentType, response.ProblemContentType)
+		return c.JSON(status, response.NewProblem(status, err.Error()))
+	}
+	return c.JSON(status, response.ResponseFailed{Success: false, Message: err.Error()})
+}
+
+func getStatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	logrus.Error(err)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrBadParamInput):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, domain.ErrUnsupportedMedia):
+		return http.StatusUnsupportedMediaType
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	// registers the GIF decoder, the JPEG and PNG ones come with their encoders
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+const (
+	// maxPixels bound the memory needed to decode an upload, whatever its compressed size
+	maxPixels    = 40 * 1000 * 1000
+	jpegQuality  = 85
+	coverKeyRoot = "covers/"
+)
+
+// coverTypes are the accepted image types, keyed by sniffed content type, with their extension
+var coverTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// CoverUsecase represent the cover image use case struct
+type CoverUsecase struct {
+	productRepo    domain.ProductRepository
+	storage        domain.BlobStorage
+	maxBytes       int
+	contextTimeout time.Duration
+}
+
+// NewCoverUsecase will create new a cover usecase object representation of domain.CoverUsecase interface,
+// uploads are limited to maxBytes
+func NewCoverUsecase(p domain.ProductRepository, s domain.BlobStorage, maxBytes int, timeout time.Duration) domain.CoverUsecase {
+	return &CoverUsecase{
+		productRepo:    p,
+		storage:        s,
+		maxBytes:       maxBytes,
+		contextTimeout: timeout,
+	}
+}
+
+// Upload will store the image as the cover of the product along with its
+// thumbnails. The type is sniffed from the content, whatever the client claims
+func (u *CoverUsecase) Upload(c context.Context, productID int, data []byte) (res domain.Product, err error) {
+	if err = domain.Authorize(c, domain.PermissionProductWrite); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if len(data) > u.maxBytes {
+		return res, domain.ErrTooLarge
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := coverTypes[contentType]
+	if !ok {
+		return res, domain.ErrUnsupportedMedia
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return res, domain.ErrUnsupportedMedia
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return res, domain.ErrTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return res, domain.ErrUnsupportedMedia
+	}
+
+	res, err = u.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return
+	}
+
+	// the keys are derived from the content so that an image URL never
+	// changes meaning and can be cached forever
+	sum := sha256.Sum256(data)
+	key := fmt.Sprintf("%s%d/%s%s", coverKeyRoot, productID, hex.EncodeToString(sum[:8]), ext)
+	if err = u.storage.Put(ctx, key, contentType, data); err != nil {
+		return
+	}
+	src := toRGBA(img)
+	for size, width := range domain.CoverSizes {
+		thumbKey := domain.CoverThumbnailKey(key, size)
+		var buf bytes.Buffer
+		thumbType := "image/png"
+		if strings.HasSuffix(thumbKey, ".jpg") {
+			thumbType = "image/jpeg"
+			err = jpeg.Encode(&buf, thumbnail(src, width), &jpeg.Options{Quality: jpegQuality})
+		} else {
+			err = png.Encode(&buf, thumbnail(src, width))
+		}
+		if err != nil {
+			return
+		}
+		if err = u.storage.Put(ctx, thumbKey, thumbType, buf.Bytes()); err != nil {
+			return
+		}
+	}
+
+	previous := res.Image
+	res.Image = domain.ImageURLPrefix + key
+	if err = u.productRepo.UpdateImage(ctx, productID, res.Image); err != nil {
+		return
+	}
+	res.Thumbnails = domain.CoverThumbnails(res.Image)
+	if previous != res.Image {
+		u.deleteCover(ctx, previous)
+	}
+	return
+}
+
+// deleteCover remove a replaced cover and its thumbnails, failures only leave orphan files behind
+func (u *CoverUsecase) deleteCover(ctx context.Context, image string) {
+	if !strings.HasPrefix(image, domain.ImageURLPrefix+coverKeyRoot) {
+		return
+	}
+	key := strings.TrimPrefix(image, domain.ImageURLPrefix)
+	keys := []string{key}
+	for size := range domain.CoverSizes {
+		keys = append(keys, domain.CoverThumbnailKey(key, size))
+	}
+	for _, k := range keys {
+		if err := u.storage.Delete(ctx, k); err != nil {
+			logrus.Error(err)
+		}
+	}
+}
+
+// Open will return the stored image, only the covers are served. The body
+// is streamed after Open returns, hence no timeout
+func (u *CoverUsecase) Open(c context.Context, key string) (domain.Blob, error) {
+	if !strings.HasPrefix(key, coverKeyRoot) {
+		return domain.Blob{}, domain.ErrNotFound
+	}
+	return u.storage.Get(c, key)
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+// CurrencyHandler represent the httphandler for currencies
+type CurrencyHandler struct {
+	CUsecase domain.CurrencyUsecase
+}
+
+// NewCurrencyHandler will initialize the currency endpoint
+func NewCurrencyHandler(e *echo.Echo, us domain.CurrencyUsecase, auth echo.MiddlewareFunc) {
+	handler := &CurrencyHandler{
+		CUsecase: us,
+	}
+	e.GET("/currency", handler.FetchRates)
+	e.PUT("/admin/currency/:currency", handler.StoreRate, auth)
+	e.DELETE("/admin/currency/:currency", handler.DeleteRate, auth)
+}
+
+// FetchRates will list the currencies the products can be priced in along with their exchange rate
+func (h *CurrencyHandler) FetchRates(c echo.Context) error {
+	ctx := c.Request().Context()
+	rates, err := h.CUsecase.FetchRates(ctx)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: rates})
+}
+
+// StoreRate will set the exchange rate of the currency given in the path
+func (h *CurrencyHandler) StoreRate(c echo.Context) (err error) {
+	var rate domain.ExchangeRate
+	if err = c.Bind(&rate); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	rate.Currency = c.Param("currency")
+	var ok bool
+	if ok, err = isRequestValid(&rate); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	if err = h.CUsecase.StoreRate(ctx, &rate); err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: rate})
+}
+
+// DeleteRate will remove the exchange rate of the currency
+func (h *CurrencyHandler) DeleteRate(c echo.Context) error {
+	ctx := c.Request().Context()
+	if err := h.CUsecase.DeleteRate(ctx, c.Param("currency")); err != nil {
+		return failed(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func isRequestValid(m interface{}) (bool, error) {
+	validate := validator.New()
+	err := validate.Struct(m)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// failed write the error response of err, a denied permission is described with a problem detail
+func failed(c echo.Context, err error) error {
+	status := getStatusCode(err)
+	if status == http.StatusForbidden {
+		c.Response().Header().Set(echo.HeaderContentType, response.ProblemContentType)
+		return c.JSON(status, response.NewProblem(status, err.Error()))
+	}
+	return c.JSON(status, response.ResponseFailed{Success: false, Message: err.Error()})
+}
+
+func getStatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	logrus.Error(err)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrBadParamInput):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
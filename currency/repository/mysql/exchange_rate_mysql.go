@@ -0,0 +1,83 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// mysqlExchangeRateRepository represent the connection database struct
+type mysqlExchangeRateRepository struct {
+	Conn *sql.DB
+}
+
+// NewMysqlExchangeRateRepository will create an object that represent the exchange rate Repository interface
+func NewMysqlExchangeRateRepository(Conn *sql.DB) domain.ExchangeRateRepository {
+	return &mysqlExchangeRateRepository{Conn: Conn}
+}
+
+// fetch read the rates, the DECIMAL column is scanned as text to stay exact
+func (m *mysqlExchangeRateRepository) fetch(ctx context.Context, query string, args ...interface{}) (result []domain.ExchangeRate, err error) {
+	rows, err := m.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.ExchangeRate, 0)
+	for rows.Next() {
+		t := domain.ExchangeRate{}
+		if err = rows.Scan(&t.Currency, &t.Rate, &t.UpdatedAt); err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+func (m *mysqlExchangeRateRepository) Fetch(ctx context.Context) ([]domain.ExchangeRate, error) {
+	return m.fetch(ctx, `SELECT currency, rate, updated_at FROM exchange_rate ORDER BY currency`)
+}
+
+func (m *mysqlExchangeRateRepository) GetByCurrency(ctx context.Context, currency string) (res domain.ExchangeRate, err error) {
+	list, err := m.fetch(ctx, `SELECT currency, rate, updated_at FROM exchange_rate WHERE currency=?`, currency)
+	if err != nil {
+		return
+	}
+	if len(list) == 0 {
+		return res, domain.ErrNotFound
+	}
+	return list[0], nil
+}
+
+func (m *mysqlExchangeRateRepository) Store(ctx context.Context, r *domain.ExchangeRate) error {
+	query := `INSERT INTO exchange_rate (currency, rate, updated_at) VALUES(?,?,?)
+		ON DUPLICATE KEY UPDATE rate=VALUES(rate), updated_at=VALUES(updated_at)`
+	r.UpdatedAt = time.Now()
+	_, err := m.Conn.ExecContext(ctx, query, r.Currency, r.Rate, r.UpdatedAt)
+	return err
+}
+
+func (m *mysqlExchangeRateRepository) Delete(ctx context.Context, currency string) error {
+	result, err := m.Conn.ExecContext(ctx, `DELETE FROM exchange_rate WHERE currency=?`, currency)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/cache"
+)
+
+const (
+	// ratesKey is the cache entry holding the whole rate table
+	ratesKey = "rates"
+	// ratesTTL bound how long the other instances may price with a rate
+	// changed through this one
+	ratesTTL = time.Minute
+)
+
+// CurrencyUsecase represent the currency use case struct
+type CurrencyUsecase struct {
+	rateRepo       domain.ExchangeRateRepository
+	base           string
+	rates          *cache.Cache
+	contextTimeout time.Duration
+}
+
+// NewCurrencyUsecase will create new a currency usecase object representation of domain.CurrencyUsecase interface,
+// base is the currency the products are priced in. The rate table is read once
+// and kept for a minute, or until changed through the usecase
+func NewCurrencyUsecase(r domain.ExchangeRateRepository, base string, timeout time.Duration) domain.CurrencyUsecase {
+	return &CurrencyUsecase{
+		rateRepo:       r,
+		base:           strings.ToUpper(base),
+		rates:          cache.New(1, ratesTTL, timeout),
+		contextTimeout: timeout,
+	}
+}
+
+// Base return the currency the products are priced in
+func (u *CurrencyUsecase) Base() string {
+	return u.base
+}
+
+// FetchRates will get the exchange rates, the base currency first
+func (u *CurrencyUsecase) FetchRates(c context.Context) (res []domain.ExchangeRate, err error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	rates, err := u.rateRepo.Fetch(ctx)
+	if err != nil {
+		return
+	}
+	return append([]domain.ExchangeRate{{Currency: u.base, Rate: "1"}}, rates...), nil
+}
+
+// StoreRate will add or replace the exchange rate of a known currency
+func (u *CurrencyUsecase) StoreRate(c context.Context, r *domain.ExchangeRate) (err error) {
+	if err = domain.Authorize(c, domain.PermissionCurrencyManage); err != nil {
+		return
+	}
+	r.Currency = strings.ToUpper(r.Currency)
+	if _, ok := domain.CurrencyDigits(r.Currency); !ok || r.Currency == u.base {
+		return domain.ErrBadParamInput
+	}
+	rate, ok := r.Ratio()
+	if !ok {
+		return domain.ErrBadParamInput
+	}
+	r.Rate = rate.FloatString(10)
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	defer u.rates.Delete(ratesKey)
+	return u.rateRepo.Store(ctx, r)
+}
+
+// DeleteRate will stop pricing in the currency
+func (u *CurrencyUsecase) DeleteRate(c context.Context, currency string) (err error) {
+	if err = domain.Authorize(c, domain.PermissionCurrencyManage); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	defer u.rates.Delete(ratesKey)
+	return u.rateRepo.Delete(ctx, strings.ToUpper(currency))
+}
+
+// table return the exchange rates keyed by currency, from the cache
+func (u *CurrencyUsecase) table(ctx context.Context) (map[string]domain.ExchangeRate, error) {
+	v, err := u.rates.GetOrLoad(ctx, ratesKey, func(ctx context.Context) (interface{}, error) {
+		rates, err := u.rateRepo.Fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		table := make(map[string]domain.ExchangeRate, len(rates))
+		for _, r := range rates {
+			table[r.Currency] = r
+		}
+		return table, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[string]domain.ExchangeRate), nil
+}
+
+// rate return the exchange rate of the currency, the base currency having a rate of 1
+func (u *CurrencyUsecase) rate(ctx context.Context, currency string) (domain.ExchangeRate, error) {
+	if currency == u.base {
+		return domain.ExchangeRate{Currency: u.base, Rate: "1"}, nil
+	}
+	table, err := u.table(ctx)
+	if err != nil {
+		return domain.ExchangeRate{}, err
+	}
+	r, ok := table[currency]
+	if !ok {
+		return r, domain.ErrNotFound
+	}
+	return r, nil
+}
+
+// Negotiate will pick the first preferred currency having an exchange rate
+func (u *CurrencyUsecase) Negotiate(c context.Context, preferred []string) (string, error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	table, err := u.table(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, currency := range preferred {
+		if currency == "*" {
+			break
+		}
+		currency = strings.ToUpper(currency)
+		if _, ok := table[currency]; ok || currency == u.base {
+			return currency, nil
+		}
+	}
+	return u.base, nil
+}
+
+// localRate return the rate of the currency and its ratio, an unknown
+// currency is a bad parameter
+func (u *CurrencyUsecase) localRate(ctx context.Context, currency string) (res domain.ExchangeRate, ratio *big.Rat, err error) {
+	res, err = u.rate(ctx, strings.ToUpper(currency))
+	if errors.Is(err, domain.ErrNotFound) {
+		return res, nil, domain.ErrBadParamInput
+	}
+	if err != nil {
+		return
+	}
+	ratio, ok := res.Ratio()
+	if !ok {
+		return res, nil, domain.ErrInternalServerError
+	}
+	return
+}
+
+// Localize will convert the price of the products from the base currency,
+// an unknown currency is a bad parameter
+func (u *CurrencyUsecase) Localize(c context.Context, products []domain.Product, currency string) (res domain.ExchangeRate, err error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	res, ratio, err := u.localRate(ctx, currency)
+	if err != nil {
+		return
+	}
+	for i := range products {
+		p := &products[i]
+		p.Currency = u.base
+		if res.Currency == u.base {
+			continue
+		}
+		converted, err := p.Money().Convert(res.Currency, ratio)
+		if err != nil {
+			return res, err
+		}
+		p.Price = converted.Amount
+		p.Currency = converted.Currency
+	}
+	return
+}
+
+// Convert will convert an amount of the base currency, an unknown currency is a bad parameter
+func (u *CurrencyUsecase) Convert(c context.Context, amount int64, currency string) (domain.Money, error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	rate, ratio, err := u.localRate(ctx, currency)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	m := domain.Money{Amount: amount, Currency: u.base}
+	if rate.Currency == u.base {
+		return m, nil
+	}
+	return m.Convert(rate.Currency, ratio)
+}
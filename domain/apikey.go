@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// ScopeCatalogRead allow reading the products and authors
+	ScopeCatalogRead = "catalog:read"
+	// ScopeCatalogImport allow creating and editing products and authors
+	ScopeCatalogImport = "catalog:import"
+	// ScopeOrders allow reading and managing the orders
+	ScopeOrders = "orders"
+	// ScopeWebhooks allow managing the webhook subscriptions of the key
+	ScopeWebhooks = "webhooks"
+)
+
+// scopePermissions hold the permissions granted by every API key scope
+var scopePermissions = map[string][]Permission{
+	ScopeCatalogRead:   {PermissionProductRead, PermissionAuthorRead},
+	ScopeCatalogImport: {PermissionProductRead, PermissionProductWrite, PermissionAuthorRead, PermissionAuthorWrite},
+	ScopeOrders:        {PermissionOrderRead, PermissionOrderManage},
+	ScopeWebhooks:      {PermissionWebhookManage},
+}
+
+// IsValidScope report whether scope is one of the known API key scopes
+func IsValidScope(scope string) bool {
+	_, ok := scopePermissions[scope]
+	return ok
+}
+
+// APIKey is a long lived credential of a partner integration, only the hash of the key is persisted
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name" validate:"required"`
+	Key        string     `json:"key,omitempty"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes" validate:"required,min=1"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// APIKeyUsecase represent the API key's usecases
+type APIKeyUsecase interface {
+	Fetch(ctx context.Context) ([]APIKey, error)
+	Issue(ctx context.Context, k *APIKey) error
+	GetByID(ctx context.Context, id int) (APIKey, error)
+	Revoke(ctx context.Context, id int) error
+	Authenticate(ctx context.Context, key string) (Principal, error)
+}
+
+// APIKeyRepository represent the API key's repository contract
+type APIKeyRepository interface {
+	Fetch(ctx context.Context) ([]APIKey, error)
+	Store(ctx context.Context, k *APIKey) error
+	GetByID(ctx context.Context, id int) (APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (APIKey, error)
+	Revoke(ctx context.Context, id int, at time.Time) error
+	UpdateLastUsed(ctx context.Context, id int, at time.Time) error
+}
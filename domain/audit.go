@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// AuditAction is the kind of mutation an audit entry records
+type AuditAction string
+
+const (
+	// AuditCreate records the creation of an entity
+	AuditCreate AuditAction = "create"
+	// AuditUpdate records the change of an entity
+	AuditUpdate AuditAction = "update"
+	// AuditDelete records the removal of an entity
+	AuditDelete AuditAction = "delete"
+)
+
+// AuditChange is the value of a field before and after a mutation, nil when absent
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditEntry records who mutated an entity, when and how, entries are never changed
+type AuditEntry struct {
+	ID         int                    `json:"id"`
+	Actor      string                 `json:"actor"`
+	Action     AuditAction            `json:"action"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   string                 `json:"entity_id"`
+	Changes    map[string]AuditChange `json:"changes"`
+	RequestID  string                 `json:"request_id"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// AuditFilter select the audit entries to list, the latest first. Before is
+// the id the entries are older than, the cursor of the next page
+type AuditFilter struct {
+	EntityType string
+	EntityID   string
+	Before     int
+	Limit      int
+}
+
+// AuditUsecase represent the audit log's usecases
+type AuditUsecase interface {
+	Fetch(ctx context.Context, filter AuditFilter) ([]AuditEntry, error)
+	// Record append an entry of the mutation by the principal of ctx, before is
+	// nil for a creation and after is nil for a removal
+	Record(ctx context.Context, action AuditAction, entityType string, entityID string, before interface{}, after interface{}) error
+}
+
+// AuditRepository represent the audit log's repository contract, it is append only
+type AuditRepository interface {
+	Fetch(ctx context.Context, filter AuditFilter) ([]AuditEntry, error)
+	Store(ctx context.Context, e *AuditEntry) error
+}
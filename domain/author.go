@@ -17,6 +17,7 @@ type Author struct {
 // AuthorUsecase represent the author's usecases
 type AuthorUsecase interface {
 	Fetch(c context.Context) ([]Author, error)
+	FetchByIDs(c context.Context, ids []int) ([]Author, error)
 	Store(c context.Context, dataAuthor *Author) error
 	GetAuthorById(c context.Context, authorId int) (Author, error)
 	UpdateAuthorById(c context.Context, authorId int, dataAuthor *Author) error
@@ -26,8 +27,12 @@ type AuthorUsecase interface {
 // AuthorRepository represent the author's repository contract
 type AuthorRepository interface {
 	Fetch(ctx context.Context) ([]Author, error)
+	// FetchByIDs return the authors of the ids, the ids without one are left out
+	FetchByIDs(ctx context.Context, ids []int) ([]Author, error)
 	Store(ctx context.Context, dataAuthor *Author) error
 	GetAuthorById(ctx context.Context, authorId int) (Author, error)
+	// GetAuthorByIdForUpdate read the author like GetAuthorById and lock it until the end of the transaction of ctx
+	GetAuthorByIdForUpdate(ctx context.Context, authorId int) (Author, error)
 	UpdateAuthorById(ctx context.Context, authorId int, dataAuthor *Author) error
 	DeleteAuthorById(ctx context.Context, authorId int) error
 }
@@ -0,0 +1,10 @@
+package domain
+
+import "context"
+
+// CatalogListener is notified after a product or an author was stored, updated or deleted.
+// The id is 0 when it is not known yet, e.g. for a product stored without an id
+type CatalogListener interface {
+	ProductChanged(ctx context.Context, id int)
+	AuthorChanged(ctx context.Context, id int)
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// ImageURLPrefix is the path the stored images are served under
+const ImageURLPrefix = "/image/"
+
+// CoverSizes are the widths in pixels of the thumbnails generated for every cover
+var CoverSizes = map[string]int{
+	"small":  160,
+	"medium": 480,
+}
+
+// CoverThumbnailKey return the storage key of the thumbnail of a cover,
+// thumbnails are JPEG unless the cover may carry transparency
+func CoverThumbnailKey(key, size string) string {
+	ext := path.Ext(key)
+	if ext != ".jpg" {
+		ext = ".png"
+	}
+	return strings.TrimSuffix(key, path.Ext(key)) + "-" + size + ext
+}
+
+// CoverThumbnails return the thumbnail URLs of an uploaded cover, keyed by size
+func CoverThumbnails(image string) map[string]string {
+	if !strings.HasPrefix(image, ImageURLPrefix) {
+		return nil
+	}
+	key := strings.TrimPrefix(image, ImageURLPrefix)
+	res := make(map[string]string, len(CoverSizes))
+	for size := range CoverSizes {
+		res[size] = ImageURLPrefix + CoverThumbnailKey(key, size)
+	}
+	return res
+}
+
+// Blob is a stored object, the caller must close its Body
+type Blob struct {
+	Body        io.ReadCloser
+	ContentType string
+	Size        int64
+	ModTime     time.Time
+}
+
+// BlobStorage represent the contract of the storages the images are kept in
+type BlobStorage interface {
+	Put(ctx context.Context, key string, contentType string, data []byte) error
+	Get(ctx context.Context, key string) (Blob, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// CoverUsecase represent the cover image's usecases
+type CoverUsecase interface {
+	Upload(ctx context.Context, productID int, data []byte) (Product, error)
+	Open(ctx context.Context, key string) (Blob, error)
+}
@@ -11,4 +11,16 @@ var (
 	ErrConflict = errors.New("your Item already exist")
 	// ErrBadParamInput will throw if the given request-body or params is not valid
 	ErrBadParamInput = errors.New("given param is not valid")
+	// ErrInvalidTransition will throw if the requested state change is not allowed
+	ErrInvalidTransition = errors.New("requested status transition is not allowed")
+	// ErrUnauthorized will throw if the given credentials or token are not valid
+	ErrUnauthorized = errors.New("invalid or missing credentials")
+	// ErrForbidden will throw if the caller is not allowed to perform the action
+	ErrForbidden = errors.New("you are not allowed to perform this action")
+	// ErrUnsupportedMedia will throw if the uploaded content is not of an accepted type
+	ErrUnsupportedMedia = errors.New("unsupported media type")
+	// ErrTooLarge will throw if the uploaded content exceeds the size limits
+	ErrTooLarge = errors.New("uploaded content is too large")
+	// ErrTooManyConnections will throw if the live connections reached their limit
+	ErrTooManyConnections = errors.New("too many connections, try again later")
 )
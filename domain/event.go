@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const (
+	// EntityProduct is the entity type of the products in the events and the audit log
+	EntityProduct = "product"
+	// EntityAuthor is the entity type of the authors in the events and the audit log
+	EntityAuthor = "author"
+)
+
+const (
+	// EventProductCreated tells a product was added, its payload is the product
+	EventProductCreated = "ProductCreated"
+	// EventProductUpdated tells a product was changed, its payload is the product
+	EventProductUpdated = "ProductUpdated"
+	// EventProductDeleted tells a product was removed, its payload is the product as it was
+	EventProductDeleted = "ProductDeleted"
+	// EventAuthorCreated tells an author was added, its payload is the author
+	EventAuthorCreated = "AuthorCreated"
+	// EventAuthorUpdated tells an author was changed, its payload is the author
+	EventAuthorUpdated = "AuthorUpdated"
+	// EventAuthorDeleted tells an author was removed, its payload is the author as it was
+	EventAuthorDeleted = "AuthorDeleted"
+)
+
+// Event is a domain event, something that happened to an entity. Sequence
+// orders the events as they were recorded, ID identify them to the consumers
+// which may see an event more than once
+type Event struct {
+	Sequence   int64           `json:"sequence"`
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Payload    json.RawMessage `json:"payload"`
+	Actor      string          `json:"actor"`
+	RequestID  string          `json:"request_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// NewEvent create an event of the entity carrying the JSON of payload
+func NewEvent(eventType string, entityType string, entityID string, payload interface{}) (Event, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{Type: eventType, EntityType: entityType, EntityID: entityID, Payload: b}, nil
+}
+
+// Transactor run a function in a database transaction carried by its context,
+// the repositories given that context take part in the transaction
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// EventEmitter record the events of a change, in the transaction of ctx when there is one
+type EventEmitter interface {
+	Emit(ctx context.Context, events ...Event) error
+}
+
+// EventHandler is called with every published event, it must return quickly.
+// An error has the event handed again later to this handler alone
+type EventHandler func(ctx context.Context, e Event) error
+
+// EventBroker carry the published events to their subscribers, known by name
+type EventBroker interface {
+	// Publish hand the event to every subscriber, it returns the errors of the
+	// subscribers that failed keyed by their name
+	Publish(ctx context.Context, e Event) map[string]error
+	// Deliver hand the event to the subscriber of the name alone, ErrNotFound
+	// tells there is no such subscriber anymore
+	Deliver(ctx context.Context, subscriber string, e Event) error
+	// Subscribe register the handler under a name unique to the subscriber,
+	// until the returned function is called
+	Subscribe(name string, h EventHandler) (unsubscribe func())
+}
+
+// EventRetry is a published event a subscriber failed to handle, it is handed
+// again to that subscriber alone once NextAttemptAt is reached
+type EventRetry struct {
+	Event         Event
+	Subscriber    string
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+}
+
+// EventSubscription is what a subscriber of the event stream receives: the
+// events it missed still in the replay buffer, then the new ones. Gap tells
+// some of the missed events are gone from the buffer. Events is closed when
+// the subscriber falls too far behind, Close must be called once done
+type EventSubscription struct {
+	Replay []Event
+	Gap    bool
+	Events <-chan Event
+	Close  func()
+}
+
+// EventStreamUsecase fan the published events out to the live subscribers
+type EventStreamUsecase interface {
+	// Subscribe follow the events of the entity types, of every type when
+	// there is none, resuming after the event of sequence lastSequence when it is set
+	Subscribe(ctx context.Context, lastSequence int64, entityTypes []string) (*EventSubscription, error)
+}
+
+// EventRepository represent the outbox of the events waiting to be published
+type EventRepository interface {
+	Store(ctx context.Context, e *Event) error
+	// FetchUnpublished list at most limit events not published yet, by sequence
+	FetchUnpublished(ctx context.Context, limit int) ([]Event, error)
+	MarkPublished(ctx context.Context, sequence int64) error
+	// StoreRetry record the retry of an event for a subscriber, replacing the one it may have
+	StoreRetry(ctx context.Context, r EventRetry) error
+	// FetchDueRetries list at most limit retries due at now, along with their event
+	FetchDueRetries(ctx context.Context, now time.Time, limit int) ([]EventRetry, error)
+	DeleteRetry(ctx context.Context, sequence int64, subscriber string) error
+}
@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// currencyDigits hold the number of minor unit digits of the ISO 4217 currencies
+// the shop may price in. The rupiah is counted in whole units, as the catalog
+// prices and the price buckets are, the sen being out of use
+var currencyDigits = map[string]int{
+	"AUD": 2, "BHD": 3, "CAD": 2, "CHF": 2, "CNY": 2, "EUR": 2, "GBP": 2, "HKD": 2,
+	"IDR": 0, "INR": 2, "JPY": 0, "KRW": 0, "KWD": 3, "MYR": 2, "NZD": 2, "PHP": 2,
+	"SAR": 2, "SGD": 2, "THB": 2, "TWD": 2, "USD": 2, "VND": 0,
+}
+
+// CurrencyDigits return the number of minor unit digits of the currency, and whether it is a known one
+func CurrencyDigits(currency string) (int, bool) {
+	digits, ok := currencyDigits[currency]
+	return digits, ok
+}
+
+// Money is an amount in the minor unit of an ISO 4217 currency, cents for USD and yens for JPY
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// String format the money in major units after its currency code, like "USD 12.50"
+func (m Money) String() string {
+	digits, _ := CurrencyDigits(m.Currency)
+	if digits == 0 {
+		return fmt.Sprintf("%s %d", m.Currency, m.Amount)
+	}
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	scale := int64(1)
+	for i := 0; i < digits; i++ {
+		scale *= 10
+	}
+	return fmt.Sprintf("%s %s%d.%0*d", m.Currency, sign, amount/scale, digits, amount%scale)
+}
+
+// Convert the money into currency, rate being the count of currency units one
+// unit of the money currency is worth. The result is rounded half away from
+// zero to the minor unit of currency
+func (m Money) Convert(currency string, rate *big.Rat) (Money, error) {
+	from, ok := CurrencyDigits(m.Currency)
+	if !ok {
+		return Money{}, ErrBadParamInput
+	}
+	to, ok := CurrencyDigits(currency)
+	if !ok {
+		return Money{}, ErrBadParamInput
+	}
+	v := new(big.Rat).SetInt64(m.Amount)
+	v.Mul(v, rate)
+	shift := to - from
+	if shift < 0 {
+		shift = -shift
+	}
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(shift)), nil))
+	if to > from {
+		v.Mul(v, scale)
+	} else {
+		v.Quo(v, scale)
+	}
+	return Money{Amount: RoundHalfAway(v), Currency: currency}, nil
+}
+
+// RoundHalfAway round v to an integer, halves going away from zero
+func RoundHalfAway(v *big.Rat) int64 {
+	num, den := v.Num(), v.Denom()
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Abs(r).Lsh(r, 1).Cmp(den) >= 0 {
+		if num.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q.Int64()
+}
+
+// ExchangeRate is the count of Currency units one unit of the base currency is
+// worth, as an exact decimal
+type ExchangeRate struct {
+	Currency  string    `json:"currency" validate:"required,len=3"`
+	Rate      string    `json:"rate" validate:"required"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Ratio parse the rate, it is not valid unless positive
+func (r ExchangeRate) Ratio() (*big.Rat, bool) {
+	rate, ok := new(big.Rat).SetString(r.Rate)
+	if !ok || rate.Sign() <= 0 {
+		return nil, false
+	}
+	return rate, true
+}
+
+// CurrencyUsecase represent the currency's usecases, the products are priced
+// in the base currency and converted with the exchange rates
+type CurrencyUsecase interface {
+	Base() string
+	FetchRates(ctx context.Context) ([]ExchangeRate, error)
+	StoreRate(ctx context.Context, r *ExchangeRate) error
+	DeleteRate(ctx context.Context, currency string) error
+	// Negotiate pick the first of the preferred currencies that can be priced in, the base currency otherwise
+	Negotiate(ctx context.Context, preferred []string) (string, error)
+	// Localize convert the price of the products into the currency and return the rate used
+	Localize(ctx context.Context, products []Product, currency string) (ExchangeRate, error)
+	// Convert convert an amount of the base currency into the currency
+	Convert(ctx context.Context, amount int64, currency string) (Money, error)
+}
+
+// ExchangeRateRepository represent the exchange rate's repository contract
+type ExchangeRateRepository interface {
+	Fetch(ctx context.Context) ([]ExchangeRate, error)
+	GetByCurrency(ctx context.Context, currency string) (ExchangeRate, error)
+	// Store add the rate of the currency or replace it
+	Store(ctx context.Context, r *ExchangeRate) error
+	Delete(ctx context.Context, currency string) error
+}
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// OrderStatus represent the state of an order in its lifecycle
+type OrderStatus string
+
+const (
+	// OrderStatusPending is the initial state of every order
+	OrderStatusPending OrderStatus = "pending"
+	// OrderStatusPaid means the payment for the order has been received
+	OrderStatusPaid OrderStatus = "paid"
+	// OrderStatusShipped means the order has been handed over to the courier
+	OrderStatusShipped OrderStatus = "shipped"
+	// OrderStatusDelivered means the order has been received by the customer
+	OrderStatusDelivered OrderStatus = "delivered"
+	// OrderStatusCancelled means the order was cancelled before payment
+	OrderStatusCancelled OrderStatus = "cancelled"
+	// OrderStatusRefunded means the payment of the order was returned to the customer
+	OrderStatusRefunded OrderStatus = "refunded"
+)
+
+// orderTransitions hold every allowed move of the order state machine
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:      {OrderStatusShipped, OrderStatusRefunded},
+	OrderStatusShipped:   {OrderStatusDelivered, OrderStatusRefunded},
+	OrderStatusDelivered: {OrderStatusRefunded},
+}
+
+// CanTransitionTo report whether an order in status s may be moved to the next status
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValid report whether s is one of the known order statuses
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case OrderStatusPending, OrderStatusPaid, OrderStatusShipped,
+		OrderStatusDelivered, OrderStatusCancelled, OrderStatusRefunded:
+		return true
+	}
+	return false
+}
+
+// Order ...
+type Order struct {
+	ID              int                  `json:"id"`
+	CustomerID      int                  `json:"customer_id" validate:"required"`
+	Status          OrderStatus          `json:"status"`
+	ShippingAddress string               `json:"shipping_address"`
+	Total           int64                `json:"total"`
+	CouponCode      string               `json:"coupon_code,omitempty"`
+	Items           []OrderItem          `json:"items" validate:"required,min=1,dive"`
+	History         []OrderStatusHistory `json:"history,omitempty"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+	CreatedAt       time.Time            `json:"created_at"`
+}
+
+// OrderItem is a line of an order, name and price are a snapshot of the product at purchase time
+type OrderItem struct {
+	ID          int    `json:"id"`
+	OrderID     int    `json:"order_id"`
+	ProductID   int    `json:"product_id" validate:"required"`
+	ProductName string `json:"product_name"`
+	Price       int64  `json:"price"`
+	Quantity    int    `json:"quantity" validate:"required,min=1"`
+}
+
+// OrderStatusHistory is an audit entry of a state change of an order
+type OrderStatusHistory struct {
+	ID         int         `json:"id"`
+	OrderID    int         `json:"order_id"`
+	FromStatus OrderStatus `json:"from_status"`
+	ToStatus   OrderStatus `json:"to_status"`
+	ChangedBy  string      `json:"changed_by"`
+	Note       string      `json:"note"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// OrderTransition represent a request to move an order to another status
+type OrderTransition struct {
+	Status    OrderStatus `json:"status" validate:"required"`
+	ChangedBy string      `json:"-"`
+	Note      string      `json:"note"`
+}
+
+// OrderFilter represent the criteria to list orders
+type OrderFilter struct {
+	CustomerID int
+	Status     OrderStatus
+}
+
+// OrderUsecase represent the order's usecases
+type OrderUsecase interface {
+	Fetch(ctx context.Context, filter OrderFilter) ([]Order, error)
+	Store(ctx context.Context, o *Order) error
+	GetByID(ctx context.Context, id int) (Order, error)
+	Transition(ctx context.Context, id int, t *OrderTransition) error
+}
+
+// OrderRepository represent the order's repository contract
+type OrderRepository interface {
+	Fetch(ctx context.Context, filter OrderFilter) ([]Order, error)
+	Store(ctx context.Context, o *Order) error
+	GetByID(ctx context.Context, id int) (Order, error)
+	UpdateStatus(ctx context.Context, id int, from OrderStatus, h *OrderStatusHistory) error
+	FetchHistory(ctx context.Context, orderID int) ([]OrderStatusHistory, error)
+}
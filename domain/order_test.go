@@ -0,0 +1,55 @@
+package domain
+
+import "testing"
+
+func TestOrderStatusCanTransitionTo(t *testing.T) {
+	statuses := []OrderStatus{
+		OrderStatusPending,
+		OrderStatusPaid,
+		OrderStatusShipped,
+		OrderStatusDelivered,
+		OrderStatusCancelled,
+		OrderStatusRefunded,
+	}
+	allowed := map[OrderStatus]map[OrderStatus]bool{
+		OrderStatusPending:   {OrderStatusPaid: true, OrderStatusCancelled: true},
+		OrderStatusPaid:      {OrderStatusShipped: true, OrderStatusRefunded: true},
+		OrderStatusShipped:   {OrderStatusDelivered: true, OrderStatusRefunded: true},
+		OrderStatusDelivered: {OrderStatusRefunded: true},
+	}
+	// every pair of statuses, the ones not listed above are forbidden
+	for _, from := range statuses {
+		for _, to := range statuses {
+			want := allowed[from][to]
+			t.Run(string(from)+" to "+string(to), func(t *testing.T) {
+				if got := from.CanTransitionTo(to); got != want {
+					t.Fatalf("CanTransitionTo() = %v, want %v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestOrderStatusIsValid(t *testing.T) {
+	tests := []struct {
+		status OrderStatus
+		valid  bool
+	}{
+		{status: OrderStatusPending, valid: true},
+		{status: OrderStatusPaid, valid: true},
+		{status: OrderStatusShipped, valid: true},
+		{status: OrderStatusDelivered, valid: true},
+		{status: OrderStatusCancelled, valid: true},
+		{status: OrderStatusRefunded, valid: true},
+		{status: ""},
+		{status: "returned"},
+		{status: "PAID"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			if got := tt.status.IsValid(); got != tt.valid {
+				t.Fatalf("IsValid() = %v, want %v", got, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// PriceChange is an entry of the price history of a product
+type PriceChange struct {
+	ID        int       `json:"id"`
+	ProductID int       `json:"product_id"`
+	OldPrice  int64     `json:"old_price"`
+	NewPrice  int64     `json:"new_price"`
+	ChangedBy string    `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// ScheduledPriceStatus represent the state of a scheduled price change
+type ScheduledPriceStatus string
+
+const (
+	// ScheduledPricePending is waiting for its effective time
+	ScheduledPricePending ScheduledPriceStatus = "pending"
+	// ScheduledPriceApplied has been applied to the product
+	ScheduledPriceApplied ScheduledPriceStatus = "applied"
+	// ScheduledPriceCancelled was cancelled before being applied
+	ScheduledPriceCancelled ScheduledPriceStatus = "cancelled"
+	// ScheduledPriceFailed could not be applied, the product is gone
+	ScheduledPriceFailed ScheduledPriceStatus = "failed"
+)
+
+// ScheduledPriceChange is a price a product takes at a future time
+type ScheduledPriceChange struct {
+	ID          int                  `json:"id"`
+	ProductID   int                  `json:"product_id"`
+	Price       int64                `json:"price" validate:"min=0"`
+	EffectiveAt time.Time            `json:"effective_at" validate:"required"`
+	Note        string               `json:"note" validate:"max=500"`
+	Status      ScheduledPriceStatus `json:"status"`
+	CreatedBy   string               `json:"created_by"`
+	AppliedAt   *time.Time           `json:"applied_at"`
+	CreatedAt   time.Time            `json:"created_at"`
+}
+
+// PriceUsecase represent the price history and price schedule usecases
+type PriceUsecase interface {
+	FetchHistory(ctx context.Context, productID int) ([]PriceChange, error)
+	FetchScheduled(ctx context.Context, productID int) ([]ScheduledPriceChange, error)
+	Schedule(ctx context.Context, s *ScheduledPriceChange) error
+	Cancel(ctx context.Context, id int) error
+	// ApplyDue apply the pending changes effective at now and return how many were applied
+	ApplyDue(ctx context.Context, now time.Time) (int, error)
+}
+
+// PriceRepository represent the price history and price schedule repository contract,
+// the history itself is written by ProductRepository along with the price
+type PriceRepository interface {
+	FetchHistory(ctx context.Context, productID int) ([]PriceChange, error)
+	FetchScheduled(ctx context.Context, productID int) ([]ScheduledPriceChange, error)
+	// FetchDue list at most limit pending changes effective at now, the oldest first
+	FetchDue(ctx context.Context, now time.Time, limit int) ([]ScheduledPriceChange, error)
+	GetScheduled(ctx context.Context, id int) (ScheduledPriceChange, error)
+	StoreScheduled(ctx context.Context, s *ScheduledPriceChange) error
+	// UpdateScheduledStatus move the change from a status to another, ErrConflict
+	// tells it was not in the from status anymore
+	UpdateScheduledStatus(ctx context.Context, id int, from ScheduledPriceStatus, to ScheduledPriceStatus) error
+}
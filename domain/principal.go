@@ -0,0 +1,29 @@
+package domain
+
+import "context"
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Subject    string   `json:"subject"`
+	Roles      []string `json:"roles"`
+	Scopes     []string `json:"scopes"`
+	AuthMethod string   `json:"auth_method"`
+}
+
+// SystemPrincipal is the service itself acting in a background job, it is granted every permission
+func SystemPrincipal(job string) Principal {
+	return Principal{Subject: "system:" + job, Roles: []string{RoleAdmin}, AuthMethod: "system"}
+}
+
+type principalContextKey struct{}
+
+// NewContextWithPrincipal return a copy of ctx carrying the given principal
+func NewContextWithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, p)
+}
+
+// PrincipalFromContext return the principal attached to ctx, if any
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalContextKey{}).(Principal)
+	return p, ok
+}
@@ -7,20 +7,85 @@ import (
 
 // Product ...
 type Product struct {
-	ID          int       `json:"id"`
-	Name        string    `json:"name"`
-	Price       int64     `json:"price"`
-	AuthorID    int       `json:"author_id"`
-	Description string    `json:"description"`
-	Image       string    `json:"image"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	CreatedAt   time.Time `json:"created_at"`
-	Author      Author    `json:"author"`
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Price       int64  `json:"price"`
+	AuthorID    int    `json:"author_id"`
+	Description string `json:"description"`
+	Image       string `json:"image"`
+	// Thumbnails are the URLs of the resized covers keyed by size, derived from Image
+	Thumbnails    map[string]string `json:"thumbnails,omitempty"`
+	Category      string            `json:"category"`
+	TaxClass      string            `json:"tax_class"`
+	PublishedYear int               `json:"published_year"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	CreatedAt     time.Time         `json:"created_at"`
+	Author        Author            `json:"author"`
+	// RatingAverage and RatingCount aggregate the approved reviews
+	RatingAverage float64 `json:"rating_average"`
+	RatingCount   int     `json:"rating_count"`
+	// Currency is the ISO 4217 code of Price, in minor units. Products are
+	// stored in the base currency and converted on the way out
+	Currency string `json:"currency,omitempty"`
+	// Available tells the product can be ordered, an unavailable product is
+	// still listed
+	Available bool `json:"available"`
+}
+
+// Money return the price of the product along with its currency
+func (p Product) Money() Money {
+	return Money{Amount: p.Price, Currency: p.Currency}
+}
+
+// PriceBucket is a price range of the price facet, Max is exclusive and 0 means unbounded
+type PriceBucket struct {
+	Key string
+	Min int64
+	Max int64
+}
+
+// PriceBuckets are the ranges the price facet count the products in
+var PriceBuckets = []PriceBucket{
+	{Key: "0-50000", Min: 0, Max: 50000},
+	{Key: "50000-100000", Min: 50000, Max: 100000},
+	{Key: "100000-200000", Min: 100000, Max: 200000},
+	{Key: "200000-", Min: 200000, Max: 0},
+}
+
+// ProductFilter represent the facet selections of a product listing, values
+// of the same facet are OR-ed and the facets are AND-ed
+type ProductFilter struct {
+	AuthorIDs    []int
+	Categories   []string
+	PriceBuckets []string
+	Years        []int
+	// Limit bound the number of products listed, 0 lists them all
+	Limit int
+}
+
+// FacetValue is the number of products having a value of a facet
+type FacetValue struct {
+	Value    string `json:"value"`
+	Label    string `json:"label,omitempty"`
+	Count    int    `json:"count"`
+	Selected bool   `json:"selected"`
+}
+
+// ProductFacets hold the facet counts of a product listing, the counts of a
+// facet ignore the selections of that same facet so that they stay selectable
+type ProductFacets struct {
+	Author   []FacetValue `json:"author"`
+	Price    []FacetValue `json:"price"`
+	Category []FacetValue `json:"category"`
+	Year     []FacetValue `json:"year"`
 }
 
 // ProductUseCase represent the product's usecases
 type ProductUseCase interface {
 	Fetch(ctx context.Context) ([]Product, error)
+	FetchFaceted(ctx context.Context, filter ProductFilter) ([]Product, ProductFacets, error)
+	// FetchFiltered list the products matching the filter without counting the facets
+	FetchFiltered(ctx context.Context, filter ProductFilter) ([]Product, error)
 	Store(context.Context, *Product) error
 	GetByID(ctx context.Context, id int) (Product, error)
 	Update(ctx context.Context, ar *Product, id int) error
@@ -30,8 +95,15 @@ type ProductUseCase interface {
 // ProductRepository represent the product's repository contract
 type ProductRepository interface {
 	Fetch(ctx context.Context) ([]Product, error)
+	FetchFiltered(ctx context.Context, filter ProductFilter) ([]Product, error)
+	FetchFacets(ctx context.Context, filter ProductFilter) (ProductFacets, error)
 	Store(ctx context.Context, a *Product) error
 	GetByID(ctx context.Context, id int) (Product, error)
+	// GetByIDForUpdate read the product like GetByID and lock it until the end of the transaction of ctx
+	GetByIDForUpdate(ctx context.Context, id int) (Product, error)
 	Update(ctx context.Context, ar *Product, id int) error
+	UpdateImage(ctx context.Context, id int, image string) error
+	// UpdatePrice change the price alone, recording it in the price history like Update does
+	UpdatePrice(ctx context.Context, id int, price int64) error
 	Delete(ctx context.Context, id int) error
 }
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ProductUpdate is the price and availability of a product as pushed to the
+// live subscribers of the product, or its deletion
+type ProductUpdate struct {
+	ProductID int   `json:"product_id"`
+	Price     int64 `json:"price"`
+	// Currency is the ISO 4217 code of Price, the one of the connection
+	Currency  string `json:"currency,omitempty"`
+	Available bool   `json:"available"`
+	// Deleted tells the product is gone, or never existed
+	Deleted   bool      `json:"deleted,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProductFeedConn is a live connection to the product feed, it receives the
+// updates of the products it subscribed to
+type ProductFeedConn interface {
+	// Subscribe follow the products and return their current update,
+	// ErrBadParamInput tells the connection would follow too many products
+	Subscribe(ctx context.Context, productIDs []int) ([]ProductUpdate, error)
+	Unsubscribe(productIDs []int)
+	// Updates is signalled when updates are waiting, Next takes them
+	Updates() <-chan struct{}
+	// Next return the waiting updates, only the latest of every product
+	Next() []ProductUpdate
+	Close()
+}
+
+// ProductFeed push the product updates to the live connections
+type ProductFeed interface {
+	// Connect open a connection of the client, ErrTooManyConnections tells
+	// the feed is full or the client holds too many connections already
+	Connect(ctx context.Context, client string) (ProductFeedConn, error)
+}
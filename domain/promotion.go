@@ -0,0 +1,197 @@
+package domain
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// DiscountType tell how the value of a promotion is taken off the price
+type DiscountType string
+
+const (
+	// DiscountPercent take Value percent off the price
+	DiscountPercent DiscountType = "percent"
+	// DiscountFixed take Value off the price of every unit, a coupon takes it
+	// off once per cart
+	DiscountFixed DiscountType = "fixed"
+)
+
+// IsValid report whether the discount type is a known one
+func (t DiscountType) IsValid() bool {
+	return t == DiscountPercent || t == DiscountFixed
+}
+
+// PromotionScope tell which products a promotion applies to, ScopeValue names them
+type PromotionScope string
+
+const (
+	// PromotionScopeAll apply to every product
+	PromotionScopeAll PromotionScope = "all"
+	// PromotionScopeProduct apply to the product whose id is ScopeValue
+	PromotionScopeProduct PromotionScope = "product"
+	// PromotionScopeAuthor apply to the products of the author whose id is ScopeValue
+	PromotionScopeAuthor PromotionScope = "author"
+	// PromotionScopeCategory apply to the products of the category ScopeValue
+	PromotionScopeCategory PromotionScope = "category"
+)
+
+// IsValid report whether the scope is a known one
+func (s PromotionScope) IsValid() bool {
+	switch s {
+	case PromotionScopeAll, PromotionScopeProduct, PromotionScopeAuthor, PromotionScopeCategory:
+		return true
+	}
+	return false
+}
+
+// Promotion is a discount rule, it applies automatically unless it has a
+// coupon code. Stackable promotions combine with each other, the others
+// only apply alone
+type Promotion struct {
+	ID         int            `json:"id"`
+	Name       string         `json:"name" validate:"required,max=200"`
+	Type       DiscountType   `json:"type" validate:"required"`
+	Value      int64          `json:"value" validate:"required,min=1"`
+	Scope      PromotionScope `json:"scope" validate:"required"`
+	ScopeValue string         `json:"scope_value"`
+	StartsAt   *time.Time     `json:"starts_at"`
+	EndsAt     *time.Time     `json:"ends_at"`
+	CouponCode string         `json:"coupon_code,omitempty"`
+	// UsageLimit bound the redemptions of the coupon, 0 means unlimited
+	UsageLimit int       `json:"usage_limit"`
+	UsageCount int       `json:"usage_count"`
+	Stackable  bool      `json:"stackable"`
+	Priority   int       `json:"priority"`
+	Active     bool      `json:"active"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AppliesTo report whether the product is in the scope of the promotion
+func (p Promotion) AppliesTo(product Product) bool {
+	switch p.Scope {
+	case PromotionScopeAll:
+		return true
+	case PromotionScopeProduct:
+		return p.ScopeValue == strconv.Itoa(product.ID)
+	case PromotionScopeAuthor:
+		return p.ScopeValue == strconv.Itoa(product.AuthorID)
+	case PromotionScopeCategory:
+		return p.ScopeValue == product.Category
+	}
+	return false
+}
+
+// Discount compute the amount the promotion takes off price, never more than price.
+// Percentages are rounded down in favour of the shop
+func (p Promotion) Discount(price int64) int64 {
+	var amount int64
+	switch p.Type {
+	case DiscountPercent:
+		amount = price * p.Value / 100
+	case DiscountFixed:
+		amount = p.Value
+	}
+	if amount > price {
+		amount = price
+	}
+	return amount
+}
+
+// AppliedPromotion is a promotion taken into account in a price quote
+type AppliedPromotion struct {
+	PromotionID int          `json:"promotion_id"`
+	Name        string       `json:"name"`
+	Type        DiscountType `json:"type"`
+	Value       int64        `json:"value"`
+	Amount      int64        `json:"amount"`
+}
+
+// SkippedPromotion is a promotion of the product left out of a price quote, and why
+type SkippedPromotion struct {
+	PromotionID int    `json:"promotion_id,omitempty"`
+	Name        string `json:"name"`
+	Reason      string `json:"reason"`
+}
+
+// PriceQuote is the price of a product once the promotions are applied
+type PriceQuote struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	CouponCode  string `json:"coupon_code,omitempty"`
+	// CouponApplied tell whether the coupon is one of the applied promotions
+	CouponApplied bool               `json:"coupon_applied"`
+	BasePrice     int64              `json:"base_price"`
+	Discount      int64              `json:"discount"`
+	FinalPrice    int64              `json:"final_price"`
+	Applied       []AppliedPromotion `json:"applied"`
+	Skipped       []SkippedPromotion `json:"skipped"`
+	QuotedAt      time.Time          `json:"quoted_at"`
+
+	// Quantity units of the product cost LineTotal, before tax
+	Quantity  int           `json:"quantity"`
+	LineTotal int64         `json:"line_total"`
+	Tax       *TaxBreakdown `json:"tax,omitempty"`
+}
+
+// QuoteOptions refine a price quote, the tax is only computed when a country is given
+type QuoteOptions struct {
+	CouponCode string
+	Quantity   int
+	TaxJurisdiction
+}
+
+// CartQuoteLine is a quantity of a product to price
+type CartQuoteLine struct {
+	ProductID int `json:"product_id" validate:"required"`
+	Quantity  int `json:"quantity" validate:"required,min=1"`
+}
+
+// CartQuoteRequest is a set of products to price together, taxed in the
+// country and region when a country is given
+type CartQuoteRequest struct {
+	CouponCode string          `json:"coupon_code"`
+	Country    string          `json:"country"`
+	Region     string          `json:"region"`
+	Lines      []CartQuoteLine `json:"lines" validate:"required,min=1,dive"`
+}
+
+// CartQuote is the price of a set of products, Total is what is paid for them
+type CartQuote struct {
+	CouponCode    string        `json:"coupon_code,omitempty"`
+	CouponApplied bool          `json:"coupon_applied"`
+	Lines         []PriceQuote  `json:"lines"`
+	Subtotal      int64         `json:"subtotal"`
+	Tax           *TaxBreakdown `json:"tax,omitempty"`
+	Total         int64         `json:"total"`
+	QuotedAt      time.Time     `json:"quoted_at"`
+}
+
+// PromotionUsecase represent the promotion's usecases
+type PromotionUsecase interface {
+	Fetch(ctx context.Context) ([]Promotion, error)
+	Store(ctx context.Context, p *Promotion) error
+	GetByID(ctx context.Context, id int) (Promotion, error)
+	Update(ctx context.Context, id int, p *Promotion) error
+	Delete(ctx context.Context, id int) error
+	Quote(ctx context.Context, productID int, opts QuoteOptions) (PriceQuote, error)
+	QuoteCart(ctx context.Context, req *CartQuoteRequest) (CartQuote, error)
+	// Redeem count a use of the coupon, ErrConflict tells its usage limit is reached
+	Redeem(ctx context.Context, coupon string) error
+	// Release give back a use of the coupon whose redemption was not completed
+	Release(ctx context.Context, coupon string) error
+}
+
+// PromotionRepository represent the promotion's repository contract
+type PromotionRepository interface {
+	Fetch(ctx context.Context) ([]Promotion, error)
+	// FetchApplicable list the active promotions whose scope includes the product
+	FetchApplicable(ctx context.Context, product Product) ([]Promotion, error)
+	GetByID(ctx context.Context, id int) (Promotion, error)
+	Store(ctx context.Context, p *Promotion) error
+	Update(ctx context.Context, p *Promotion) error
+	Delete(ctx context.Context, id int) error
+	Redeem(ctx context.Context, coupon string) error
+	Release(ctx context.Context, coupon string) error
+}
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// Permission is an action a principal may be allowed to perform
+type Permission string
+
+const (
+	// PermissionProductRead allow reading the products
+	PermissionProductRead Permission = "product:read"
+	// PermissionProductWrite allow creating and editing products
+	PermissionProductWrite Permission = "product:write"
+	// PermissionProductDelete allow deleting products
+	PermissionProductDelete Permission = "product:delete"
+	// PermissionAuthorRead allow reading the authors
+	PermissionAuthorRead Permission = "author:read"
+	// PermissionAuthorWrite allow creating and editing authors
+	PermissionAuthorWrite Permission = "author:write"
+	// PermissionAuthorDelete allow deleting authors
+	PermissionAuthorDelete Permission = "author:delete"
+	// PermissionOrderRead allow reading the orders of every customer
+	PermissionOrderRead Permission = "order:read"
+	// PermissionOrderManage allow placing orders for any customer and changing their status
+	PermissionOrderManage Permission = "order:manage"
+	// PermissionAPIKeyManage allow issuing and revoking API keys
+	PermissionAPIKeyManage Permission = "apikey:manage"
+	// PermissionReviewModerate allow approving, rejecting and deleting the reviews of anybody
+	PermissionReviewModerate Permission = "review:moderate"
+	// PermissionPromotionManage allow creating and editing the promotions and coupons
+	PermissionPromotionManage Permission = "promotion:manage"
+	// PermissionCurrencyManage allow maintaining the exchange rates
+	PermissionCurrencyManage Permission = "currency:manage"
+	// PermissionTaxManage allow maintaining the tax rates
+	PermissionTaxManage Permission = "tax:manage"
+	// PermissionAuditRead allow reading the audit log of the catalog
+	PermissionAuditRead Permission = "audit:read"
+	// PermissionWebhookManage allow subscribing webhooks to the catalog events and following their deliveries
+	PermissionWebhookManage Permission = "webhook:manage"
+	// PermissionWebhookAdmin allow managing the webhook subscriptions of everybody
+	PermissionWebhookAdmin Permission = "webhook:admin"
+	// PermissionEventRead allow following the catalog events as they happen
+	PermissionEventRead Permission = "event:read"
+)
+
+const (
+	// RoleAdmin may do everything
+	RoleAdmin = "admin"
+	// RoleCatalogEditor maintain the products and authors but may not delete authors
+	RoleCatalogEditor = "catalog_editor"
+	// RolePartner is a read only partner of the catalog
+	RolePartner = "partner"
+	// RoleCustomer is a registered customer of the shop
+	RoleCustomer = "customer"
+)
+
+// rolePermissions hold the permissions granted by every role
+var rolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermissionProductRead, PermissionProductWrite, PermissionProductDelete,
+		PermissionAuthorRead, PermissionAuthorWrite, PermissionAuthorDelete,
+		PermissionOrderRead, PermissionOrderManage,
+		PermissionAPIKeyManage,
+		PermissionReviewModerate,
+		PermissionPromotionManage,
+		PermissionCurrencyManage,
+		PermissionTaxManage,
+		PermissionAuditRead,
+		PermissionWebhookManage, PermissionWebhookAdmin,
+		PermissionEventRead,
+	},
+	RoleCatalogEditor: {
+		PermissionProductRead, PermissionProductWrite, PermissionProductDelete,
+		PermissionAuthorRead, PermissionAuthorWrite,
+		PermissionReviewModerate,
+		PermissionPromotionManage,
+		PermissionEventRead,
+	},
+	RolePartner: {
+		PermissionProductRead, PermissionAuthorRead,
+		PermissionWebhookManage,
+	},
+	RoleCustomer: {
+		PermissionProductRead, PermissionAuthorRead,
+	},
+}
+
+// Can report whether the principal is granted the permission by one of its roles or scopes
+func (p Principal) Can(perm Permission) bool {
+	for _, role := range p.Roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == perm {
+				return true
+			}
+		}
+	}
+	for _, scope := range p.Scopes {
+		if Permission(scope) == perm {
+			return true
+		}
+		for _, granted := range scopePermissions[scope] {
+			if granted == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsUser report whether the principal is the registered user of the given id
+func (p Principal) IsUser(id int) bool {
+	return p.Subject == "user:"+strconv.Itoa(id)
+}
+
+// UserID return the id of the registered user the principal is, if it is one
+func (p Principal) UserID() (int, bool) {
+	if !strings.HasPrefix(p.Subject, "user:") {
+		return 0, false
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(p.Subject, "user:"))
+	return id, err == nil
+}
+
+// Authorize check that the principal of ctx is granted the permission, it
+// returns ErrUnauthorized when nobody is authenticated and ErrForbidden when
+// the principal lacks the permission
+func Authorize(ctx context.Context, perm Permission) error {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return ErrUnauthorized
+	}
+	if !p.Can(perm) {
+		return ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "context"
+
+type requestIDContextKey struct{}
+
+// NewContextWithRequestID return a copy of ctx carrying the id of the request it serves
+func NewContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestIDFromContext return the id of the request ctx serves, empty outside of a request
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ReviewStatus is the moderation state of a review
+type ReviewStatus string
+
+const (
+	// ReviewPending is awaiting moderation, every new or edited review starts pending
+	ReviewPending ReviewStatus = "pending"
+	// ReviewApproved is published and counts in the rating of the product
+	ReviewApproved ReviewStatus = "approved"
+	// ReviewRejected is hidden from everybody but its author and the moderators
+	ReviewRejected ReviewStatus = "rejected"
+)
+
+// IsValid report whether the status is a known one
+func (s ReviewStatus) IsValid() bool {
+	switch s {
+	case ReviewPending, ReviewApproved, ReviewRejected:
+		return true
+	}
+	return false
+}
+
+// Review is the rating and opinion of a customer on a product, a customer reviews a product once
+type Review struct {
+	ID           int          `json:"id"`
+	ProductID    int          `json:"product_id"`
+	UserID       int          `json:"user_id"`
+	Rating       int          `json:"rating" validate:"required,min=1,max=5"`
+	Title        string       `json:"title" validate:"max=200"`
+	Body         string       `json:"body" validate:"max=10000"`
+	Status       ReviewStatus `json:"status"`
+	HelpfulCount int          `json:"helpful_count"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+// ReviewModeration is a moderation decision on a review
+type ReviewModeration struct {
+	Status ReviewStatus `json:"status" validate:"required"`
+}
+
+// ReviewFilter select the reviews to fetch, zero values match everything
+type ReviewFilter struct {
+	ProductID int
+	UserID    int
+	Status    ReviewStatus
+}
+
+// ReviewUsecase represent the review's usecases
+type ReviewUsecase interface {
+	Fetch(ctx context.Context, filter ReviewFilter) ([]Review, error)
+	Store(ctx context.Context, r *Review) error
+	Update(ctx context.Context, r *Review) error
+	Delete(ctx context.Context, id int) error
+	Moderate(ctx context.Context, id int, m *ReviewModeration) error
+	VoteHelpful(ctx context.Context, id int) error
+}
+
+// ReviewRepository represent the review's repository contract, the mutations
+// keep the rating aggregates of the product up to date
+type ReviewRepository interface {
+	Fetch(ctx context.Context, filter ReviewFilter) ([]Review, error)
+	GetByID(ctx context.Context, id int) (Review, error)
+	GetByUserAndProduct(ctx context.Context, userID int, productID int) (Review, error)
+	Store(ctx context.Context, r *Review) error
+	Update(ctx context.Context, r *Review) error
+	UpdateStatus(ctx context.Context, id int, status ReviewStatus) error
+	Delete(ctx context.Context, id int) error
+	// AddHelpfulVote count the vote of the user once, a repeated vote is ErrConflict
+	AddHelpfulVote(ctx context.Context, id int, userID int) error
+}
@@ -0,0 +1,38 @@
+package domain
+
+import "context"
+
+const (
+	// SearchTypeProduct is the type of the hits describing a product
+	SearchTypeProduct = "product"
+	// SearchTypeAuthor is the type of the hits describing an author
+	SearchTypeAuthor = "author"
+)
+
+// SearchQuery represent a full-text search request
+type SearchQuery struct {
+	Q     string
+	Limit int
+}
+
+// SearchHit is a ranked result of a full-text search
+type SearchHit struct {
+	Type       string            `json:"type"`
+	ID         int               `json:"id"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+	Product    *Product          `json:"product,omitempty"`
+	Author     *Author           `json:"author,omitempty"`
+	// MatchedTerms are the indexed terms which matched the query, they drive the highlighting
+	MatchedTerms []string `json:"-"`
+}
+
+// SearchUsecase represent the search's usecases
+type SearchUsecase interface {
+	Search(ctx context.Context, q SearchQuery) ([]SearchHit, error)
+}
+
+// SearchRepository represent the search's repository contract
+type SearchRepository interface {
+	Search(ctx context.Context, q SearchQuery) ([]SearchHit, error)
+}
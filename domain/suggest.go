@@ -0,0 +1,21 @@
+package domain
+
+import "context"
+
+// Suggestion is a completion of what the user is typing in the search box
+type Suggestion struct {
+	Type  string  `json:"type"`
+	ID    int     `json:"id"`
+	Text  string  `json:"text"`
+	Score float64 `json:"score"`
+}
+
+// SuggestUsecase represent the suggestion's usecases
+type SuggestUsecase interface {
+	Suggest(ctx context.Context, prefix string, limit int) ([]Suggestion, error)
+}
+
+// SuggestRepository represent the suggestion's repository contract
+type SuggestRepository interface {
+	Suggest(ctx context.Context, prefix string, limit int) ([]Suggestion, error)
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"context"
+	"math/big"
+	"time"
+)
+
+// TaxClassStandard is the tax class of the products that do not tell theirs
+const TaxClassStandard = "standard"
+
+// TaxRate is a tax levied on a tax class in a country, or in a region of it.
+// The rates of the whole country and those of the region add up
+type TaxRate struct {
+	ID       int    `json:"id"`
+	Country  string `json:"country" validate:"required,len=2"`
+	Region   string `json:"region" validate:"max=10"`
+	TaxClass string `json:"tax_class" validate:"required,max=50"`
+	Name     string `json:"name" validate:"required,max=100"`
+	// Rate is the percentage taken, as an exact decimal
+	Rate      string    `json:"rate" validate:"required"`
+	UpdatedAt time.Time `json:"updated_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Fraction parse the rate as a fraction of the net amount, it is not valid unless
+// between 0 and 100 percent
+func (r TaxRate) Fraction() (*big.Rat, bool) {
+	rate, ok := new(big.Rat).SetString(r.Rate)
+	if !ok || rate.Sign() < 0 || rate.Cmp(big.NewRat(100, 1)) > 0 {
+		return nil, false
+	}
+	return rate.Quo(rate, big.NewRat(100, 1)), true
+}
+
+// TaxJurisdiction is where the goods are taxed, Country is an ISO 3166-1
+// alpha-2 code and Region the subdivision part of an ISO 3166-2 code
+type TaxJurisdiction struct {
+	Country string `json:"country"`
+	Region  string `json:"region,omitempty"`
+}
+
+// TaxableLine is an amount to tax, the price of a quantity of a product
+type TaxableLine struct {
+	ProductID int
+	TaxClass  string
+	Amount    int64
+}
+
+// TaxAmount is the part of a line taken by a tax rate
+type TaxAmount struct {
+	TaxRateID int    `json:"tax_rate_id"`
+	Name      string `json:"name"`
+	Rate      string `json:"rate"`
+	Amount    int64  `json:"amount"`
+}
+
+// TaxedLine is the tax breakdown of a line, Gross is Net plus the taxes
+type TaxedLine struct {
+	ProductID int         `json:"product_id"`
+	TaxClass  string      `json:"tax_class"`
+	Net       int64       `json:"net"`
+	Tax       int64       `json:"tax"`
+	Gross     int64       `json:"gross"`
+	Taxes     []TaxAmount `json:"taxes"`
+}
+
+// TaxBreakdown is the tax of a set of lines in a jurisdiction, the amounts
+// of the lines were taken as gross when PricesIncludeTax and as net otherwise
+type TaxBreakdown struct {
+	TaxJurisdiction
+	PricesIncludeTax bool        `json:"prices_include_tax"`
+	Lines            []TaxedLine `json:"lines"`
+	Net              int64       `json:"net"`
+	Tax              int64       `json:"tax"`
+	Gross            int64       `json:"gross"`
+}
+
+// TaxUsecase represent the tax's usecases
+type TaxUsecase interface {
+	FetchRates(ctx context.Context, country string) ([]TaxRate, error)
+	StoreRate(ctx context.Context, r *TaxRate) error
+	UpdateRate(ctx context.Context, id int, r *TaxRate) error
+	DeleteRate(ctx context.Context, id int) error
+	// Calculate compute the tax of every line in the jurisdiction
+	Calculate(ctx context.Context, j TaxJurisdiction, lines []TaxableLine) (TaxBreakdown, error)
+}
+
+// TaxRepository represent the tax rate's repository contract
+type TaxRepository interface {
+	Fetch(ctx context.Context, country string) ([]TaxRate, error)
+	// FetchApplicable list the rates of the tax classes in the country and in its region
+	FetchApplicable(ctx context.Context, j TaxJurisdiction, classes []string) ([]TaxRate, error)
+	GetByID(ctx context.Context, id int) (TaxRate, error)
+	Store(ctx context.Context, r *TaxRate) error
+	Update(ctx context.Context, r *TaxRate) error
+	Delete(ctx context.Context, id int) error
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// User ...
+type User struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name" validate:"required"`
+	Email        string    `json:"email" validate:"required,email"`
+	PasswordHash string    `json:"-"`
+	Role         string    `json:"role"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// UserRegistration represent the request body to register a new customer
+type UserRegistration struct {
+	Name     string `json:"name" validate:"required"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+// UserCredential represent the request body to log in
+type UserCredential struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// PasswordReset represent the request body to set a new password with a reset token
+type PasswordReset struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+// Session is a logged in session of a user, only the hash of the token is persisted
+type Session struct {
+	Token     string    `json:"token"`
+	TokenHash string    `json:"-"`
+	UserID    int       `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PasswordResetToken is a single use token allowing a user to set a new password
+type PasswordResetToken struct {
+	TokenHash string
+	UserID    int
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// Mail represent an outgoing email
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer represent the contract to deliver emails
+type Mailer interface {
+	Send(ctx context.Context, m Mail) error
+}
+
+// UserUsecase represent the user's usecases
+type UserUsecase interface {
+	Register(ctx context.Context, r *UserRegistration) (User, error)
+	Login(ctx context.Context, cred *UserCredential) (Session, error)
+	Logout(ctx context.Context, token string) error
+	Authenticate(ctx context.Context, token string) (User, error)
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, r *PasswordReset) error
+	GetByID(ctx context.Context, id int) (User, error)
+	UpdateProfile(ctx context.Context, id int, u *User) error
+}
+
+// UserRepository represent the user's repository contract
+type UserRepository interface {
+	Store(ctx context.Context, u *User) error
+	GetByID(ctx context.Context, id int) (User, error)
+	GetByEmail(ctx context.Context, email string) (User, error)
+	Update(ctx context.Context, id int, u *User) error
+	UpdatePassword(ctx context.Context, id int, passwordHash string) error
+	StoreSession(ctx context.Context, s *Session) error
+	GetSession(ctx context.Context, tokenHash string) (Session, error)
+	DeleteSession(ctx context.Context, tokenHash string) error
+	DeleteSessionsByUserID(ctx context.Context, userID int) error
+	StorePasswordResetToken(ctx context.Context, t *PasswordResetToken) error
+	GetPasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error)
+	MarkPasswordResetTokenUsed(ctx context.Context, tokenHash string) error
+}
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// EventTypes list the types of the events a webhook may subscribe to
+var EventTypes = []string{
+	EventProductCreated, EventProductUpdated, EventProductDeleted,
+	EventAuthorCreated, EventAuthorUpdated, EventAuthorDeleted,
+}
+
+// IsValidEventType report whether t is one of the known event types
+func IsValidEventType(t string) bool {
+	for _, known := range EventTypes {
+		if known == t {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookSubscription is a URL the events of the given types are posted to,
+// every event type when there is none. The deliveries are signed with the
+// secret, only shown when it is set
+type WebhookSubscription struct {
+	ID         int       `json:"id"`
+	Owner      string    `json:"owner"`
+	URL        string    `json:"url" validate:"required,url,max=2000"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"secret,omitempty"`
+	Active     bool      `json:"active"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Wants report whether the event is posted to the subscription
+func (s WebhookSubscription) Wants(e Event) bool {
+	if !s.Active {
+		return false
+	}
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range s.EventTypes {
+		if t == e.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus tell where a delivery stands
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending is waiting for its next attempt
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliverySucceeded was acknowledged by a 2xx response
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	// WebhookDeliveryDead failed every attempt, it is only sent again on demand
+	WebhookDeliveryDead WebhookDeliveryStatus = "dead"
+)
+
+// IsValid report whether the status is a known one
+func (s WebhookDeliveryStatus) IsValid() bool {
+	switch s {
+	case WebhookDeliveryPending, WebhookDeliverySucceeded, WebhookDeliveryDead:
+		return true
+	}
+	return false
+}
+
+// WebhookDelivery is an event to post to a subscription and how the attempts went
+type WebhookDelivery struct {
+	ID             int                   `json:"id"`
+	SubscriptionID int                   `json:"subscription_id"`
+	EventID        string                `json:"event_id"`
+	EventType      string                `json:"event_type"`
+	Payload        json.RawMessage       `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"`
+	// LastStatusCode is the HTTP status of the last attempt, 0 when it got no response
+	LastStatusCode int        `json:"last_status_code"`
+	LastError      string     `json:"last_error"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// WebhookDeliveryFilter select a page of the deliveries of a subscription,
+// the latest first, before the delivery id Before when it is set
+type WebhookDeliveryFilter struct {
+	SubscriptionID int
+	Status         WebhookDeliveryStatus
+	Before         int
+	Limit          int
+}
+
+// WebhookUsecase represent the webhook's usecases, the subscriptions are
+// managed by their owner
+type WebhookUsecase interface {
+	Fetch(ctx context.Context) ([]WebhookSubscription, error)
+	Store(ctx context.Context, s *WebhookSubscription) error
+	GetByID(ctx context.Context, id int) (WebhookSubscription, error)
+	Update(ctx context.Context, id int, s *WebhookSubscription) error
+	Delete(ctx context.Context, id int) error
+	FetchDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error)
+	// Redeliver send a delivery again, whatever its status
+	Redeliver(ctx context.Context, deliveryID int) (WebhookDelivery, error)
+	// Enqueue plan the deliveries of the event to the subscriptions wanting it
+	Enqueue(ctx context.Context, e Event) error
+	// DeliverDue attempt the deliveries due at now, returning how many succeeded
+	DeliverDue(ctx context.Context, now time.Time) (int, error)
+}
+
+// WebhookRepository represent the webhook's repository contract
+type WebhookRepository interface {
+	Fetch(ctx context.Context, owner string) ([]WebhookSubscription, error)
+	FetchActive(ctx context.Context) ([]WebhookSubscription, error)
+	GetByID(ctx context.Context, id int) (WebhookSubscription, error)
+	Store(ctx context.Context, s *WebhookSubscription) error
+	Update(ctx context.Context, s *WebhookSubscription) error
+	Delete(ctx context.Context, id int) error
+	FetchDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error)
+	GetDelivery(ctx context.Context, id int) (WebhookDelivery, error)
+	// StoreDelivery add a delivery, ErrConflict tells the event is already planned for the subscription
+	StoreDelivery(ctx context.Context, d *WebhookDelivery) error
+	// FetchDue list at most limit pending deliveries due at now, the oldest first
+	FetchDue(ctx context.Context, now time.Time, limit int) ([]WebhookDelivery, error)
+	// Claim push the next attempt of a pending delivery due at dueAt to until,
+	// ErrConflict tells another dispatcher claimed it first
+	Claim(ctx context.Context, id int, dueAt time.Time, until time.Time) error
+	// UpdateDelivery save the status and the attempts of a delivery
+	UpdateDelivery(ctx context.Context, d *WebhookDelivery) error
+}
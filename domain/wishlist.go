@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// WishlistVisibility tell who may see a wishlist
+type WishlistVisibility string
+
+const (
+	// WishlistPrivate is only visible to its owner
+	WishlistPrivate WishlistVisibility = "private"
+	// WishlistPublic is visible to anybody knowing its slug
+	WishlistPublic WishlistVisibility = "public"
+)
+
+// IsValid report whether the visibility is a known one
+func (v WishlistVisibility) IsValid() bool {
+	return v == WishlistPrivate || v == WishlistPublic
+}
+
+// Wishlist is a named and ordered list of products kept by a user
+type Wishlist struct {
+	ID          int                `json:"id"`
+	UserID      int                `json:"user_id"`
+	Name        string             `json:"name" validate:"required,max=100"`
+	Description string             `json:"description" validate:"max=1000"`
+	Visibility  WishlistVisibility `json:"visibility"`
+	// Slug identify the wishlist in its shareable URL
+	Slug      string         `json:"slug"`
+	Items     []WishlistItem `json:"items"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// WishlistItem is a product of a wishlist at a position, starting at 0
+type WishlistItem struct {
+	WishlistID int    `json:"-"`
+	ProductID  int    `json:"product_id" validate:"required"`
+	Position   *int   `json:"position,omitempty"`
+	Note       string `json:"note" validate:"max=1000"`
+	// LastPrice is the price of the product when last checked for a drop
+	LastPrice int64     `json:"-"`
+	AddedAt   time.Time `json:"added_at"`
+	Product   *Product  `json:"product,omitempty"`
+}
+
+// PriceDrop is the price of a product going down while it is in a wishlist
+type PriceDrop struct {
+	UserID       int
+	WishlistID   int
+	WishlistName string
+	Product      Product
+	OldPrice     int64
+	NewPrice     int64
+}
+
+// PriceDropNotifier is told about the price drops of the wishlisted products
+type PriceDropNotifier interface {
+	PriceDropped(ctx context.Context, drop PriceDrop) error
+}
+
+// WishlistUsecase represent the wishlist's usecases
+type WishlistUsecase interface {
+	FetchMine(ctx context.Context) ([]Wishlist, error)
+	Store(ctx context.Context, w *Wishlist) error
+	GetByID(ctx context.Context, id int) (Wishlist, error)
+	GetBySlug(ctx context.Context, slug string) (Wishlist, error)
+	Update(ctx context.Context, id int, w *Wishlist) error
+	Delete(ctx context.Context, id int) error
+	AddItem(ctx context.Context, wishlistID int, item *WishlistItem) error
+	UpdateItem(ctx context.Context, wishlistID int, item *WishlistItem) error
+	RemoveItem(ctx context.Context, wishlistID int, productID int) error
+}
+
+// WishlistRepository represent the wishlist's repository contract
+type WishlistRepository interface {
+	FetchByUser(ctx context.Context, userID int) ([]Wishlist, error)
+	GetByID(ctx context.Context, id int) (Wishlist, error)
+	GetBySlug(ctx context.Context, slug string) (Wishlist, error)
+	Store(ctx context.Context, w *Wishlist) error
+	Update(ctx context.Context, w *Wishlist) error
+	Delete(ctx context.Context, id int) error
+	FetchItems(ctx context.Context, wishlistID int) ([]WishlistItem, error)
+	// AddItem append the item to the wishlist, a product is in a wishlist once
+	AddItem(ctx context.Context, item *WishlistItem) error
+	// UpdateItem change the note of the item and move it to its position, shifting the items in between
+	UpdateItem(ctx context.Context, item *WishlistItem) error
+	RemoveItem(ctx context.Context, wishlistID int, productID int) error
+	// ClaimPriceDrops lower to price the last price of the wishlist items last
+	// seen above it and list them, an item is claimed by a single caller
+	ClaimPriceDrops(ctx context.Context, productID int, price int64) ([]PriceDrop, error)
+	// UpdateLastPrice raise to price the last price of the wishlist items last seen below it
+	UpdateLastPrice(ctx context.Context, productID int, price int64) error
+}
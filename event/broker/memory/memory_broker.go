@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+type subscriber struct {
+	id      int
+	handler domain.EventHandler
+}
+
+// memoryBroker hand the events to the subscribers of the same process
+type memoryBroker struct {
+	mu          sync.RWMutex
+	subscribers map[string]subscriber
+	nextID      int
+}
+
+// NewMemoryBroker will create an in-memory domain.EventBroker
+func NewMemoryBroker() domain.EventBroker {
+	return &memoryBroker{subscribers: map[string]subscriber{}}
+}
+
+// Publish call every subscriber with the event, in turn, a failing one does
+// not keep the event from the others. The lock is not held meanwhile so that
+// a handler may unsubscribe
+func (b *memoryBroker) Publish(ctx context.Context, e domain.Event) map[string]error {
+	b.mu.RLock()
+	handlers := make(map[string]domain.EventHandler, len(b.subscribers))
+	for name, sub := range b.subscribers {
+		handlers[name] = sub.handler
+	}
+	b.mu.RUnlock()
+	failures := map[string]error{}
+	for name, h := range handlers {
+		if err := h(ctx, e); err != nil {
+			failures[name] = err
+		}
+	}
+	return failures
+}
+
+func (b *memoryBroker) Deliver(ctx context.Context, name string, e domain.Event) error {
+	b.mu.RLock()
+	sub, ok := b.subscribers[name]
+	b.mu.RUnlock()
+	if !ok {
+		return domain.ErrNotFound
+	}
+	return sub.handler(ctx, e)
+}
+
+// Subscribe register the handler, replacing the one of the same name
+func (b *memoryBroker) Subscribe(name string, h domain.EventHandler) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.nextID
+	b.nextID++
+	b.subscribers[name] = subscriber{id: id, handler: h}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			// the handler may have been replaced since
+			if b.subscribers[name].id == id {
+				delete(b.subscribers, name)
+			}
+		})
+	}
+}
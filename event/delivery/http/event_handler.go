@@ -0,0 +1,146 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+)
+
+const (
+	// heartbeatInterval is how often a comment is sent on an idle stream, so
+	// that the proxies do not close it
+	heartbeatInterval = 15 * time.Second
+	// retryMillis is how long the clients wait before reconnecting
+	retryMillis = 3000
+)
+
+// EventHandler represent the httphandler for the event stream
+type EventHandler struct {
+	EUsecase domain.EventStreamUsecase
+}
+
+// NewEventHandler will initialize the event stream endpoint
+func NewEventHandler(e *echo.Echo, us domain.EventStreamUsecase, auth echo.MiddlewareFunc) {
+	handler := &EventHandler{
+		EUsecase: us,
+	}
+	e.GET("/events/stream", handler.Stream, auth)
+}
+
+// Stream will send the catalog events as Server-Sent Events, of the entity
+// types of the comma separated entity query param or of every type. The id
+// of every message is the sequence of its event, a client reconnecting with
+// the Last-Event-ID header gets the events it missed while they are still
+// buffered, a reset message tells it missed more and should reload its data
+func (h *EventHandler) Stream(c echo.Context) error {
+	var lastSequence int64
+	if v := c.Request().Header.Get("Last-Event-ID"); v != "" {
+		var err error
+		if lastSequence, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return failed(c, domain.ErrBadParamInput)
+		}
+	}
+	var entityTypes []string
+	if v := c.QueryParam("entity"); v != "" {
+		entityTypes = strings.Split(v, ",")
+	}
+	ctx := c.Request().Context()
+	sub, err := h.EUsecase.Subscribe(ctx, lastSequence, entityTypes)
+	if err != nil {
+		return failed(c, err)
+	}
+	defer sub.Close()
+
+	res := c.Response()
+	header := res.Header()
+	header.Set(echo.HeaderContentType, "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// nginx would otherwise hold the messages back
+	header.Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	if _, err = fmt.Fprintf(res, "retry: %d\n\n", retryMillis); err != nil {
+		return nil
+	}
+	if sub.Gap {
+		if _, err = fmt.Fprint(res, "event: reset\ndata: {}\n\n"); err != nil {
+			return nil
+		}
+	}
+	for _, e := range sub.Replay {
+		if err = send(res, e); err != nil {
+			return nil
+		}
+	}
+	res.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-sub.Events:
+			if !ok {
+				// too far behind, the client reconnects and resumes from the buffer
+				return nil
+			}
+			if err = send(res, e); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			if _, err = fmt.Fprint(res, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		}
+		res.Flush()
+	}
+}
+
+// send write an event as a message named after its type
+func send(res *echo.Response, e domain.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(res, "id: %d\nevent: %s\ndata: %s\n\n", e.Sequence, e.Type, data)
+	return err
+}
+
+// failed write the error response of err, a denied permission is described with a problem detail
+func failed(c echo.Context, err error) error {
+	status := getStatusCode(err)
+	if status == http.StatusForbidden {
+		c.Response().Header().Set(echo.HeaderContentType, response.ProblemContentType)
+		return c.JSON(status, response.NewProblem(status, err.Error()))
+	}
+	return c.JSON(status, response.ResponseFailed{Success: false, Message: err.Error()})
+}
+
+func getStatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	logrus.Error(err)
+	switch {
+	case errors.Is(err, domain.ErrBadParamInput):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,113 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/sqltx"
+)
+
+// mysqlEventRepository represent the connection database struct
+type mysqlEventRepository struct {
+	Conn *sql.DB
+}
+
+// NewMysqlEventRepository will create an object that represent the event outbox Repository interface
+func NewMysqlEventRepository(Conn *sql.DB) domain.EventRepository {
+	return &mysqlEventRepository{Conn: Conn}
+}
+
+// Store append the event to the outbox, in the transaction of ctx so that it
+// is only recorded along with the change it tells about
+func (m *mysqlEventRepository) Store(ctx context.Context, e *domain.Event) error {
+	query := `INSERT INTO event_outbox (id, type, entity_type, entity_id, payload, actor, request_id, occurred_at) VALUES(?,?,?,?,?,?,?,?)`
+	result, err := sqltx.From(ctx, m.Conn).ExecContext(ctx, query, e.ID, e.Type, e.EntityType, e.EntityID, []byte(e.Payload), e.Actor, e.RequestID, e.OccurredAt)
+	if err != nil {
+		return err
+	}
+	e.Sequence, err = result.LastInsertId()
+	return err
+}
+
+func (m *mysqlEventRepository) FetchUnpublished(ctx context.Context, limit int) (result []domain.Event, err error) {
+	query := `SELECT sequence, id, type, entity_type, entity_id, payload, actor, request_id, occurred_at
+		FROM event_outbox WHERE published_at IS NULL ORDER BY sequence LIMIT ?`
+	rows, err := m.Conn.QueryContext(ctx, query, limit)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.Event, 0)
+	for rows.Next() {
+		t := domain.Event{}
+		var payload []byte
+		err = rows.Scan(&t.Sequence, &t.ID, &t.Type, &t.EntityType, &t.EntityID, &payload, &t.Actor, &t.RequestID, &t.OccurredAt)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		t.Payload = payload
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+func (m *mysqlEventRepository) MarkPublished(ctx context.Context, sequence int64) error {
+	_, err := m.Conn.ExecContext(ctx, `UPDATE event_outbox SET published_at=? WHERE sequence=?`, time.Now(), sequence)
+	return err
+}
+
+// StoreRetry upsert the retry, keyed by the event and the subscriber
+func (m *mysqlEventRepository) StoreRetry(ctx context.Context, r domain.EventRetry) error {
+	query := `INSERT INTO event_retry (sequence, subscriber, attempts, next_attempt_at, last_error) VALUES(?,?,?,?,?)
+		ON DUPLICATE KEY UPDATE attempts=VALUES(attempts), next_attempt_at=VALUES(next_attempt_at), last_error=VALUES(last_error)`
+	_, err := m.Conn.ExecContext(ctx, query, r.Event.Sequence, r.Subscriber, r.Attempts, r.NextAttemptAt, r.LastError)
+	return err
+}
+
+func (m *mysqlEventRepository) FetchDueRetries(ctx context.Context, now time.Time, limit int) (result []domain.EventRetry, err error) {
+	query := `SELECT e.sequence, e.id, e.type, e.entity_type, e.entity_id, e.payload, e.actor, e.request_id, e.occurred_at,
+		r.subscriber, r.attempts, r.next_attempt_at, r.last_error
+		FROM event_retry r JOIN event_outbox e ON e.sequence = r.sequence
+		WHERE r.next_attempt_at<=? ORDER BY r.next_attempt_at, r.sequence LIMIT ?`
+	rows, err := m.Conn.QueryContext(ctx, query, now, limit)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.EventRetry, 0)
+	for rows.Next() {
+		t := domain.EventRetry{}
+		e := &t.Event
+		var payload []byte
+		err = rows.Scan(&e.Sequence, &e.ID, &e.Type, &e.EntityType, &e.EntityID, &payload, &e.Actor, &e.RequestID, &e.OccurredAt,
+			&t.Subscriber, &t.Attempts, &t.NextAttemptAt, &t.LastError)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		e.Payload = payload
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+func (m *mysqlEventRepository) DeleteRetry(ctx context.Context, sequence int64, subscriber string) error {
+	_, err := m.Conn.ExecContext(ctx, `DELETE FROM event_retry WHERE sequence=? AND subscriber=?`, sequence, subscriber)
+	return err
+}
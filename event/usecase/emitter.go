@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/sqltx"
+)
+
+// OutboxEmitter record the events in the outbox, the relay publishes them
+type OutboxEmitter struct {
+	eventRepo domain.EventRepository
+	relay     *OutboxRelay
+}
+
+// NewOutboxEmitter will create an emitter storing the events in r and waking
+// relay up once they are committed
+func NewOutboxEmitter(r domain.EventRepository, relay *OutboxRelay) domain.EventEmitter {
+	return &OutboxEmitter{
+		eventRepo: r,
+		relay:     relay,
+	}
+}
+
+// Emit will store the events, identified and stamped with the principal and
+// the request of ctx
+func (o *OutboxEmitter) Emit(ctx context.Context, events ...domain.Event) (err error) {
+	actor := ""
+	if p, ok := domain.PrincipalFromContext(ctx); ok {
+		actor = p.Subject
+	}
+	now := time.Now()
+	for i := range events {
+		e := &events[i]
+		if e.ID, err = newEventID(); err != nil {
+			return
+		}
+		e.Actor = actor
+		e.RequestID = domain.RequestIDFromContext(ctx)
+		e.OccurredAt = now
+		if err = o.eventRepo.Store(ctx, e); err != nil {
+			return
+		}
+	}
+	sqltx.AfterCommit(ctx, o.relay.Wake)
+	return
+}
+
+func newEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
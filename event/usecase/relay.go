@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+const (
+	// relayBatchSize is the number of events read from the outbox at once
+	relayBatchSize = 100
+
+	// maxRetryAttempts is the number of times a subscriber is handed an event
+	// it fails to handle before the event is given up for that subscriber
+	maxRetryAttempts = 10
+	// baseRetryDelay is the wait after the first failure, doubled after every other one
+	baseRetryDelay = 5 * time.Second
+	// maxRetryDelay bound the wait between two attempts
+	maxRetryDelay = 30 * time.Minute
+	// maxErrorLength bound the error kept about a failed attempt
+	maxErrorLength = 500
+)
+
+// OutboxRelay publish the events of the outbox to the broker, in order. An
+// event is marked published once every subscriber took it or had its failure
+// recorded, so it is published at least once: after a crash, or with several
+// instances relaying the same outbox, the consumers may see it again and tell
+// by its id. A subscriber failing an event is handed it again later on its
+// own, the others and the events after it are not held up
+type OutboxRelay struct {
+	eventRepo domain.EventRepository
+	broker    domain.EventBroker
+	interval  time.Duration
+	wake      chan struct{}
+}
+
+// NewOutboxRelay will create a relay looking for unpublished events every
+// interval, or as soon as it is woken up
+func NewOutboxRelay(r domain.EventRepository, b domain.EventBroker, interval time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		eventRepo: r,
+		broker:    b,
+		interval:  interval,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Wake make the relay look for unpublished events now, it never blocks
+func (r *OutboxRelay) Wake() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run publish the events and retry the failed ones until ctx is done
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if err := r.drain(ctx); err != nil {
+			logrus.Error(err)
+		}
+		if err := r.retry(ctx, time.Now()); err != nil {
+			logrus.Error(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.wake:
+		}
+	}
+}
+
+// drain publish the unpublished events batch after batch. The failures of
+// the subscribers are recorded for a retry, only a failure of the outbox
+// itself stops it so that the events are published in order
+func (r *OutboxRelay) drain(ctx context.Context) error {
+	for {
+		events, err := r.eventRepo.FetchUnpublished(ctx, relayBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			for subscriber, errHandler := range r.broker.Publish(ctx, e) {
+				if err = r.failed(ctx, domain.EventRetry{Event: e, Subscriber: subscriber}, errHandler); err != nil {
+					return err
+				}
+			}
+			if err = r.eventRepo.MarkPublished(ctx, e.Sequence); err != nil {
+				return err
+			}
+		}
+		if len(events) < relayBatchSize {
+			return nil
+		}
+	}
+}
+
+// retry hand the events due at now again to the subscribers that failed them
+func (r *OutboxRelay) retry(ctx context.Context, now time.Time) error {
+	retries, err := r.eventRepo.FetchDueRetries(ctx, now, relayBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, t := range retries {
+		errHandler := r.broker.Deliver(ctx, t.Subscriber, t.Event)
+		if errHandler != nil && !errors.Is(errHandler, domain.ErrNotFound) {
+			err = r.failed(ctx, t, errHandler)
+		} else {
+			err = r.eventRepo.DeleteRetry(ctx, t.Event.Sequence, t.Subscriber)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// failed record a failed attempt of the subscriber at the event, giving the
+// event up for that subscriber after maxRetryAttempts
+func (r *OutboxRelay) failed(ctx context.Context, t domain.EventRetry, errHandler error) error {
+	t.Attempts++
+	logrus.WithFields(logrus.Fields{
+		"event":      t.Event.ID,
+		"subscriber": t.Subscriber,
+		"attempts":   t.Attempts,
+	}).Warn(errHandler)
+	if t.Attempts >= maxRetryAttempts {
+		logrus.WithFields(logrus.Fields{"event": t.Event.ID, "subscriber": t.Subscriber}).Error("event given up")
+		return r.eventRepo.DeleteRetry(ctx, t.Event.Sequence, t.Subscriber)
+	}
+	t.NextAttemptAt = time.Now().Add(retryDelay(t.Attempts))
+	t.LastError = errHandler.Error()
+	if len(t.LastError) > maxErrorLength {
+		t.LastError = t.LastError[:maxErrorLength]
+	}
+	return r.eventRepo.StoreRetry(ctx, t)
+}
+
+// retryDelay is the wait after the given number of failed attempts, doubling
+// each time up to maxRetryDelay
+func retryDelay(attempts int) time.Duration {
+	if attempts > 16 {
+		return maxRetryDelay
+	}
+	if d := baseRetryDelay << uint(attempts-1); d < maxRetryDelay {
+		return d
+	}
+	return maxRetryDelay
+}
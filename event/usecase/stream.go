@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// subscriberBuffer is the number of events a subscriber may lag behind
+// before it is dropped, it resumes from the replay buffer once reconnected
+const subscriberBuffer = 256
+
+type streamSubscriber struct {
+	entityTypes []string
+	events      chan domain.Event
+}
+
+func (s *streamSubscriber) wants(e domain.Event) bool {
+	if len(s.entityTypes) == 0 {
+		return true
+	}
+	for _, t := range s.entityTypes {
+		if t == e.EntityType {
+			return true
+		}
+	}
+	return false
+}
+
+// EventStream keep the latest published events in a bounded replay buffer
+// and hand the new ones to its live subscribers. It only sees the events
+// published in this process
+type EventStream struct {
+	mu sync.Mutex
+	// buffer is a ring of at most size events, the oldest at start
+	buffer      []domain.Event
+	start       int
+	size        int
+	subscribers map[*streamSubscriber]struct{}
+}
+
+// NewEventStream will create an event stream replaying up to size events,
+// its Handle is to be subscribed to the broker
+func NewEventStream(size int) *EventStream {
+	if size < 1 {
+		size = 1
+	}
+	return &EventStream{
+		buffer:      make([]domain.Event, 0, size),
+		size:        size,
+		subscribers: map[*streamSubscriber]struct{}{},
+	}
+}
+
+// last return the latest buffered event, if any
+func (s *EventStream) last() (domain.Event, bool) {
+	if len(s.buffer) == 0 {
+		return domain.Event{}, false
+	}
+	return s.buffer[(s.start+len(s.buffer)-1)%len(s.buffer)], true
+}
+
+// Handle buffer a published event and hand it to the subscribers wanting it,
+// the subscribers too far behind to take it are dropped. An event redelivered
+// or older than the latest buffered one is ignored, so that the sequences
+// stay increasing
+func (s *EventStream) Handle(ctx context.Context, e domain.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if last, ok := s.last(); ok && e.Sequence <= last.Sequence {
+		return nil
+	}
+	if len(s.buffer) < s.size {
+		s.buffer = append(s.buffer, e)
+	} else {
+		s.buffer[s.start] = e
+		s.start = (s.start + 1) % s.size
+	}
+	for sub := range s.subscribers {
+		if !sub.wants(e) {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			delete(s.subscribers, sub)
+			close(sub.events)
+		}
+	}
+	return nil
+}
+
+// Subscribe will follow the events of the entity types, replaying the
+// buffered ones after lastSequence
+func (s *EventStream) Subscribe(ctx context.Context, lastSequence int64, entityTypes []string) (res *domain.EventSubscription, err error) {
+	if err = domain.Authorize(ctx, domain.PermissionEventRead); err != nil {
+		return
+	}
+	for _, t := range entityTypes {
+		if t != domain.EntityProduct && t != domain.EntityAuthor {
+			return nil, domain.ErrBadParamInput
+		}
+	}
+	sub := &streamSubscriber{entityTypes: entityTypes, events: make(chan domain.Event, subscriberBuffer)}
+	res = &domain.EventSubscription{Replay: []domain.Event{}, Events: sub.events}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if lastSequence > 0 {
+		// the sequences may skip the numbers of rolled back events, a gap is
+		// reported as soon as the oldest buffered event is not the next one,
+		// which at worst makes the subscriber reload for nothing. Nothing
+		// buffered, the events since lastSequence cannot be vouched for
+		if len(s.buffer) == 0 || s.buffer[s.start].Sequence > lastSequence+1 {
+			res.Gap = true
+		}
+		for i := 0; i < len(s.buffer); i++ {
+			e := s.buffer[(s.start+i)%len(s.buffer)]
+			if e.Sequence > lastSequence && sub.wants(e) {
+				res.Replay = append(res.Replay, e)
+			}
+		}
+	}
+	s.subscribers[sub] = struct{}{}
+	res.Close = func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subscribers[sub]; ok {
+			delete(s.subscribers, sub)
+			close(sub.events)
+		}
+	}
+	return
+}
@@ -0,0 +1,259 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/negotiate"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// heartbeatInterval is how often a ping frame is sent, so that the
+	// proxies keep the connection open and a vanished client is noticed
+	heartbeatInterval = 30 * time.Second
+	// writeTimeout bound the time a client may take to accept a message,
+	// the clients slower than that are disconnected
+	writeTimeout = 10 * time.Second
+	// maxMessageBytes bound the messages of the clients
+	maxMessageBytes = 16 * 1024
+)
+
+// errOriginNotAllowed is returned by the handshake of a page of an origin not allowed
+var errOriginNotAllowed = errors.New("origin not allowed")
+
+// message is what goes over the websocket, in both directions. The clients
+// send "subscribe" and "unsubscribe" messages with product ids, they receive
+// "subscribed" with the current state of the products, "update" whenever
+// they change and "error" for the messages they got wrong
+type message struct {
+	Type       string                 `json:"type"`
+	ProductIDs []int                  `json:"product_ids,omitempty"`
+	Updates    []domain.ProductUpdate `json:"updates,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// FeedHandler represent the websocket handler of the product updates
+type FeedHandler struct {
+	Feed     domain.ProductFeed
+	CUsecase domain.CurrencyUsecase
+	// AllowedOrigins are the origins of the pages, other than the API's own,
+	// that may connect. "*" allows any
+	AllowedOrigins []string
+}
+
+// NewFeedHandler will initialize the product feed endpoint, the prices are
+// converted with cu into the currency negotiated on connection
+func NewFeedHandler(e *echo.Echo, feed domain.ProductFeed, cu domain.CurrencyUsecase, allowedOrigins []string) {
+	handler := &FeedHandler{
+		Feed:           feed,
+		CUsecase:       cu,
+		AllowedOrigins: allowedOrigins,
+	}
+	e.GET("/product/live", handler.Serve)
+}
+
+// Serve will upgrade the request to a websocket pushing the price or the
+// deletion of the products the client subscribes to, priced in the
+// currency query param or else the one negotiated with the Accept-Currency
+// header. The connection is refused with 503 when the feed or the
+// connections of the client are full, and with 403 from a page of an origin
+// not allowed
+func (h *FeedHandler) Serve(c echo.Context) error {
+	req := c.Request()
+	currency, err := negotiate.Currency(c, h.CUsecase)
+	if err != nil {
+		return failed(c, err)
+	}
+	// an unknown currency is refused before the upgrade
+	if _, err = h.CUsecase.Convert(req.Context(), 0, currency); err != nil {
+		return failed(c, err)
+	}
+	conn, err := h.Feed.Connect(req.Context(), client(c))
+	if err != nil {
+		return failed(c, err)
+	}
+	defer conn.Close()
+	server := websocket.Server{
+		Handshake: func(_ *websocket.Config, r *http.Request) error {
+			return h.checkOrigin(r)
+		},
+		Handler: func(ws *websocket.Conn) {
+			ws.MaxPayloadBytes = maxMessageBytes
+			h.session(ws, conn, currency)
+		},
+	}
+	server.ServeHTTP(c.Response(), req)
+	return nil
+}
+
+// client identify who opens a connection, the principal when authenticated
+// and the address otherwise
+func client(c echo.Context) string {
+	if p, ok := domain.PrincipalFromContext(c.Request().Context()); ok {
+		return "principal:" + p.Subject
+	}
+	return "ip:" + c.RealIP()
+}
+
+// checkOrigin refuse the pages of the origins not allowed, so that a page
+// cannot have its visitors hold connections. The clients other than browsers
+// send no Origin
+func (h *FeedHandler) checkOrigin(r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err == nil && u.Host == r.Host {
+		return nil
+	}
+	for _, allowed := range h.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return nil
+		}
+	}
+	return errOriginNotAllowed
+}
+
+// session serve a websocket until the client leaves or falls behind. The
+// messages of the client are read on their own goroutine, every write happens
+// on this one
+func (h *FeedHandler) session(ws *websocket.Conn, conn domain.ProductFeedConn, currency string) {
+	defer ws.Close()
+	ctx := ws.Request().Context()
+	replies := make(chan message, 8)
+	done := make(chan struct{})
+	quit := make(chan struct{})
+	defer close(quit)
+	go func() {
+		defer close(done)
+		for {
+			var m message
+			if err := websocket.JSON.Receive(ws, &m); err != nil {
+				return
+			}
+			reply := h.handle(ctx, conn, currency, m)
+			select {
+			case replies <- reply:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		var err error
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case m := <-replies:
+			err = send(ws, m)
+		case _, ok := <-conn.Updates():
+			if !ok {
+				return
+			}
+			if updates := conn.Next(); len(updates) > 0 {
+				if err = h.localize(ctx, currency, updates); err != nil {
+					logrus.Error(err)
+					return
+				}
+				err = send(ws, message{Type: "update", Updates: updates})
+			}
+		case <-heartbeat.C:
+			err = ping(ws)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// handle answer a message of the client
+func (h *FeedHandler) handle(ctx context.Context, conn domain.ProductFeedConn, currency string, m message) message {
+	switch m.Type {
+	case "subscribe":
+		updates, err := conn.Subscribe(ctx, m.ProductIDs)
+		if err == nil {
+			err = h.localize(ctx, currency, updates)
+		}
+		if err != nil {
+			logrus.Error(err)
+			return message{Type: "error", Error: err.Error()}
+		}
+		return message{Type: "subscribed", ProductIDs: m.ProductIDs, Updates: updates}
+	case "unsubscribe":
+		conn.Unsubscribe(m.ProductIDs)
+		return message{Type: "unsubscribed", ProductIDs: m.ProductIDs}
+	}
+	return message{Type: "error", Error: domain.ErrBadParamInput.Error()}
+}
+
+// localize convert the price of the updates into the currency of the connection
+func (h *FeedHandler) localize(ctx context.Context, currency string, updates []domain.ProductUpdate) error {
+	for i := range updates {
+		price, err := h.CUsecase.Convert(ctx, updates[i].Price, currency)
+		if err != nil {
+			return err
+		}
+		updates[i].Price = price.Amount
+		updates[i].Currency = price.Currency
+	}
+	return nil
+}
+
+func send(ws *websocket.Conn, m message) error {
+	if err := ws.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	return websocket.JSON.Send(ws, m)
+}
+
+// ping send a ping frame, the browsers answer it on their own
+func ping(ws *websocket.Conn) error {
+	if err := ws.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	w, err := ws.NewFrameWriter(websocket.PingFrame)
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(nil); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// failed write the error response of err, before the upgrade
+func failed(c echo.Context, err error) error {
+	status := getStatusCode(err)
+	return c.JSON(status, response.ResponseFailed{Success: false, Message: err.Error()})
+}
+
+func getStatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	logrus.Error(err)
+	switch {
+	case errors.Is(err, domain.ErrBadParamInput):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrTooManyConnections):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,244 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// ProductFeed hand the updates of the products to the live connections
+// subscribed to them. It only sees the events published in this process
+type ProductFeed struct {
+	productRepo       domain.ProductRepository
+	maxConns          int
+	maxConnsPerClient int
+	maxSubscriptions  int
+	contextTimeout    time.Duration
+
+	mu        sync.Mutex
+	conns     map[*feedConn]struct{}
+	byClient  map[string]int
+	byProduct map[int]map[*feedConn]struct{}
+}
+
+// NewProductFeed will create a feed of at most maxConns connections, up to
+// maxConnsPerClient of the same client, each following up to
+// maxSubscriptions products. Its Handle is to be subscribed to the broker
+func NewProductFeed(p domain.ProductRepository, maxConns int, maxConnsPerClient int, maxSubscriptions int, timeout time.Duration) *ProductFeed {
+	return &ProductFeed{
+		productRepo:       p,
+		maxConns:          maxConns,
+		maxConnsPerClient: maxConnsPerClient,
+		maxSubscriptions:  maxSubscriptions,
+		contextTimeout:    timeout,
+		conns:             map[*feedConn]struct{}{},
+		byClient:          map[string]int{},
+		byProduct:         map[int]map[*feedConn]struct{}{},
+	}
+}
+
+// updateOf read the product update carried by an event, ok is false for the
+// events not about a product
+func updateOf(e domain.Event) (u domain.ProductUpdate, ok bool, err error) {
+	switch e.Type {
+	case domain.EventProductCreated, domain.EventProductUpdated:
+		var p domain.Product
+		if err = json.Unmarshal(e.Payload, &p); err != nil {
+			return
+		}
+		return domain.ProductUpdate{ProductID: p.ID, Price: p.Price, Available: p.Available, UpdatedAt: p.UpdatedAt}, true, nil
+	case domain.EventProductDeleted:
+		id, errID := strconv.Atoi(e.EntityID)
+		if errID != nil {
+			return u, false, errID
+		}
+		return domain.ProductUpdate{ProductID: id, Deleted: true, UpdatedAt: e.OccurredAt}, true, nil
+	}
+	return
+}
+
+// Handle push the product update of a published event to the connections
+// following the product
+func (f *ProductFeed) Handle(ctx context.Context, e domain.Event) error {
+	u, ok, err := updateOf(e)
+	if err != nil || !ok {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for c := range f.byProduct[u.ProductID] {
+		c.push(u)
+	}
+	return nil
+}
+
+// Connect will open a connection to the feed, as long as neither the feed
+// nor the connections of the client are full
+func (f *ProductFeed) Connect(ctx context.Context, client string) (domain.ProductFeedConn, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.conns) >= f.maxConns || f.byClient[client] >= f.maxConnsPerClient {
+		return nil, domain.ErrTooManyConnections
+	}
+	f.byClient[client]++
+	c := &feedConn{
+		feed:     f,
+		client:   client,
+		products: map[int]struct{}{},
+		pending:  map[int]domain.ProductUpdate{},
+		signal:   make(chan struct{}, 1),
+	}
+	f.conns[c] = struct{}{}
+	return c, nil
+}
+
+// feedConn is a connection to the feed. A connection that does not keep up
+// is not queued every update: only the latest of every product waits for it
+type feedConn struct {
+	feed   *ProductFeed
+	client string
+
+	// mu guard the fields below, it is taken after the lock of the feed
+	mu       sync.Mutex
+	products map[int]struct{}
+	pending  map[int]domain.ProductUpdate
+	signal   chan struct{}
+	closed   bool
+}
+
+func (c *feedConn) push(u domain.ProductUpdate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.pending[u.ProductID] = u
+	select {
+	case c.signal <- struct{}{}:
+	default:
+	}
+}
+
+// Subscribe will follow the products, registered before their current state
+// is read so that no change is missed in between
+func (c *feedConn) Subscribe(ctx context.Context, productIDs []int) (res []domain.ProductUpdate, err error) {
+	f := c.feed
+	f.mu.Lock()
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		f.mu.Unlock()
+		return nil, domain.ErrNotFound
+	}
+	count := len(c.products)
+	for _, id := range productIDs {
+		if _, ok := c.products[id]; !ok {
+			count++
+		}
+	}
+	if count > f.maxSubscriptions {
+		c.mu.Unlock()
+		f.mu.Unlock()
+		return nil, domain.ErrBadParamInput
+	}
+	for _, id := range productIDs {
+		c.products[id] = struct{}{}
+		if f.byProduct[id] == nil {
+			f.byProduct[id] = map[*feedConn]struct{}{}
+		}
+		f.byProduct[id][c] = struct{}{}
+	}
+	c.mu.Unlock()
+	f.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, f.contextTimeout)
+	defer cancel()
+	res = make([]domain.ProductUpdate, 0, len(productIDs))
+	for _, id := range productIDs {
+		p, errProduct := f.productRepo.GetByID(ctx, id)
+		switch {
+		case errors.Is(errProduct, domain.ErrNotFound):
+			res = append(res, domain.ProductUpdate{ProductID: id, Deleted: true})
+		case errProduct != nil:
+			return nil, errProduct
+		default:
+			res = append(res, domain.ProductUpdate{ProductID: id, Price: p.Price, Available: p.Available, UpdatedAt: p.UpdatedAt})
+		}
+	}
+
+	// the updates pushed meanwhile are stale unless newer than the state just read
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, u := range res {
+		if pending, ok := c.pending[u.ProductID]; ok && !pending.UpdatedAt.After(u.UpdatedAt) {
+			delete(c.pending, u.ProductID)
+		}
+	}
+	return
+}
+
+func (c *feedConn) Unsubscribe(productIDs []int) {
+	f := c.feed
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range productIDs {
+		delete(c.products, id)
+		delete(c.pending, id)
+		f.forget(id, c)
+	}
+}
+
+// forget unregister the connection from the product, the lock of the feed is held
+func (f *ProductFeed) forget(id int, c *feedConn) {
+	delete(f.byProduct[id], c)
+	if len(f.byProduct[id]) == 0 {
+		delete(f.byProduct, id)
+	}
+}
+
+func (c *feedConn) Updates() <-chan struct{} {
+	return c.signal
+}
+
+// Next take the waiting updates, by product id
+func (c *feedConn) Next() []domain.ProductUpdate {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := make([]domain.ProductUpdate, 0, len(c.pending))
+	for _, u := range c.pending {
+		res = append(res, u)
+	}
+	c.pending = map[int]domain.ProductUpdate{}
+	sort.Slice(res, func(i, j int) bool { return res[i].ProductID < res[j].ProductID })
+	return res
+}
+
+// Close leave the feed, it may be called more than once
+func (c *feedConn) Close() {
+	f := c.feed
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	for id := range c.products {
+		f.forget(id, c)
+	}
+	delete(f.conns, c)
+	f.byClient[c.client]--
+	if f.byClient[c.client] <= 0 {
+		delete(f.byClient, c.client)
+	}
+	close(c.signal)
+}
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/graphql/schema"
+	"github.com/wdwiramadhan/bookhub-api/helper/negotiate"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+)
+
+// GraphQLHandler represent the httphandler for the GraphQL endpoint
+type GraphQLHandler struct {
+	Schema     *schema.Schema
+	CUsecase   domain.CurrencyUsecase
+	Playground bool
+}
+
+// NewGraphQLHandler will initialize the /graphql endpoint, a GET without a
+// query serves the GraphiQL playground when playground is set. The prices are
+// in the currency query param or else the one negotiated with the
+// Accept-Currency header
+func NewGraphQLHandler(e *echo.Echo, s *schema.Schema, cu domain.CurrencyUsecase, playground bool, optionalAuth echo.MiddlewareFunc) {
+	handler := &GraphQLHandler{
+		Schema:     s,
+		CUsecase:   cu,
+		Playground: playground,
+	}
+	e.POST("/graphql", handler.Post, optionalAuth)
+	e.GET("/graphql", handler.Get, optionalAuth)
+}
+
+// Post will run the GraphQL request of the JSON body. Every well-formed
+// request is answered with 200, its errors are listed in the result
+func (h *GraphQLHandler) Post(c echo.Context) error {
+	var req schema.Request
+	if err := c.Bind(&req); err != nil {
+		return failed(c, domain.ErrBadParamInput)
+	}
+	return h.execute(c, req)
+}
+
+// Get will run the GraphQL request of the query, operationName and variables
+// query params
+func (h *GraphQLHandler) Get(c echo.Context) error {
+	req := schema.Request{
+		Query:         c.QueryParam("query"),
+		OperationName: c.QueryParam("operationName"),
+	}
+	if req.Query == "" && h.Playground {
+		return c.HTML(http.StatusOK, playgroundPage)
+	}
+	if v := c.QueryParam("variables"); v != "" {
+		if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+			return failed(c, domain.ErrBadParamInput)
+		}
+	}
+	return h.execute(c, req)
+}
+
+func (h *GraphQLHandler) execute(c echo.Context, req schema.Request) error {
+	if req.Query == "" {
+		return failed(c, domain.ErrBadParamInput)
+	}
+	ctx := c.Request().Context()
+	currency, err := negotiate.Currency(c, h.CUsecase)
+	if err != nil {
+		return failed(c, err)
+	}
+	// an unknown currency is refused before the query runs
+	if _, err = h.CUsecase.Convert(ctx, 0, currency); err != nil {
+		return failed(c, err)
+	}
+	req.Currency = currency
+	result := h.Schema.Execute(ctx, req)
+	return c.JSON(http.StatusOK, result)
+}
+
+func failed(c echo.Context, err error) error {
+	return c.JSON(getStatusCode(err), response.ResponseFailed{Success: false, Message: err.Error()})
+}
+
+func getStatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	logrus.Error(err)
+	switch {
+	case errors.Is(err, domain.ErrBadParamInput):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// playgroundPage is GraphiQL, loaded from a CDN and pointed at this endpoint
+const playgroundPage = `<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>Bookhub GraphiQL</title>
+	<link rel="stylesheet" href="https://unpkg.com/graphiql@1.4.7/graphiql.min.css">
+	<style>html, body, #graphiql { height: 100%; margin: 0; }</style>
+</head>
+<body>
+	<div id="graphiql">Loading...</div>
+	<script src="https://unpkg.com/react@17.0.2/umd/react.production.min.js" crossorigin></script>
+	<script src="https://unpkg.com/react-dom@17.0.2/umd/react-dom.production.min.js" crossorigin></script>
+	<script src="https://unpkg.com/graphiql@1.4.7/graphiql.min.js" crossorigin></script>
+	<script>
+		var fetcher = GraphiQL.createFetcher({ url: window.location.pathname });
+		ReactDOM.render(React.createElement(GraphiQL, { fetcher: fetcher }), document.getElementById('graphiql'));
+	</script>
+</body>
+</html>
+`
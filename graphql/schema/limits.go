@@ -0,0 +1,218 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const (
+	// defaultFirst is the number of items a list field resolves when its
+	// first argument is not given
+	defaultFirst = 20
+	// maxFirst is the highest first argument of a list field
+	maxFirst = 100
+)
+
+// errIntrospectionDisabled is returned for the queries of __schema or __type
+// when introspection is off
+var errIntrospectionDisabled = errors.New("introspection is disabled")
+
+// Limits bound the queries, 0 means unbounded
+type Limits struct {
+	// MaxDepth is the deepest field nesting, the top-level fields are at depth 1
+	MaxDepth int
+	// MaxComplexity is the highest estimated number of resolved fields, the
+	// fields selected on the items of a list counting once per item the list
+	// may hold
+	MaxComplexity int
+	// Introspection allow the __schema and __type fields
+	Introspection bool
+}
+
+// firstArg is the argument bounding the items of the catalog lists
+var firstArg = &graphql.ArgumentConfig{
+	Type:         graphql.Int,
+	DefaultValue: defaultFirst,
+	Description:  "The number of items, at most " + strconv.Itoa(maxFirst),
+}
+
+// first read the first argument of a list field, bounded by maxFirst
+func first(p graphql.ResolveParams) int {
+	n, _ := p.Args["first"].(int)
+	if n < 0 {
+		return 0
+	}
+	if n > maxFirst {
+		return maxFirst
+	}
+	return n
+}
+
+// checkLimits reject the operations of the document nesting too deep or
+// resolving too many fields, the document is valid
+func (s *Schema) checkLimits(doc *ast.Document, variables map[string]interface{}) error {
+	w := &limitWalker{
+		schema:        &s.schema,
+		fragments:     map[string]*ast.FragmentDefinition{},
+		variables:     variables,
+		introspection: s.limits.Introspection,
+		// the introspection lists hold at most about as many items as the schema has types
+		introspectionListSize: len(s.schema.TypeMap()),
+	}
+	for _, def := range doc.Definitions {
+		if f, ok := def.(*ast.FragmentDefinition); ok {
+			w.fragments[f.Name.Value] = f
+		}
+	}
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		depth, complexity := w.walk(s.schema.QueryType(), op.SelectionSet, 0)
+		if w.err != nil {
+			return w.err
+		}
+		if s.limits.MaxDepth > 0 && depth > s.limits.MaxDepth {
+			return fmt.Errorf("query depth %d exceeds the limit of %d", depth, s.limits.MaxDepth)
+		}
+		if s.limits.MaxComplexity > 0 && complexity > s.limits.MaxComplexity {
+			return fmt.Errorf("query complexity %d exceeds the limit of %d", complexity, s.limits.MaxComplexity)
+		}
+	}
+	return nil
+}
+
+type limitWalker struct {
+	schema                *graphql.Schema
+	fragments             map[string]*ast.FragmentDefinition
+	variables             map[string]interface{}
+	introspection         bool
+	introspectionListSize int
+	err                   error
+}
+
+// walk return the depth and the complexity of the selections on parent, at depth
+func (w *limitWalker) walk(parent graphql.Type, set *ast.SelectionSet, depth int) (maxDepth int, complexity int) {
+	maxDepth = depth
+	if set == nil {
+		return
+	}
+	for _, sel := range set.Selections {
+		var d, c int
+		switch sel := sel.(type) {
+		case *ast.Field:
+			d, c = w.field(parent, sel, depth)
+		case *ast.InlineFragment:
+			on := parent
+			if sel.TypeCondition != nil {
+				on = w.schema.Type(sel.TypeCondition.Name.Value)
+			}
+			d, c = w.walk(on, sel.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			if f, ok := w.fragments[sel.Name.Value]; ok {
+				d, c = w.walk(w.schema.Type(f.TypeCondition.Name.Value), f.SelectionSet, depth)
+			}
+		}
+		if d > maxDepth {
+			maxDepth = d
+		}
+		complexity += c
+	}
+	return
+}
+
+func (w *limitWalker) field(parent graphql.Type, f *ast.Field, depth int) (maxDepth int, complexity int) {
+	def := w.definition(parent, f.Name.Value)
+	if def == nil {
+		return depth + 1, 1
+	}
+	t, lists := unwrap(def.Type)
+	multiplier := 1
+	if lists > 0 {
+		multiplier = w.listSize(def, f)
+		for i := 1; i < lists; i++ {
+			multiplier *= w.introspectionListSize
+		}
+	}
+	maxDepth, complexity = w.walk(t, f.SelectionSet, depth+1)
+	return maxDepth, 1 + complexity*multiplier
+}
+
+// definition return the definition of the field of parent, the introspection
+// fields included
+func (w *limitWalker) definition(parent graphql.Type, name string) *graphql.FieldDefinition {
+	switch name {
+	case "__typename":
+		return graphql.TypeNameMetaFieldDef
+	case "__schema", "__type":
+		if !w.introspection {
+			w.err = errIntrospectionDisabled
+		}
+		if name == "__schema" {
+			return graphql.SchemaMetaFieldDef
+		}
+		return graphql.TypeMetaFieldDef
+	}
+	obj, ok := parent.(*graphql.Object)
+	if !ok {
+		return nil
+	}
+	return obj.Fields()[name]
+}
+
+// listSize return the number of items the list field may hold: its first
+// argument for the catalog lists, the size of the schema for the introspection ones
+func (w *limitWalker) listSize(def *graphql.FieldDefinition, f *ast.Field) int {
+	hasFirst := false
+	for _, arg := range def.Args {
+		hasFirst = hasFirst || arg.Name() == "first"
+	}
+	if !hasFirst {
+		return w.introspectionListSize
+	}
+	n := defaultFirst
+	for _, arg := range f.Arguments {
+		if arg.Name.Value != "first" {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			n, _ = strconv.Atoi(v.Value)
+		case *ast.Variable:
+			switch value := w.variables[v.Name.Value].(type) {
+			case float64:
+				n = int(value)
+			case int:
+				n = value
+			}
+		}
+	}
+	if n < 0 {
+		return 0
+	}
+	if n > maxFirst {
+		return maxFirst
+	}
+	return n
+}
+
+// unwrap return the named type of t, along with the number of lists wrapping it
+func unwrap(t graphql.Type) (graphql.Type, int) {
+	lists := 0
+	for {
+		switch wrapped := t.(type) {
+		case *graphql.NonNull:
+			t = wrapped.OfType
+		case *graphql.List:
+			t = wrapped.OfType
+			lists++
+		default:
+			return t, lists
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// batchFunc load the values of the keys at once, the keys without a value are left out
+type batchFunc func(ctx context.Context, keys []int) (map[int]interface{}, error)
+
+type loaded struct {
+	value interface{}
+	err   error
+}
+
+// loader collect the keys asked by the resolvers of a level of the query and
+// load them in a single batch once the first of them is needed. The executor
+// resolves the thunks breadth first, so a list of n products costs one batch
+// instead of n queries. A loader lives for a single request and caches what
+// it loaded
+type loader struct {
+	batch batchFunc
+
+	mu      sync.Mutex
+	pending map[int]struct{}
+	results map[int]loaded
+}
+
+func newLoader(batch batchFunc) *loader {
+	return &loader{
+		batch:   batch,
+		pending: map[int]struct{}{},
+		results: map[int]loaded{},
+	}
+}
+
+// load queue the key and return the thunk resolving it, the value is nil when
+// the key has none
+func (l *loader) load(ctx context.Context, key int) func() (interface{}, error) {
+	l.mu.Lock()
+	if _, ok := l.results[key]; !ok {
+		l.pending[key] = struct{}{}
+	}
+	l.mu.Unlock()
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if _, ok := l.results[key]; !ok {
+			l.dispatch(ctx)
+		}
+		r := l.results[key]
+		return r.value, r.err
+	}
+}
+
+// prime cache a value loaded by other means, so that it is not loaded again
+func (l *loader) prime(key int, value interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.results[key]; !ok {
+		l.results[key] = loaded{value: value}
+		delete(l.pending, key)
+	}
+}
+
+// dispatch load the pending keys, the lock is held
+func (l *loader) dispatch(ctx context.Context) {
+	keys := make([]int, 0, len(l.pending))
+	for key := range l.pending {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	l.pending = map[int]struct{}{}
+	values, err := l.batch(ctx, keys)
+	for _, key := range keys {
+		l.results[key] = loaded{value: values[key], err: err}
+	}
+}
+
+// loaders are the loaders of a request
+type loaders struct {
+	authors          *loader
+	productsByAuthor *loader
+}
+
+type loadersKey struct{}
+
+// withLoaders return a copy of ctx carrying new loaders
+func (s *Schema) withLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		authors:          newLoader(s.loadAuthors),
+		productsByAuthor: newLoader(s.loadProductsByAuthor),
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// loadAuthors look a single author up by id, more of them are fetched by
+// their ids in one query
+func (s *Schema) loadAuthors(ctx context.Context, keys []int) (map[int]interface{}, error) {
+	res := make(map[int]interface{}, len(keys))
+	if len(keys) == 1 {
+		a, err := s.authorUsecase.GetAuthorById(ctx, keys[0])
+		if errors.Is(err, domain.ErrNotFound) {
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		res[keys[0]] = a
+		return res, nil
+	}
+	authors, err := s.authorUsecase.FetchByIDs(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range authors {
+		if id, errID := strconv.Atoi(a.ID); errID == nil {
+			res[id] = a
+		}
+	}
+	return res, nil
+}
+
+// loadProductsByAuthor list the products of the authors in one filtered fetch
+func (s *Schema) loadProductsByAuthor(ctx context.Context, keys []int) (map[int]interface{}, error) {
+	products, err := s.productUsecase.FetchFiltered(ctx, domain.ProductFilter{AuthorIDs: keys})
+	if err != nil {
+		return nil, err
+	}
+	byAuthor := make(map[int][]domain.Product, len(keys))
+	for _, p := range products {
+		byAuthor[p.AuthorID] = append(byAuthor[p.AuthorID], p)
+	}
+	res := make(map[int]interface{}, len(keys))
+	for _, key := range keys {
+		list := byAuthor[key]
+		if list == nil {
+			list = []domain.Product{}
+		}
+		res[key] = list
+	}
+	return res, nil
+}
@@ -0,0 +1,223 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// Request is a GraphQL request, as posted by the clients
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	// Currency is the one the prices are converted into, the base currency when empty
+	Currency string `json:"-"`
+}
+
+type currencyKey struct{}
+
+// Schema is the read-only GraphQL schema of the catalog, its products and
+// authors are resolved through their usecases
+type Schema struct {
+	productUsecase  domain.ProductUseCase
+	authorUsecase   domain.AuthorUsecase
+	currencyUsecase domain.CurrencyUsecase
+	limits          Limits
+	schema          graphql.Schema
+}
+
+// NewSchema will build the catalog schema, the queries beyond the limits are rejected before they run
+func NewSchema(pu domain.ProductUseCase, au domain.AuthorUsecase, cu domain.CurrencyUsecase, limits Limits) (*Schema, error) {
+	s := &Schema{
+		productUsecase:  pu,
+		authorUsecase:   au,
+		currencyUsecase: cu,
+		limits:          limits,
+	}
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: s.queryType()})
+	if err != nil {
+		return nil, err
+	}
+	s.schema = schema
+	return s, nil
+}
+
+// Execute will run the request, with loaders of its own
+func (s *Schema) Execute(ctx context.Context, req Request) *graphql.Result {
+	if req.Currency == "" {
+		req.Currency = s.currencyUsecase.Base()
+	}
+	ctx = context.WithValue(ctx, currencyKey{}, req.Currency)
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	validation := graphql.ValidateDocument(&s.schema, doc, nil)
+	if !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}
+	}
+	if err = s.checkLimits(doc, req.Variables); err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       s.withLoaders(ctx),
+	})
+}
+
+// argID read an ID argument, the ids of the catalog are numbers
+func argID(p graphql.ResolveParams, name string) (int, error) {
+	var raw string
+	switch v := p.Args[name].(type) {
+	case string:
+		raw = v
+	case int:
+		return v, nil
+	}
+	id, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, domain.ErrBadParamInput
+	}
+	return id, nil
+}
+
+// argIDs read a list of ID arguments
+func argIDs(p graphql.ResolveParams, name string) ([]int, error) {
+	list, _ := p.Args[name].([]interface{})
+	res := make([]int, 0, len(list))
+	for _, v := range list {
+		id, err := strconv.Atoi(v.(string))
+		if err != nil {
+			return nil, domain.ErrBadParamInput
+		}
+		res = append(res, id)
+	}
+	return res, nil
+}
+
+func argStrings(p graphql.ResolveParams, name string) []string {
+	list, _ := p.Args[name].([]interface{})
+	res := make([]string, 0, len(list))
+	for _, v := range list {
+		res = append(res, v.(string))
+	}
+	return res
+}
+
+func argInts(p graphql.ResolveParams, name string) []int {
+	list, _ := p.Args[name].([]interface{})
+	res := make([]int, 0, len(list))
+	for _, v := range list {
+		res = append(res, v.(int))
+	}
+	return res
+}
+
+func (s *Schema) queryType() *graphql.Object {
+	product, author := s.catalogTypes()
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"product": &graphql.Field{
+				Type:        product,
+				Description: "The product of the id, null when there is none",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: s.resolveProduct,
+			},
+			"products": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(product))),
+				Description: "The products, of any of the authors, categories and published years when given",
+				Args: graphql.FieldConfigArgument{
+					"authorIds":  &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.ID))},
+					"categories": &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+					"years":      &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.Int))},
+					"first":      firstArg,
+				},
+				Resolve: s.resolveProducts,
+			},
+			"author": &graphql.Field{
+				Type:        author,
+				Description: "The author of the id, null when there is none",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: s.resolveAuthor,
+			},
+			"authors": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(author))),
+				Args: graphql.FieldConfigArgument{
+					"first": firstArg,
+				},
+				Resolve: s.resolveAuthors,
+			},
+		},
+	})
+}
+
+func (s *Schema) resolveProduct(p graphql.ResolveParams) (interface{}, error) {
+	id, err := argID(p, "id")
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.productUsecase.GetByID(p.Context, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *Schema) resolveProducts(p graphql.ResolveParams) (interface{}, error) {
+	authorIDs, err := argIDs(p, "authorIds")
+	if err != nil {
+		return nil, err
+	}
+	return s.productUsecase.FetchFiltered(p.Context, domain.ProductFilter{
+		AuthorIDs:  authorIDs,
+		Categories: argStrings(p, "categories"),
+		Years:      argInts(p, "years"),
+		Limit:      first(p),
+	})
+}
+
+func (s *Schema) resolveAuthor(p graphql.ResolveParams) (interface{}, error) {
+	id, err := argID(p, "id")
+	if err != nil {
+		return nil, err
+	}
+	return loadersFrom(p.Context).authors.load(p.Context, id), nil
+}
+
+func (s *Schema) resolveAuthors(p graphql.ResolveParams) (interface{}, error) {
+	res, err := s.authorUsecase.Fetch(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	if n := first(p); len(res) > n {
+		res = res[:n]
+	}
+	l := loadersFrom(p.Context)
+	for _, a := range res {
+		if id, errID := strconv.Atoi(a.ID); errID == nil {
+			l.authors.prime(id, a)
+		}
+	}
+	return res, nil
+}
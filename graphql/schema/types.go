@@ -0,0 +1,131 @@
+package schema
+
+import (
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// productField resolve a field of the product being resolved
+func productField(t graphql.Output, get func(domain.Product) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: t,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(domain.Product)), nil
+		},
+	}
+}
+
+// authorField resolve a field of the author being resolved
+func authorField(t graphql.Output, get func(domain.Author) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: t,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(domain.Author)), nil
+		},
+	}
+}
+
+// catalogTypes build the Product and Author types, which refer to each other
+func (s *Schema) catalogTypes() (product *graphql.Object, author *graphql.Object) {
+	product = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Product",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":   productField(graphql.NewNonNull(graphql.ID), func(p domain.Product) interface{} { return p.ID }),
+				"name": productField(graphql.NewNonNull(graphql.String), func(p domain.Product) interface{} { return p.Name }),
+				"price": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.Int),
+					Description: "The price in the minor unit of the currency",
+					Resolve:     s.resolvePrice,
+				},
+				"currency": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.String),
+					Description: "The ISO 4217 code of the currency of the price",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Context.Value(currencyKey{}).(string), nil
+					},
+				},
+				"description": productField(graphql.NewNonNull(graphql.String), func(p domain.Product) interface{} { return p.Description }),
+				"image":       productField(graphql.NewNonNull(graphql.String), func(p domain.Product) interface{} { return p.Image }),
+				"category":    productField(graphql.NewNonNull(graphql.String), func(p domain.Product) interface{} { return p.Category }),
+				"taxClass":    productField(graphql.NewNonNull(graphql.String), func(p domain.Product) interface{} { return p.TaxClass }),
+				"publishedYear": productField(graphql.NewNonNull(graphql.Int), func(p domain.Product) interface{} {
+					return p.PublishedYear
+				}),
+				"ratingAverage": productField(graphql.NewNonNull(graphql.Float), func(p domain.Product) interface{} {
+					return p.RatingAverage
+				}),
+				"available":   productField(graphql.NewNonNull(graphql.Boolean), func(p domain.Product) interface{} { return p.Available }),
+				"ratingCount": productField(graphql.NewNonNull(graphql.Int), func(p domain.Product) interface{} { return p.RatingCount }),
+				"updatedAt":   productField(graphql.NewNonNull(graphql.DateTime), func(p domain.Product) interface{} { return p.UpdatedAt }),
+				"createdAt":   productField(graphql.NewNonNull(graphql.DateTime), func(p domain.Product) interface{} { return p.CreatedAt }),
+				"author": &graphql.Field{
+					Type:    graphql.NewNonNull(author),
+					Resolve: s.resolveProductAuthor,
+				},
+			}
+		}),
+	})
+	author = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Author",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":          authorField(graphql.NewNonNull(graphql.ID), func(a domain.Author) interface{} { return a.ID }),
+				"name":        authorField(graphql.NewNonNull(graphql.String), func(a domain.Author) interface{} { return a.Name }),
+				"dateOfBirth": authorField(graphql.NewNonNull(graphql.String), func(a domain.Author) interface{} { return a.DateOfBirth }),
+				"updatedAt":   authorField(graphql.NewNonNull(graphql.DateTime), func(a domain.Author) interface{} { return a.UpdatedAt }),
+				"createdAt":   authorField(graphql.NewNonNull(graphql.DateTime), func(a domain.Author) interface{} { return a.CreatedAt }),
+				"products": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(product))),
+					Args: graphql.FieldConfigArgument{
+						"first": firstArg,
+					},
+					Resolve: s.resolveAuthorProducts,
+				},
+			}
+		}),
+	})
+	return
+}
+
+// resolvePrice convert the price into the currency of the request
+func (s *Schema) resolvePrice(p graphql.ResolveParams) (interface{}, error) {
+	price, err := s.currencyUsecase.Convert(p.Context, p.Source.(domain.Product).Price, p.Context.Value(currencyKey{}).(string))
+	if err != nil {
+		return nil, err
+	}
+	return price.Amount, nil
+}
+
+// resolveProductAuthor use the author joined to the product when there is
+// one, the others are batched
+func (s *Schema) resolveProductAuthor(p graphql.ResolveParams) (interface{}, error) {
+	product := p.Source.(domain.Product)
+	l := loadersFrom(p.Context)
+	if product.Author.ID != "" {
+		l.authors.prime(product.AuthorID, product.Author)
+	}
+	return l.authors.load(p.Context, product.AuthorID), nil
+}
+
+func (s *Schema) resolveAuthorProducts(p graphql.ResolveParams) (interface{}, error) {
+	id, err := strconv.Atoi(p.Source.(domain.Author).ID)
+	if err != nil {
+		return nil, err
+	}
+	thunk := loadersFrom(p.Context).productsByAuthor.load(p.Context, id)
+	n := first(p)
+	return func() (interface{}, error) {
+		v, err := thunk()
+		if err != nil {
+			return nil, err
+		}
+		if products, ok := v.([]domain.Product); ok && len(products) > n {
+			v = products[:n]
+		}
+		return v, nil
+	}, nil
+}
@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// item is a cached value along with its expiry
+type item struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+// call is a load in flight, the concurrent lookups of the same key wait for it
+type call struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// Cache is a bounded in-process cache evicting the least recently used
+// entries, every entry expires after the TTL. Concurrent misses on the same
+// key share a single load
+type Cache struct {
+	capacity    int
+	ttl         time.Duration
+	loadTimeout time.Duration
+
+	mu       sync.Mutex
+	order    *list.List
+	items    map[string]*list.Element
+	inflight map[string]*call
+	// version is bumped on every invalidation, a load started before an
+	// invalidation may have read stale data and is not cached
+	version uint64
+}
+
+// New will create a cache holding at most capacity entries for at most ttl,
+// a load is given up to loadTimeout
+func New(capacity int, ttl time.Duration, loadTimeout time.Duration) *Cache {
+	return &Cache{
+		capacity:    capacity,
+		ttl:         ttl,
+		loadTimeout: loadTimeout,
+		order:       list.New(),
+		items:       map[string]*list.Element{},
+		inflight:    map[string]*call{},
+	}
+}
+
+// Get return the cached value of key, if any and not expired
+func (c *Cache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(key)
+}
+
+func (c *Cache) get(key string) (interface{}, bool) {
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	it := el.Value.(*item)
+	if time.Now().After(it.expiresAt) {
+		c.remove(el)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return it.value, true
+}
+
+func (c *Cache) set(key string, value interface{}) {
+	if el, ok := c.items[key]; ok {
+		it := el.Value.(*item)
+		it.value = value
+		it.expiresAt = time.Now().Add(c.ttl)
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&item{key: key, value: value, expiresAt: time.Now().Add(c.ttl)})
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *Cache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*item).key)
+}
+
+// GetOrLoad return the cached value of key, or load it once however many
+// lookups of the key are waiting. The load is shared, it runs on a context of
+// its own rather than the one of the lookup starting it, so that a lookup
+// giving up does not fail the others. Errors are not cached
+func (c *Cache) GetOrLoad(ctx context.Context, key string, load func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	if v, ok := c.get(key); ok {
+		c.mu.Unlock()
+		return v, nil
+	}
+	cl, ok := c.inflight[key]
+	if !ok {
+		cl = &call{done: make(chan struct{})}
+		c.inflight[key] = cl
+		go c.load(key, cl, c.version, load)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-cl.done:
+		return cl.value, cl.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// load run the shared load of key started at version
+func (c *Cache) load(key string, cl *call, version uint64, load func(ctx context.Context) (interface{}, error)) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.loadTimeout)
+	defer cancel()
+	cl.value, cl.err = load(ctx)
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	if cl.err == nil && version == c.version {
+		c.set(key, cl.value)
+	}
+	c.mu.Unlock()
+	close(cl.done)
+}
+
+// Delete invalidate key
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version++
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// DeleteFunc invalidate every entry for which match return true
+func (c *Cache) DeleteFunc(match func(key string, value interface{}) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version++
+	for key, el := range c.items {
+		if match(key, el.Value.(*item).value) {
+			c.remove(el)
+		}
+	}
+}
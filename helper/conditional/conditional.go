@@ -0,0 +1,64 @@
+package conditional
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// NotModified set the Last-Modified and ETag validators of a representation
+// made of count entities last modified at modified, and report whether the
+// client copy is still fresh. The count is part of the ETag so that deleting
+// an entity of a collection, which leaves the latest modification unchanged,
+// still changes the validators
+func NotModified(c echo.Context, modified time.Time, count int) bool {
+	return NotModifiedVariant(c, modified, count, "")
+}
+
+// NotModifiedVariant is NotModified for a representation of which the
+// resource has several, like one per currency. The variant is part of the ETag
+func NotModifiedVariant(c echo.Context, modified time.Time, count int, variant string) bool {
+	if modified.IsZero() {
+		return false
+	}
+	// HTTP dates have a one second resolution
+	modified = modified.UTC().Truncate(time.Second)
+	etag := fmt.Sprintf(`W/"%x-%x"`, modified.Unix(), count)
+	if variant != "" {
+		etag = fmt.Sprintf(`W/"%x-%x-%s"`, modified.Unix(), count, variant)
+	}
+	header := c.Response().Header()
+	header.Set(echo.HeaderLastModified, modified.Format(http.TimeFormat))
+	header.Set("ETag", etag)
+
+	req := c.Request()
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	// If-None-Match takes precedence over If-Modified-Since
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(req.Header.Get(echo.HeaderIfModifiedSince))
+	return err == nil && !modified.After(since)
+}
+
+// Latest return the most recent of the times
+func Latest(times ...time.Time) time.Time {
+	var latest time.Time
+	for _, t := range times {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
@@ -0,0 +1,43 @@
+package debounce
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Debouncer run a function once a burst of triggers is over, the triggers of
+// a burst share one run
+type Debouncer struct {
+	delay   time.Duration
+	timeout time.Duration
+	fn      func(ctx context.Context) error
+
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+// New will create a Debouncer running fn delay after the last trigger, on a
+// context bounded by timeout
+func New(delay time.Duration, timeout time.Duration, fn func(ctx context.Context) error) *Debouncer {
+	return &Debouncer{delay: delay, timeout: timeout, fn: fn}
+}
+
+// Trigger will schedule a run, pushing back the one already scheduled
+func (d *Debouncer) Trigger() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.timer != nil {
+		d.timer.Reset(d.delay)
+		return
+	}
+	d.timer = time.AfterFunc(d.delay, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+		defer cancel()
+		if err := d.fn(ctx); err != nil {
+			logrus.Error(err)
+		}
+	})
+}
@@ -0,0 +1,16 @@
+package mysqlerr
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ErrDuplicateEntry is the mysql error number of a unique key violation
+const ErrDuplicateEntry = 1062
+
+// IsDuplicate report whether err is a unique key violation
+func IsDuplicate(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == ErrDuplicateEntry
+}
@@ -0,0 +1,65 @@
+package negotiate
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// HeaderAcceptCurrency list the currencies a client would like the prices in
+const HeaderAcceptCurrency = "Accept-Currency"
+
+// Currency return the currency of the currency query param or else the one
+// negotiated with the Accept-Currency header
+func Currency(c echo.Context, cu domain.CurrencyUsecase) (string, error) {
+	if currency := c.QueryParam("currency"); currency != "" {
+		return strings.ToUpper(currency), nil
+	}
+	return cu.Negotiate(c.Request().Context(), AcceptCurrencies(c.Request().Header.Get(HeaderAcceptCurrency)))
+}
+
+// Localize convert the price of the products into the requested currency and
+// return the rate used
+func Localize(c echo.Context, cu domain.CurrencyUsecase, products []domain.Product) (domain.ExchangeRate, error) {
+	currency, err := Currency(c, cu)
+	if err != nil {
+		return domain.ExchangeRate{}, err
+	}
+	return cu.Localize(c.Request().Context(), products, currency)
+}
+
+// AcceptCurrencies list the currencies of an Accept-Currency header by decreasing quality
+func AcceptCurrencies(header string) []string {
+	type preference struct {
+		currency string
+		q        float64
+	}
+	preferences := []preference{}
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		currency := strings.TrimSpace(params[0])
+		if currency == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			if param = strings.TrimSpace(param); strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			preferences = append(preferences, preference{currency: currency, q: q})
+		}
+	}
+	sort.SliceStable(preferences, func(i, j int) bool { return preferences[i].q > preferences[j].q })
+	currencies := make([]string, len(preferences))
+	for i, pref := range preferences {
+		currencies[i] = pref.currency
+	}
+	return currencies
+}
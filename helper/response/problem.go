@@ -0,0 +1,24 @@
+package response
+
+import "net/http"
+
+// ProblemContentType is the media type of a problem detail response
+const ProblemContentType = "application/problem+json"
+
+// Problem represent a RFC 7807 problem detail
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// NewProblem create a problem detail of the given status code
+func NewProblem(status int, detail string) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
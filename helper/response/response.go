@@ -11,3 +11,17 @@ type ResponseSuccess struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data"`
 }
+
+// ResponsePaginated represent the reseponse of a page of a listing, Next is the cursor of the following page
+type ResponsePaginated struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data"`
+	Next    string      `json:"next,omitempty"`
+}
+
+// ResponseFaceted represent the reseponse of a listing along with its facet counts
+type ResponseFaceted struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data"`
+	Facets  interface{} `json:"facets"`
+}
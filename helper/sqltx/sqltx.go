@@ -0,0 +1,94 @@
+package sqltx
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// Conn is what a *sql.DB and a *sql.Tx have in common
+type Conn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txContextKey struct{}
+
+// state is the transaction carried by a context, along with what runs once it commits
+type state struct {
+	tx          *sql.Tx
+	afterCommit []func()
+}
+
+// Transactor run functions in a transaction of its database
+type Transactor struct {
+	db *sql.DB
+}
+
+// NewTransactor will create a domain.Transactor over db
+func NewTransactor(db *sql.DB) domain.Transactor {
+	return &Transactor{db: db}
+}
+
+// WithinTransaction run fn with a context carrying a transaction, committed
+// when fn succeeds and rolled back otherwise. Called with a context already
+// in a transaction, fn simply takes part in it
+func (t *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txContextKey{}).(*state); ok {
+		return fn(ctx)
+	}
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	s := &state{tx: tx}
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(); errRollback != nil {
+				logrus.Error(errRollback)
+			}
+		}
+	}()
+	if err = fn(context.WithValue(ctx, txContextKey{}, s)); err != nil {
+		return
+	}
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	for _, f := range s.afterCommit {
+		f()
+	}
+	return
+}
+
+// Tx return the transaction carried by ctx, if any
+func Tx(ctx context.Context) (*sql.Tx, bool) {
+	s, ok := ctx.Value(txContextKey{}).(*state)
+	if !ok {
+		return nil, false
+	}
+	return s.tx, true
+}
+
+// From return the transaction carried by ctx, or db outside of a transaction
+func From(ctx context.Context, db *sql.DB) Conn {
+	if tx, ok := Tx(ctx); ok {
+		return tx
+	}
+	return db
+}
+
+// AfterCommit run fn once the transaction carried by ctx commits, it is
+// dropped on rollback. Outside of a transaction fn runs right away
+func AfterCommit(ctx context.Context, fn func()) {
+	s, ok := ctx.Value(txContextKey{}).(*state)
+	if !ok {
+		fn()
+		return
+	}
+	s.afterCommit = append(s.afterCommit, fn)
+}
@@ -0,0 +1,118 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token is a word of a text along with its byte offsets
+type Token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+// stopWords are too common to be worth indexing
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "the": true, "of": true, "in": true,
+	"on": true, "to": true, "for": true, "by": true, "with": true, "is": true,
+}
+
+// Tokenize split s into lower cased words, keeping the position of each word in s
+func Tokenize(s string) []Token {
+	tokens := []Token{}
+	start := -1
+	for i, r := range s {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && start < 0 {
+			start = i
+		}
+		if !isWord && start >= 0 {
+			tokens = append(tokens, Token{Term: strings.ToLower(s[start:i]), Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, Token{Term: strings.ToLower(s[start:]), Start: start, End: len(s)})
+	}
+	return tokens
+}
+
+// Terms return the indexable words of s, stop words are dropped
+func Terms(s string) []string {
+	terms := []string{}
+	for _, t := range Tokenize(s) {
+		if !stopWords[t.Term] {
+			terms = append(terms, t.Term)
+		}
+	}
+	return terms
+}
+
+// Distance compute the edit distance between a and b, counting the
+// transposition of two adjacent letters as one typo. It gives up with max+1
+// as soon as the distance is known to exceed max
+func Distance(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	if abs(len(ra)-len(rb)) > max {
+		return max + 1
+	}
+	prevPrev := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = minInt(curr[j], prevPrev[j-2]+1)
+			}
+			if curr[j] < rowMin {
+				rowMin = curr[j]
+			}
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prevPrev, prev, curr = prev, curr, prevPrev
+	}
+	return prev[len(rb)]
+}
+
+// MaxTypos is the number of typos tolerated in a term of the given length
+func MaxTypos(term string) int {
+	n := len([]rune(term))
+	switch {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,50 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// localMailer deliver emails to a local file, or to the log when no file is configured
+type localMailer struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLocalMailer will create an object that represent the domain.Mailer interface,
+// an empty path means the emails are written to the log
+func NewLocalMailer(path string) domain.Mailer {
+	return &localMailer{path: path}
+}
+
+func (l *localMailer) Send(ctx context.Context, m domain.Mail) (err error) {
+	if l.path == "" {
+		logrus.WithFields(logrus.Fields{
+			"to":      m.To,
+			"subject": m.Subject,
+		}).Info(m.Body)
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer func() {
+		errClose := f.Close()
+		if err == nil {
+			err = errClose
+		}
+	}()
+	_, err = fmt.Fprintf(f, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n---\n",
+		time.Now().Format(time.RFC1123Z), m.To, m.Subject, m.Body)
+	return
+}
@@ -0,0 +1,16 @@
+-- the API keys of the partner integrations, stored as the SHA-256 of the key
+-- along with its first characters to recognize it
+CREATE TABLE IF NOT EXISTS api_key (
+	id INT NOT NULL AUTO_INCREMENT,
+	name VARCHAR(255) NOT NULL,
+	prefix VARCHAR(20) NOT NULL,
+	key_hash CHAR(64) NOT NULL,
+	scopes VARCHAR(1000) NOT NULL,
+	expires_at DATETIME NULL,
+	last_used_at DATETIME NULL,
+	revoked_at DATETIME NULL,
+	created_by VARCHAR(255) NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	UNIQUE KEY api_key_hash (key_hash)
+);
@@ -0,0 +1,14 @@
+-- the audit trail of the catalog mutations, with the actor, the changed
+-- fields and the request they were made in
+CREATE TABLE IF NOT EXISTS audit_log (
+	id BIGINT NOT NULL AUTO_INCREMENT,
+	actor VARCHAR(255) NOT NULL DEFAULT '',
+	action VARCHAR(20) NOT NULL,
+	entity_type VARCHAR(50) NOT NULL,
+	entity_id VARCHAR(50) NOT NULL,
+	changes JSON NOT NULL,
+	request_id VARCHAR(100) NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	KEY audit_log_entity (entity_type, entity_id, id)
+);
@@ -0,0 +1,17 @@
+-- the catalog events, appended in the transaction of the change they tell
+-- about and published in sequence order by the relay
+CREATE TABLE IF NOT EXISTS event_outbox (
+	sequence BIGINT NOT NULL AUTO_INCREMENT,
+	id VARCHAR(36) NOT NULL,
+	type VARCHAR(100) NOT NULL,
+	entity_type VARCHAR(50) NOT NULL,
+	entity_id VARCHAR(50) NOT NULL,
+	payload JSON NOT NULL,
+	actor VARCHAR(255) NOT NULL DEFAULT '',
+	request_id VARCHAR(100) NOT NULL DEFAULT '',
+	occurred_at DATETIME(6) NOT NULL,
+	published_at DATETIME(6) NULL,
+	PRIMARY KEY (sequence),
+	UNIQUE KEY event_outbox_id (id),
+	KEY event_outbox_unpublished (published_at, sequence)
+);
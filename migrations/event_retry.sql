@@ -0,0 +1,11 @@
+-- the published events a subscriber failed to handle, handed again to that
+-- subscriber alone until it takes them or they are given up
+CREATE TABLE IF NOT EXISTS event_retry (
+	sequence BIGINT NOT NULL,
+	subscriber VARCHAR(100) NOT NULL,
+	attempts INT NOT NULL,
+	next_attempt_at DATETIME(6) NOT NULL,
+	last_error VARCHAR(500) NOT NULL DEFAULT '',
+	PRIMARY KEY (sequence, subscriber),
+	KEY event_retry_due (next_attempt_at)
+);
@@ -0,0 +1,7 @@
+-- the exchange rates from the base currency, kept as exact decimals
+CREATE TABLE IF NOT EXISTS exchange_rate (
+	currency CHAR(3) NOT NULL,
+	rate DECIMAL(24,12) NOT NULL,
+	updated_at DATETIME NOT NULL,
+	PRIMARY KEY (currency)
+);
@@ -0,0 +1,37 @@
+-- the orders of the customers, their lines priced at purchase time and the
+-- audit trail of their status changes
+CREATE TABLE IF NOT EXISTS orders (
+	id INT NOT NULL AUTO_INCREMENT,
+	customer_id INT NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	shipping_address VARCHAR(500) NOT NULL DEFAULT '',
+	total BIGINT NOT NULL,
+	updated_at DATETIME NOT NULL,
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	KEY orders_customer (customer_id, created_at),
+	KEY orders_status (status, created_at)
+);
+
+CREATE TABLE IF NOT EXISTS order_item (
+	id INT NOT NULL AUTO_INCREMENT,
+	order_id INT NOT NULL,
+	product_id INT NOT NULL,
+	product_name VARCHAR(255) NOT NULL,
+	price BIGINT NOT NULL,
+	quantity INT NOT NULL,
+	PRIMARY KEY (id),
+	KEY order_item_order (order_id)
+);
+
+CREATE TABLE IF NOT EXISTS order_status_history (
+	id INT NOT NULL AUTO_INCREMENT,
+	order_id INT NOT NULL,
+	from_status VARCHAR(20) NOT NULL DEFAULT '',
+	to_status VARCHAR(20) NOT NULL,
+	changed_by VARCHAR(255) NOT NULL DEFAULT '',
+	note VARCHAR(500) NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	KEY order_status_history_order (order_id)
+);
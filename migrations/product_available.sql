@@ -0,0 +1,2 @@
+-- whether a product can be ordered, pushed to the product feed along with its price
+ALTER TABLE product ADD available TINYINT(1) NOT NULL DEFAULT 1;
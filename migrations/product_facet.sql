@@ -0,0 +1,8 @@
+-- the category and the year of publication of the products, filtered on and
+-- counted by the facets of the product listing
+ALTER TABLE product
+	ADD category VARCHAR(100) NOT NULL DEFAULT '',
+	ADD published_year INT NOT NULL DEFAULT 0,
+	ADD KEY product_category (category),
+	ADD KEY product_published_year (published_year),
+	ADD KEY product_price (price);
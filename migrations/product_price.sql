@@ -0,0 +1,27 @@
+-- the history of the product prices and the price changes scheduled for a
+-- later time, applied by the price scheduler once due
+CREATE TABLE IF NOT EXISTS product_price_history (
+	id INT NOT NULL AUTO_INCREMENT,
+	product_id INT NOT NULL,
+	old_price BIGINT NOT NULL,
+	new_price BIGINT NOT NULL,
+	changed_by VARCHAR(255) NOT NULL DEFAULT '',
+	changed_at DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	KEY product_price_history_product (product_id, changed_at)
+);
+
+CREATE TABLE IF NOT EXISTS product_price_schedule (
+	id INT NOT NULL AUTO_INCREMENT,
+	product_id INT NOT NULL,
+	price BIGINT NOT NULL,
+	effective_at DATETIME NOT NULL,
+	note VARCHAR(500) NOT NULL DEFAULT '',
+	status VARCHAR(20) NOT NULL,
+	created_by VARCHAR(255) NOT NULL DEFAULT '',
+	applied_at DATETIME NULL,
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	KEY product_price_schedule_product (product_id, effective_at),
+	KEY product_price_schedule_due (status, effective_at)
+);
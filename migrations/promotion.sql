@@ -0,0 +1,26 @@
+-- the promotions and their coupons, a NULL coupon code is an automatic
+-- promotion so that the unique key only applies to the coupons
+CREATE TABLE IF NOT EXISTS promotion (
+	id INT NOT NULL AUTO_INCREMENT,
+	name VARCHAR(200) NOT NULL,
+	type VARCHAR(20) NOT NULL,
+	value BIGINT NOT NULL,
+	scope VARCHAR(20) NOT NULL,
+	scope_value VARCHAR(255) NOT NULL DEFAULT '',
+	starts_at DATETIME NULL,
+	ends_at DATETIME NULL,
+	coupon_code VARCHAR(100) NULL,
+	usage_limit INT NOT NULL DEFAULT 0,
+	usage_count INT NOT NULL DEFAULT 0,
+	stackable TINYINT(1) NOT NULL DEFAULT 0,
+	priority INT NOT NULL DEFAULT 0,
+	active TINYINT(1) NOT NULL DEFAULT 1,
+	updated_at DATETIME NOT NULL,
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	UNIQUE KEY promotion_coupon_code (coupon_code),
+	KEY promotion_scope (scope, scope_value)
+);
+
+-- the coupon redeemed by an order
+ALTER TABLE orders ADD coupon_code VARCHAR(100) NOT NULL DEFAULT '';
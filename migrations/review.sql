@@ -0,0 +1,29 @@
+-- the reviews of the products, one per user and product, and the helpful
+-- votes on them, one per user and review
+CREATE TABLE IF NOT EXISTS review (
+	id INT NOT NULL AUTO_INCREMENT,
+	product_id INT NOT NULL,
+	user_id INT NOT NULL,
+	rating TINYINT NOT NULL,
+	title VARCHAR(200) NOT NULL DEFAULT '',
+	body TEXT NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	helpful_count INT NOT NULL DEFAULT 0,
+	updated_at DATETIME NOT NULL,
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	UNIQUE KEY review_user_product (user_id, product_id),
+	KEY review_product_status (product_id, status)
+);
+
+CREATE TABLE IF NOT EXISTS review_vote (
+	review_id INT NOT NULL,
+	user_id INT NOT NULL,
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (review_id, user_id)
+);
+
+-- the rating aggregates of the approved reviews, kept up to date by the review repository
+ALTER TABLE product
+	ADD rating_average DECIMAL(3,2) NOT NULL DEFAULT 0,
+	ADD rating_count INT NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+-- the full-text indexes of the search, MATCH only uses an index on exactly
+-- the columns it lists. InnoDB builds one full-text index per statement
+ALTER TABLE product ADD FULLTEXT KEY product_name_fulltext (name);
+
+ALTER TABLE product ADD FULLTEXT KEY product_name_description_fulltext (name, description);
+
+ALTER TABLE author ADD FULLTEXT KEY author_name_fulltext (name);
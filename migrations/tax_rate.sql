@@ -0,0 +1,17 @@
+-- the tax rates by country, region and tax class, an empty region applies to
+-- the whole country and the rates of a class add up
+CREATE TABLE IF NOT EXISTS tax_rate (
+	id INT NOT NULL AUTO_INCREMENT,
+	country CHAR(2) NOT NULL,
+	region VARCHAR(10) NOT NULL DEFAULT '',
+	tax_class VARCHAR(50) NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	rate DECIMAL(9,4) NOT NULL,
+	updated_at DATETIME NOT NULL,
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	UNIQUE KEY tax_rate_name (country, region, tax_class, name)
+);
+
+-- the tax class of a product picks its rates
+ALTER TABLE product ADD tax_class VARCHAR(50) NOT NULL DEFAULT 'standard';
@@ -0,0 +1,32 @@
+-- the customer accounts, their login sessions and their password reset
+-- tokens, the tokens being stored as their SHA-256
+CREATE TABLE IF NOT EXISTS user (
+	id INT NOT NULL AUTO_INCREMENT,
+	name VARCHAR(255) NOT NULL,
+	email VARCHAR(255) NOT NULL,
+	password_hash VARCHAR(100) NOT NULL,
+	role VARCHAR(50) NOT NULL,
+	updated_at DATETIME NOT NULL,
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	UNIQUE KEY user_email (email)
+);
+
+CREATE TABLE IF NOT EXISTS user_session (
+	token_hash CHAR(64) NOT NULL,
+	user_id INT NOT NULL,
+	expires_at DATETIME NOT NULL,
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (token_hash),
+	KEY user_session_user (user_id)
+);
+
+CREATE TABLE IF NOT EXISTS password_reset_token (
+	token_hash CHAR(64) NOT NULL,
+	user_id INT NOT NULL,
+	expires_at DATETIME NOT NULL,
+	used_at DATETIME NULL,
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (token_hash),
+	KEY password_reset_token_user (user_id)
+);
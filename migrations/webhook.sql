@@ -0,0 +1,33 @@
+-- the webhook subscriptions of the partners and the deliveries of the events
+-- to them, one per subscription and event, retried until delivered or dead
+CREATE TABLE IF NOT EXISTS webhook_subscription (
+	id INT NOT NULL AUTO_INCREMENT,
+	owner VARCHAR(255) NOT NULL,
+	url VARCHAR(2000) NOT NULL,
+	event_types VARCHAR(1000) NOT NULL DEFAULT '',
+	secret VARCHAR(255) NOT NULL,
+	active TINYINT(1) NOT NULL DEFAULT 1,
+	updated_at DATETIME NOT NULL,
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	KEY webhook_subscription_owner (owner)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+	id INT NOT NULL AUTO_INCREMENT,
+	subscription_id INT NOT NULL,
+	event_id VARCHAR(36) NOT NULL,
+	event_type VARCHAR(100) NOT NULL,
+	payload JSON NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	next_attempt_at DATETIME(6) NOT NULL,
+	last_status_code INT NOT NULL DEFAULT 0,
+	last_error VARCHAR(500) NOT NULL DEFAULT '',
+	delivered_at DATETIME NULL,
+	updated_at DATETIME NOT NULL,
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	UNIQUE KEY webhook_delivery_event (subscription_id, event_id),
+	KEY webhook_delivery_due (status, next_attempt_at)
+);
@@ -0,0 +1,27 @@
+-- the wishlists of the customers, shared by their slug, and their ordered
+-- items along with the price last notified for each
+CREATE TABLE IF NOT EXISTS wishlist (
+	id INT NOT NULL AUTO_INCREMENT,
+	user_id INT NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	description VARCHAR(1000) NOT NULL DEFAULT '',
+	visibility VARCHAR(20) NOT NULL,
+	slug VARCHAR(50) NOT NULL,
+	updated_at DATETIME NOT NULL,
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	UNIQUE KEY wishlist_slug (slug),
+	KEY wishlist_user (user_id, created_at)
+);
+
+CREATE TABLE IF NOT EXISTS wishlist_item (
+	wishlist_id INT NOT NULL,
+	product_id INT NOT NULL,
+	position INT NOT NULL,
+	note VARCHAR(1000) NOT NULL DEFAULT '',
+	last_price BIGINT NOT NULL,
+	added_at DATETIME NOT NULL,
+	PRIMARY KEY (wishlist_id, product_id),
+	KEY wishlist_item_position (wishlist_id, position),
+	KEY wishlist_item_product (product_id, last_price)
+);
@@ -0,0 +1,153 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+// OrderHandler represent the httphandler for order
+type OrderHandler struct {
+	OUsecase domain.OrderUsecase
+}
+
+// NewOrderHandler will initialize the order endpoint, the status changes
+// require their permission on top of auth
+func NewOrderHandler(e *echo.Echo, us domain.OrderUsecase, auth echo.MiddlewareFunc, require func(domain.Permission) echo.MiddlewareFunc) {
+	handler := &OrderHandler{
+		OUsecase: us,
+	}
+	e.POST("/order", handler.Store, auth)
+	e.GET("/order/:orderId", handler.GetByID, auth)
+	e.GET("/customer/:customerId/order", handler.FetchByCustomer, auth)
+	e.GET("/admin/order", handler.Fetch, auth)
+	e.PUT("/admin/order/:orderId/status", handler.Transition, auth, require(domain.PermissionOrderManage))
+}
+
+// Fetch will fetch the orders, optionally filtered by status and customer_id query params
+func (o *OrderHandler) Fetch(c echo.Context) error {
+	customerID, _ := strconv.Atoi(c.QueryParam("customer_id"))
+	filter := domain.OrderFilter{
+		CustomerID: customerID,
+		Status:     domain.OrderStatus(c.QueryParam("status")),
+	}
+	ctx := c.Request().Context()
+	orders, err := o.OUsecase.Fetch(ctx, filter)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: orders})
+}
+
+// FetchByCustomer will fetch the order history of a customer
+func (o *OrderHandler) FetchByCustomer(c echo.Context) error {
+	customerID, _ := strconv.Atoi(c.Param("customerId"))
+	ctx := c.Request().Context()
+	orders, err := o.OUsecase.Fetch(ctx, domain.OrderFilter{CustomerID: customerID})
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: orders})
+}
+
+// Store will place an order by given request body
+func (o *OrderHandler) Store(c echo.Context) (err error) {
+	var order domain.Order
+	err = c.Bind(&order)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&order); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	err = o.OUsecase.Store(ctx, &order)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusCreated, response.ResponseSuccess{Success: true, Data: order})
+}
+
+// GetByID will get order by given id
+func (o *OrderHandler) GetByID(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("orderId"))
+	ctx := c.Request().Context()
+	order, err := o.OUsecase.GetByID(ctx, id)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: order})
+}
+
+// Transition will move the order to the status given in the request body, the
+// authenticated principal is recorded as the author of the change
+func (o *OrderHandler) Transition(c echo.Context) (err error) {
+	id, _ := strconv.Atoi(c.Param("orderId"))
+	var transition domain.OrderTransition
+	err = c.Bind(&transition)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&transition); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	principal, _ := domain.PrincipalFromContext(ctx)
+	transition.ChangedBy = principal.Subject
+	err = o.OUsecase.Transition(ctx, id, &transition)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: nil})
+}
+
+func isRequestValid(m interface{}) (bool, error) {
+	validate := validator.New()
+	err := validate.Struct(m)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// failed write the error response of err, a denied permission is described with a problem detail
+func failed(c echo.Context, err error) error {
+	status := getStatusCode(err)
+	if status == http.StatusForbidden {
+		c.Response().Header().Set(echo.HeaderContentType, response.ProblemContentType)
+		return c.JSON(status, response.NewProblem(status, err.Error()))
+	}
+	return c.JSON(status, response.ResponseFailed{Success: false, Message: err.Error()})
+}
+
+func getStatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	logrus.Error(err)
+	switch {
+	case errors.Is(err, domain.ErrInternalServerError):
+		return http.StatusInternalServerError
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrConflict), errors.Is(err, domain.ErrInvalidTransition):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrBadParamInput):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
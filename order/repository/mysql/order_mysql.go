@@ -0,0 +1,266 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// mysqlOrderRepository represent the connection database struct
+type mysqlOrderRepository struct {
+	Conn *sql.DB
+}
+
+// NewMysqlOrderRepository will create an object that represent the order.Repository interface
+func NewMysqlOrderRepository(Conn *sql.DB) domain.OrderRepository {
+	return &mysqlOrderRepository{Conn: Conn}
+}
+
+func (m *mysqlOrderRepository) fetch(ctx context.Context, query string, args ...interface{}) (result []domain.Order, err error) {
+	rows, err := m.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.Order, 0)
+	for rows.Next() {
+		t := domain.Order{}
+		err = rows.Scan(
+			&t.ID,
+			&t.CustomerID,
+			&t.Status,
+			&t.ShippingAddress,
+			&t.Total,
+			&t.CouponCode,
+			&t.UpdatedAt,
+			&t.CreatedAt,
+		)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+func (m *mysqlOrderRepository) fetchItems(ctx context.Context, orderID int) (result []domain.OrderItem, err error) {
+	query := `SELECT id, order_id, product_id, product_name, price, quantity FROM order_item WHERE order_id=? ORDER BY id`
+	rows, err := m.Conn.QueryContext(ctx, query, orderID)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.OrderItem, 0)
+	for rows.Next() {
+		t := domain.OrderItem{}
+		err = rows.Scan(&t.ID, &t.OrderID, &t.ProductID, &t.ProductName, &t.Price, &t.Quantity)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+func (m *mysqlOrderRepository) Fetch(ctx context.Context, filter domain.OrderFilter) (res []domain.Order, err error) {
+	query := `SELECT id, customer_id, status, shipping_address, total, coupon_code, updated_at, created_at FROM orders`
+	conds := []string{}
+	args := []interface{}{}
+	if filter.CustomerID != 0 {
+		conds = append(conds, "customer_id=?")
+		args = append(args, filter.CustomerID)
+	}
+	if filter.Status != "" {
+		conds = append(conds, "status=?")
+		args = append(args, filter.Status)
+	}
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY created_at DESC"
+	res, err = m.fetch(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range res {
+		res[i].Items, err = m.fetchItems(ctx, res[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+func (m *mysqlOrderRepository) Store(ctx context.Context, o *domain.Order) (err error) {
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(); errRollback != nil {
+				logrus.Error(errRollback)
+			}
+		}
+	}()
+
+	now := time.Now()
+	query := `INSERT INTO orders (customer_id, status, shipping_address, total, coupon_code, updated_at, created_at) VALUES(?,?,?,?,?,?,?)`
+	result, err := tx.ExecContext(ctx, query, o.CustomerID, o.Status, o.ShippingAddress, o.Total, o.CouponCode, now, now)
+	if err != nil {
+		return
+	}
+	lastID, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+	o.ID = int(lastID)
+	o.UpdatedAt = now
+	o.CreatedAt = now
+
+	query = `INSERT INTO order_item (order_id, product_id, product_name, price, quantity) VALUES(?,?,?,?,?)`
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return
+	}
+	defer stmt.Close()
+	for i := range o.Items {
+		item := &o.Items[i]
+		item.OrderID = o.ID
+		result, err = stmt.ExecContext(ctx, item.OrderID, item.ProductID, item.ProductName, item.Price, item.Quantity)
+		if err != nil {
+			return
+		}
+		lastID, err = result.LastInsertId()
+		if err != nil {
+			return
+		}
+		item.ID = int(lastID)
+	}
+
+	query = `INSERT INTO order_status_history (order_id, from_status, to_status, changed_by, note, created_at) VALUES(?,?,?,?,?,?)`
+	for i := range o.History {
+		h := &o.History[i]
+		h.OrderID = o.ID
+		h.CreatedAt = now
+		result, err = tx.ExecContext(ctx, query, h.OrderID, h.FromStatus, h.ToStatus, h.ChangedBy, h.Note, h.CreatedAt)
+		if err != nil {
+			return
+		}
+		lastID, err = result.LastInsertId()
+		if err != nil {
+			return
+		}
+		h.ID = int(lastID)
+	}
+	err = tx.Commit()
+	return
+}
+
+func (m *mysqlOrderRepository) GetByID(ctx context.Context, id int) (res domain.Order, err error) {
+	query := `SELECT id, customer_id, status, shipping_address, total, coupon_code, updated_at, created_at FROM orders WHERE id=?`
+	list, err := m.fetch(ctx, query, id)
+	if err != nil {
+		return
+	}
+	if len(list) == 0 {
+		return res, domain.ErrNotFound
+	}
+	res = list[0]
+	res.Items, err = m.fetchItems(ctx, res.ID)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// UpdateStatus move the order from the given status and record the change, the
+// update only succeed if nobody else changed the status in the meantime
+func (m *mysqlOrderRepository) UpdateStatus(ctx context.Context, id int, from domain.OrderStatus, h *domain.OrderStatusHistory) (err error) {
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(); errRollback != nil {
+				logrus.Error(errRollback)
+			}
+		}
+	}()
+
+	now := time.Now()
+	query := `UPDATE orders SET status=?, updated_at=? WHERE id=? AND status=?`
+	result, err := tx.ExecContext(ctx, query, h.ToStatus, now, id, from)
+	if err != nil {
+		return
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+	if affected == 0 {
+		err = domain.ErrConflict
+		return
+	}
+
+	query = `INSERT INTO order_status_history (order_id, from_status, to_status, changed_by, note, created_at) VALUES(?,?,?,?,?,?)`
+	result, err = tx.ExecContext(ctx, query, id, from, h.ToStatus, h.ChangedBy, h.Note, now)
+	if err != nil {
+		return
+	}
+	lastID, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+	h.ID = int(lastID)
+	h.OrderID = id
+	h.FromStatus = from
+	h.CreatedAt = now
+	err = tx.Commit()
+	return
+}
+
+func (m *mysqlOrderRepository) FetchHistory(ctx context.Context, orderID int) (result []domain.OrderStatusHistory, err error) {
+	query := `SELECT id, order_id, from_status, to_status, changed_by, note, created_at FROM order_status_history WHERE order_id=? ORDER BY id`
+	rows, err := m.Conn.QueryContext(ctx, query, orderID)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.OrderStatusHistory, 0)
+	for rows.Next() {
+		t := domain.OrderStatusHistory{}
+		err = rows.Scan(&t.ID, &t.OrderID, &t.FromStatus, &t.ToStatus, &t.ChangedBy, &t.Note, &t.CreatedAt)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
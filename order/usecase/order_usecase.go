@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// transitionGuard check whether an order satisfy the precondition of a status change
+type transitionGuard func(o domain.Order, t *domain.OrderTransition) error
+
+// guards hold the extra precondition of a status, on top of the allowed moves of the state machine
+var guards = map[domain.OrderStatus]transitionGuard{
+	domain.OrderStatusPaid: func(o domain.Order, t *domain.OrderTransition) error {
+		// an order fully discounted by its promotions is paid at 0
+		if o.Total < 0 {
+			return fmt.Errorf("%w: order has a negative total", domain.ErrInvalidTransition)
+		}
+		return nil
+	},
+	domain.OrderStatusShipped: func(o domain.Order, t *domain.OrderTransition) error {
+		if o.ShippingAddress == "" {
+			return fmt.Errorf("%w: order has no shipping address", domain.ErrInvalidTransition)
+		}
+		return nil
+	},
+	domain.OrderStatusRefunded: func(o domain.Order, t *domain.OrderTransition) error {
+		if t.Note == "" {
+			return fmt.Errorf("%w: refund needs a reason in note", domain.ErrInvalidTransition)
+		}
+		return nil
+	},
+}
+
+// OrderUsecase represent the order use case struct
+type OrderUsecase struct {
+	orderRepo      domain.OrderRepository
+	productRepo    domain.ProductRepository
+	promotions     domain.PromotionUsecase
+	contextTimeout time.Duration
+}
+
+// NewOrderUsecase will create new an order usecase object representation of domain.OrderUsecase interface,
+// the lines are priced with the promotions running when the order is placed
+func NewOrderUsecase(o domain.OrderRepository, p domain.ProductRepository, pu domain.PromotionUsecase, timeout time.Duration) domain.OrderUsecase {
+	return &OrderUsecase{
+		orderRepo:      o,
+		productRepo:    p,
+		promotions:     pu,
+		contextTimeout: timeout,
+	}
+}
+
+// authorizeCustomer allow a customer to act on its own orders, and anybody else granted perm
+func authorizeCustomer(ctx context.Context, customerID int, perm domain.Permission) error {
+	p, ok := domain.PrincipalFromContext(ctx)
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+	if p.Can(perm) || (customerID != 0 && p.IsUser(customerID)) {
+		return nil
+	}
+	return domain.ErrForbidden
+}
+
+// Fetch will get orders matching the given filter
+func (o *OrderUsecase) Fetch(c context.Context, filter domain.OrderFilter) (res []domain.Order, err error) {
+	if err = authorizeCustomer(c, filter.CustomerID, domain.PermissionOrderRead); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, o.contextTimeout)
+	defer cancel()
+	res, err = o.orderRepo.Fetch(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Store will place a new order, the name and discounted price of every product is snapshotted into its line.
+// A coupon must apply to one of the lines at least and is redeemed once per order
+func (o *OrderUsecase) Store(c context.Context, m *domain.Order) (err error) {
+	if err = authorizeCustomer(c, m.CustomerID, domain.PermissionOrderManage); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, o.contextTimeout)
+	defer cancel()
+
+	m.Total = 0
+	m.CouponCode = strings.ToUpper(strings.TrimSpace(m.CouponCode))
+	req := &domain.CartQuoteRequest{
+		CouponCode: m.CouponCode,
+		Lines:      make([]domain.CartQuoteLine, len(m.Items)),
+	}
+	for i, item := range m.Items {
+		req.Lines[i] = domain.CartQuoteLine{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+	quote, err := o.promotions.QuoteCart(ctx, req)
+	if err != nil {
+		return
+	}
+	for i := range m.Items {
+		item := &m.Items[i]
+		item.ProductName = quote.Lines[i].ProductName
+		item.Price = quote.Lines[i].FinalPrice
+		m.Total += item.Price * int64(item.Quantity)
+	}
+	if m.CouponCode != "" {
+		if !quote.CouponApplied {
+			return fmt.Errorf("%w: coupon does not apply to this order", domain.ErrBadParamInput)
+		}
+		if err = o.promotions.Redeem(ctx, m.CouponCode); err != nil {
+			if errors.Is(err, domain.ErrConflict) {
+				return fmt.Errorf("%w: coupon is no longer redeemable", domain.ErrConflict)
+			}
+			return
+		}
+		defer func() {
+			if err != nil {
+				// the request may be what failed, the use is given back regardless
+				releaseCtx, cancelRelease := context.WithTimeout(context.Background(), o.contextTimeout)
+				defer cancelRelease()
+				if errRelease := o.promotions.Release(releaseCtx, m.CouponCode); errRelease != nil {
+					logrus.Error(errRelease)
+				}
+			}
+		}()
+	}
+	principal, _ := domain.PrincipalFromContext(ctx)
+	m.Status = domain.OrderStatusPending
+	m.History = []domain.OrderStatusHistory{{
+		ToStatus:  domain.OrderStatusPending,
+		ChangedBy: principal.Subject,
+		Note:      "order placed",
+	}}
+	err = o.orderRepo.Store(ctx, m)
+	return
+}
+
+// GetByID will get an order along with its status history
+func (o *OrderUsecase) GetByID(c context.Context, id int) (res domain.Order, err error) {
+	ctx, cancel := context.WithTimeout(c, o.contextTimeout)
+	defer cancel()
+	res, err = o.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		return
+	}
+	if err = authorizeCustomer(ctx, res.CustomerID, domain.PermissionOrderRead); err != nil {
+		return domain.Order{}, err
+	}
+	res.History, err = o.orderRepo.FetchHistory(ctx, id)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// Transition will move an order to the requested status when the state machine allows it
+func (o *OrderUsecase) Transition(c context.Context, id int, t *domain.OrderTransition) (err error) {
+	if err = domain.Authorize(c, domain.PermissionOrderManage); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, o.contextTimeout)
+	defer cancel()
+
+	if !t.Status.IsValid() {
+		return domain.ErrBadParamInput
+	}
+	order, err := o.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		return
+	}
+	if !order.Status.CanTransitionTo(t.Status) {
+		return fmt.Errorf("%w: %s to %s", domain.ErrInvalidTransition, order.Status, t.Status)
+	}
+	if guard, ok := guards[t.Status]; ok {
+		if err = guard(order, t); err != nil {
+			return
+		}
+	}
+	err = o.orderRepo.UpdateStatus(ctx, id, order.Status, &domain.OrderStatusHistory{
+		ToStatus:  t.Status,
+		ChangedBy: t.ChangedBy,
+		Note:      t.Note,
+	})
+	return
+}
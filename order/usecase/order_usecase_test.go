@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// fakeOrderRepository hold a single order and record its status changes
+type fakeOrderRepository struct {
+	domain.OrderRepository
+	order   domain.Order
+	updated *domain.OrderStatusHistory
+}
+
+func (r *fakeOrderRepository) GetByID(ctx context.Context, id int) (domain.Order, error) {
+	if id != r.order.ID {
+		return domain.Order{}, domain.ErrNotFound
+	}
+	return r.order, nil
+}
+
+func (r *fakeOrderRepository) UpdateStatus(ctx context.Context, id int, from domain.OrderStatus, h *domain.OrderStatusHistory) error {
+	if from != r.order.Status {
+		return domain.ErrConflict
+	}
+	r.updated = h
+	return nil
+}
+
+func TestOrderUsecaseTransition(t *testing.T) {
+	admin := domain.NewContextWithPrincipal(context.Background(), domain.Principal{Subject: "user:1", Roles: []string{domain.RoleAdmin}})
+	statuses := []domain.OrderStatus{
+		domain.OrderStatusPending,
+		domain.OrderStatusPaid,
+		domain.OrderStatusShipped,
+		domain.OrderStatusDelivered,
+		domain.OrderStatusCancelled,
+		domain.OrderStatusRefunded,
+	}
+	allowed := map[domain.OrderStatus]map[domain.OrderStatus]bool{
+		domain.OrderStatusPending:   {domain.OrderStatusPaid: true, domain.OrderStatusCancelled: true},
+		domain.OrderStatusPaid:      {domain.OrderStatusShipped: true, domain.OrderStatusRefunded: true},
+		domain.OrderStatusShipped:   {domain.OrderStatusDelivered: true, domain.OrderStatusRefunded: true},
+		domain.OrderStatusDelivered: {domain.OrderStatusRefunded: true},
+	}
+
+	type test struct {
+		name    string
+		order   domain.Order
+		to      domain.OrderStatus
+		note    string
+		wantErr error
+	}
+	// an order meeting every guard, so that only the state machine decides
+	valid := func(status domain.OrderStatus) domain.Order {
+		return domain.Order{ID: 1, Status: status, Total: 50000, ShippingAddress: "Jl. Merdeka 1"}
+	}
+	tests := []test{}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			tt := test{name: string(from) + " to " + string(to), order: valid(from), to: to, note: "damaged"}
+			if !allowed[from][to] {
+				tt.wantErr = domain.ErrInvalidTransition
+			}
+			tests = append(tests, tt)
+		}
+	}
+	tests = append(tests,
+		test{
+			name:  "paid at 0",
+			order: domain.Order{ID: 1, Status: domain.OrderStatusPending, Total: 0},
+			to:    domain.OrderStatusPaid,
+		},
+		test{
+			name:    "paid with a negative total",
+			order:   domain.Order{ID: 1, Status: domain.OrderStatusPending, Total: -1},
+			to:      domain.OrderStatusPaid,
+			wantErr: domain.ErrInvalidTransition,
+		},
+		test{
+			name:    "shipped without address",
+			order:   domain.Order{ID: 1, Status: domain.OrderStatusPaid, Total: 50000},
+			to:      domain.OrderStatusShipped,
+			wantErr: domain.ErrInvalidTransition,
+		},
+		test{
+			name:    "refunded without note",
+			order:   valid(domain.OrderStatusDelivered),
+			to:      domain.OrderStatusRefunded,
+			wantErr: domain.ErrInvalidTransition,
+		},
+		test{
+			name:    "unknown status",
+			order:   valid(domain.OrderStatusPending),
+			to:      "returned",
+			wantErr: domain.ErrBadParamInput,
+		},
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeOrderRepository{order: tt.order}
+			u := NewOrderUsecase(repo, nil, nil, time.Second)
+			err := u.Transition(admin, tt.order.ID, &domain.OrderTransition{Status: tt.to, Note: tt.note})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Transition() error = %v, want %v", err, tt.wantErr)
+				}
+				if repo.updated != nil {
+					t.Fatalf("Transition() updated the status to %s", repo.updated.ToStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Transition() error = %v", err)
+			}
+			if repo.updated == nil || repo.updated.ToStatus != tt.to {
+				t.Fatalf("Transition() updated = %+v, want status %s", repo.updated, tt.to)
+			}
+		})
+	}
+}
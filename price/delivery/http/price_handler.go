@@ -0,0 +1,120 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+// PriceHandler represent the httphandler for the price history and schedule
+type PriceHandler struct {
+	PUsecase domain.PriceUsecase
+}
+
+// NewPriceHandler will initialize the price history and schedule endpoints
+func NewPriceHandler(e *echo.Echo, us domain.PriceUsecase, auth echo.MiddlewareFunc) {
+	handler := &PriceHandler{
+		PUsecase: us,
+	}
+	e.GET("/product/:productId/price-history", handler.FetchHistory, auth)
+	e.GET("/product/:productId/price-schedule", handler.FetchScheduled, auth)
+	e.POST("/product/:productId/price-schedule", handler.Schedule, auth)
+	e.DELETE("/price-schedule/:scheduleId", handler.Cancel, auth)
+}
+
+// FetchHistory will fetch the price changes of the product, the latest first
+func (p *PriceHandler) FetchHistory(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("productId"))
+	ctx := c.Request().Context()
+	history, err := p.PUsecase.FetchHistory(ctx, id)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: history})
+}
+
+// FetchScheduled will fetch the scheduled price changes of the product
+func (p *PriceHandler) FetchScheduled(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("productId"))
+	ctx := c.Request().Context()
+	scheduled, err := p.PUsecase.FetchScheduled(ctx, id)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: scheduled})
+}
+
+// Schedule will plan the price change of the request body
+func (p *PriceHandler) Schedule(c echo.Context) (err error) {
+	var scheduled domain.ScheduledPriceChange
+	if err = c.Bind(&scheduled); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	scheduled.ProductID, _ = strconv.Atoi(c.Param("productId"))
+	var ok bool
+	if ok, err = isRequestValid(&scheduled); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	if err = p.PUsecase.Schedule(ctx, &scheduled); err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusCreated, response.ResponseSuccess{Success: true, Data: scheduled})
+}
+
+// Cancel will drop a pending price change
+func (p *PriceHandler) Cancel(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("scheduleId"))
+	ctx := c.Request().Context()
+	if err := p.PUsecase.Cancel(ctx, id); err != nil {
+		return failed(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func isRequestValid(m interface{}) (bool, error) {
+	validate := validator.New()
+	err := validate.Struct(m)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// failed write the error response of err, a denied permission is described with a problem detail
+func failed(c echo.Context, err error) error {
+	status := getStatusCode(err)
+	if status == http.StatusForbidden {
+		c.Response().Header().Set(echo.HeaderContentType, response.ProblemContentType)
+		return c.JSON(status, response.NewProblem(status, err.Error()))
+	}
+	return c.JSON(status, response.ResponseFailed{Success: false, Message: err.Error()})
+}
+
+func getStatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	logrus.Error(err)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrBadParamInput):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
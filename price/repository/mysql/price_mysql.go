@@ -0,0 +1,150 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/sqltx"
+)
+
+const selectScheduled = `SELECT id, product_id, price, effective_at, note, status, created_by, applied_at, created_at
+	FROM product_price_schedule`
+
+// mysqlPriceRepository represent the connection database struct
+type mysqlPriceRepository struct {
+	Conn *sql.DB
+}
+
+// NewMysqlPriceRepository will create an object that represent the price Repository interface
+func NewMysqlPriceRepository(Conn *sql.DB) domain.PriceRepository {
+	return &mysqlPriceRepository{Conn: Conn}
+}
+
+func (m *mysqlPriceRepository) FetchHistory(ctx context.Context, productID int) (result []domain.PriceChange, err error) {
+	query := `SELECT id, product_id, old_price, new_price, changed_by, changed_at FROM product_price_history
+		WHERE product_id=? ORDER BY changed_at DESC, id DESC`
+	rows, err := m.Conn.QueryContext(ctx, query, productID)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.PriceChange, 0)
+	for rows.Next() {
+		t := domain.PriceChange{}
+		if err = rows.Scan(&t.ID, &t.ProductID, &t.OldPrice, &t.NewPrice, &t.ChangedBy, &t.ChangedAt); err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+func (m *mysqlPriceRepository) fetchScheduled(ctx context.Context, query string, args ...interface{}) (result []domain.ScheduledPriceChange, err error) {
+	rows, err := m.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.ScheduledPriceChange, 0)
+	for rows.Next() {
+		t := domain.ScheduledPriceChange{}
+		var appliedAt sql.NullTime
+		err = rows.Scan(
+			&t.ID,
+			&t.ProductID,
+			&t.Price,
+			&t.EffectiveAt,
+			&t.Note,
+			&t.Status,
+			&t.CreatedBy,
+			&appliedAt,
+			&t.CreatedAt,
+		)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		if appliedAt.Valid {
+			t.AppliedAt = &appliedAt.Time
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+func (m *mysqlPriceRepository) FetchScheduled(ctx context.Context, productID int) ([]domain.ScheduledPriceChange, error) {
+	return m.fetchScheduled(ctx, selectScheduled+` WHERE product_id=? ORDER BY effective_at, id`, productID)
+}
+
+func (m *mysqlPriceRepository) FetchDue(ctx context.Context, now time.Time, limit int) ([]domain.ScheduledPriceChange, error) {
+	query := selectScheduled + ` WHERE status=? AND effective_at<=? ORDER BY effective_at, id LIMIT ?`
+	return m.fetchScheduled(ctx, query, domain.ScheduledPricePending, now, limit)
+}
+
+func (m *mysqlPriceRepository) GetScheduled(ctx context.Context, id int) (res domain.ScheduledPriceChange, err error) {
+	list, err := m.fetchScheduled(ctx, selectScheduled+` WHERE id=?`, id)
+	if err != nil {
+		return
+	}
+	if len(list) == 0 {
+		return res, domain.ErrNotFound
+	}
+	return list[0], nil
+}
+
+func (m *mysqlPriceRepository) StoreScheduled(ctx context.Context, s *domain.ScheduledPriceChange) error {
+	query := `INSERT INTO product_price_schedule (product_id, price, effective_at, note, status, created_by, created_at)
+		VALUES(?,?,?,?,?,?,?)`
+	now := time.Now()
+	result, err := m.Conn.ExecContext(ctx, query, s.ProductID, s.Price, s.EffectiveAt, s.Note, s.Status, s.CreatedBy, now)
+	if err != nil {
+		return err
+	}
+	lastID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	s.ID = int(lastID)
+	s.CreatedAt = now
+	return nil
+}
+
+// UpdateScheduledStatus only moves the change when it is still in the from
+// status, so that two schedulers never apply the same change. It takes part in
+// the transaction of ctx, the change is only claimed along with the new price
+func (m *mysqlPriceRepository) UpdateScheduledStatus(ctx context.Context, id int, from domain.ScheduledPriceStatus, to domain.ScheduledPriceStatus) error {
+	var appliedAt *time.Time
+	if to == domain.ScheduledPriceApplied {
+		now := time.Now()
+		appliedAt = &now
+	}
+	query := `UPDATE product_price_schedule SET status=?, applied_at=? WHERE id=? AND status=?`
+	result, err := sqltx.From(ctx, m.Conn).ExecContext(ctx, query, to, appliedAt, id, from)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrConflict
+	}
+	return nil
+}
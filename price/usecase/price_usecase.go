@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// dueBatchSize bound the scheduled changes applied by a single ApplyDue
+const dueBatchSize = 100
+
+// PriceUsecase represent the price use case struct
+type PriceUsecase struct {
+	priceRepo      domain.PriceRepository
+	productRepo    domain.ProductRepository
+	transactor     domain.Transactor
+	events         domain.EventEmitter
+	contextTimeout time.Duration
+}
+
+// NewPriceUsecase will create new a price usecase object representation of domain.PriceUsecase interface,
+// the applied changes emit a product update in the transaction making them
+func NewPriceUsecase(pr domain.PriceRepository, p domain.ProductRepository, tx domain.Transactor, events domain.EventEmitter, timeout time.Duration) domain.PriceUsecase {
+	return &PriceUsecase{
+		priceRepo:      pr,
+		productRepo:    p,
+		transactor:     tx,
+		events:         events,
+		contextTimeout: timeout,
+	}
+}
+
+// FetchHistory will get the price changes of a product, the latest first
+func (u *PriceUsecase) FetchHistory(c context.Context, productID int) (res []domain.PriceChange, err error) {
+	if err = domain.Authorize(c, domain.PermissionProductWrite); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	if _, err = u.productRepo.GetByID(ctx, productID); err != nil {
+		return
+	}
+	return u.priceRepo.FetchHistory(ctx, productID)
+}
+
+// FetchScheduled will get the scheduled price changes of a product, whatever their status
+func (u *PriceUsecase) FetchScheduled(c context.Context, productID int) (res []domain.ScheduledPriceChange, err error) {
+	if err = domain.Authorize(c, domain.PermissionProductWrite); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	if _, err = u.productRepo.GetByID(ctx, productID); err != nil {
+		return
+	}
+	return u.priceRepo.FetchScheduled(ctx, productID)
+}
+
+// Schedule will plan a price change of a product, it must be in the future
+func (u *PriceUsecase) Schedule(c context.Context, s *domain.ScheduledPriceChange) (err error) {
+	if err = domain.Authorize(c, domain.PermissionProductWrite); err != nil {
+		return
+	}
+	if !s.EffectiveAt.After(time.Now()) {
+		return domain.ErrBadParamInput
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	if _, err = u.productRepo.GetByID(ctx, s.ProductID); err != nil {
+		return
+	}
+	principal, _ := domain.PrincipalFromContext(ctx)
+	s.Status = domain.ScheduledPricePending
+	s.CreatedBy = principal.Subject
+	s.AppliedAt = nil
+	return u.priceRepo.StoreScheduled(ctx, s)
+}
+
+// Cancel will drop a pending price change
+func (u *PriceUsecase) Cancel(c context.Context, id int) (err error) {
+	if err = domain.Authorize(c, domain.PermissionProductWrite); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	if _, err = u.priceRepo.GetScheduled(ctx, id); err != nil {
+		return
+	}
+	return u.priceRepo.UpdateScheduledStatus(ctx, id, domain.ScheduledPricePending, domain.ScheduledPriceCancelled)
+}
+
+// ApplyDue will apply the pending changes effective at now. Every change is
+// claimed, applied and announced in a single transaction, a change claimed or
+// cancelled by somebody else in the meantime is left alone
+func (u *PriceUsecase) ApplyDue(c context.Context, now time.Time) (applied int, err error) {
+	if err = domain.Authorize(c, domain.PermissionProductWrite); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	due, err := u.priceRepo.FetchDue(ctx, now, dueBatchSize)
+	if err != nil {
+		return
+	}
+	for _, s := range due {
+		err = u.apply(ctx, s)
+		if errors.Is(err, domain.ErrConflict) {
+			continue
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			// the product is gone, the change never will be applied
+			err = u.priceRepo.UpdateScheduledStatus(ctx, s.ID, domain.ScheduledPricePending, domain.ScheduledPriceFailed)
+			if err != nil && !errors.Is(err, domain.ErrConflict) {
+				return
+			}
+			continue
+		}
+		if err != nil {
+			return
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// apply claim the change and update the price of the product along with its
+// update event, the product read back in the same transaction
+func (u *PriceUsecase) apply(ctx context.Context, s domain.ScheduledPriceChange) error {
+	return u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := u.priceRepo.UpdateScheduledStatus(ctx, s.ID, domain.ScheduledPricePending, domain.ScheduledPriceApplied)
+		if err != nil {
+			return err
+		}
+		if err = u.productRepo.UpdatePrice(ctx, s.ProductID, s.Price); err != nil {
+			return err
+		}
+		product, err := u.productRepo.GetByID(ctx, s.ProductID)
+		if err != nil {
+			return err
+		}
+		product.Thumbnails = domain.CoverThumbnails(product.Image)
+		e, err := domain.NewEvent(domain.EventProductUpdated, domain.EntityProduct, strconv.Itoa(s.ProductID), product)
+		if err != nil {
+			return err
+		}
+		return u.events.Emit(ctx, e)
+	})
+}
@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// PriceScheduler apply the scheduled price changes as they become effective
+type PriceScheduler struct {
+	usecase  domain.PriceUsecase
+	interval time.Duration
+}
+
+// NewPriceScheduler will create a scheduler looking for due changes every interval
+func NewPriceScheduler(u domain.PriceUsecase, interval time.Duration) *PriceScheduler {
+	return &PriceScheduler{
+		usecase:  u,
+		interval: interval,
+	}
+}
+
+// Run apply the due changes until ctx is done, the changes are made by the
+// system principal so that the price history tells them apart
+func (s *PriceScheduler) Run(ctx context.Context) {
+	ctx = domain.NewContextWithPrincipal(ctx, domain.SystemPrincipal("price-scheduler"))
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		applied, err := s.usecase.ApplyDue(ctx, time.Now())
+		if err != nil {
+			logrus.Error(err)
+		} else if applied > 0 {
+			logrus.Infof("applied %d scheduled price changes", applied)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
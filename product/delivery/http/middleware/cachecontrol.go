@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CacheControlConfig represent the settings of the caching headers middleware
+type CacheControlConfig struct {
+	// Default is the Cache-Control of the routes without a policy, e.g. "no-store"
+	Default string
+	// Routes set the Cache-Control of a route, keyed by method and route path, e.g. "GET /product"
+	Routes map[string]string
+	// Vary list the request headers the cacheable responses depend on
+	Vary []string
+}
+
+// CacheControl will set the Cache-Control policy of the route on its responses,
+// error responses are never cached whatever the policy of the route
+func (m *GoMiddleware) CacheControl(cfg CacheControlConfig) echo.MiddlewareFunc {
+	vary := strings.Join(cfg.Vary, ", ")
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			method := req.Method
+			if method == http.MethodHead {
+				method = http.MethodGet
+			}
+			policy, ok := cfg.Routes[method+" "+c.Path()]
+			if !ok {
+				policy = cfg.Default
+			}
+			if policy == "" {
+				return next(c)
+			}
+
+			res := c.Response()
+			res.Header().Set("Cache-Control", policy)
+			if ok && vary != "" {
+				res.Header().Add(echo.HeaderVary, vary)
+			}
+			res.Writer = &cacheControlWriter{ResponseWriter: res.Writer}
+			return next(c)
+		}
+	}
+}
+
+// cacheControlWriter turn the policy of an error response into no-store
+type cacheControlWriter struct {
+	http.ResponseWriter
+}
+
+func (w *cacheControlWriter) WriteHeader(code int) {
+	if code >= http.StatusBadRequest {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Flush let the streamed responses through the wrapped writer
+func (w *cacheControlWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack let the upgraded connections, such as websockets, take over the wrapped writer
+func (w *cacheControlWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	return h.Hijack()
+}
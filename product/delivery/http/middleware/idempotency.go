@@ -0,0 +1,231 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+)
+
+const (
+	// HeaderIdempotencyKey is the request header carrying the idempotency key
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed is set on the responses replayed from the store
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+	// maxIdempotencyKeyLength bound the size of the keys kept in the store
+	maxIdempotencyKeyLength = 255
+)
+
+// IdempotencyState is the state of a stored idempotency key
+type IdempotencyState int
+
+const (
+	// IdempotencyNew means the key was just reserved by the caller
+	IdempotencyNew IdempotencyState = iota
+	// IdempotencyInFlight means the first request of the key is still being processed
+	IdempotencyInFlight
+	// IdempotencyCompleted means the response of the key is stored and can be replayed
+	IdempotencyCompleted
+)
+
+// IdempotencyRecord is the stored outcome of the first request of a key
+type IdempotencyRecord struct {
+	State       IdempotencyState
+	Fingerprint string
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// IdempotencyStore keep the responses of the requests by idempotency key
+type IdempotencyStore interface {
+	// Reserve atomically reserve the key for a new request, or return the record already stored for it
+	Reserve(ctx context.Context, key string, fingerprint string, ttl time.Duration) (IdempotencyRecord, error)
+	// Complete store the response of the request which reserved the key
+	Complete(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) error
+	// Release forget the key so the request can be retried
+	Release(ctx context.Context, key string) error
+}
+
+// IdempotencyConfig represent the settings of the idempotency middleware
+type IdempotencyConfig struct {
+	Store IdempotencyStore
+	// TTL is how long a response is replayed for
+	TTL time.Duration
+	// MaxBodyBytes bound the bodies read to fingerprint the requests, the
+	// larger ones are rejected with 413. It defaults to 1MB
+	MaxBodyBytes int64
+	// Exclude lists the routes, as "METHOD path", whose responses must not be
+	// kept because they carry a secret, their Idempotency-Key is ignored
+	Exclude map[string]bool
+}
+
+// Idempotency will replay the stored response of a POST request retried with
+// the same Idempotency-Key, a key reused with another payload is rejected
+// with 422 and a retry arriving while the first request is in flight with 409.
+// It runs after Authenticate, the keys are kept per principal and per client
+// IP for the anonymous requests
+func (m *GoMiddleware) Idempotency(cfg IdempotencyConfig) echo.MiddlewareFunc {
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = 1 << 20
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			idempotencyKey := req.Header.Get(HeaderIdempotencyKey)
+			if req.Method != http.MethodPost || idempotencyKey == "" || cfg.Exclude[req.Method+" "+c.Path()] {
+				return next(c)
+			}
+			if len(idempotencyKey) > maxIdempotencyKeyLength {
+				return c.JSON(http.StatusBadRequest, response.ResponseFailed{Success: false, Message: "idempotency key is too long"})
+			}
+
+			body, err := ioutil.ReadAll(http.MaxBytesReader(c.Response(), req.Body, cfg.MaxBodyBytes))
+			if err != nil {
+				if int64(len(body)) >= cfg.MaxBodyBytes {
+					return c.JSON(http.StatusRequestEntityTooLarge, response.ResponseFailed{Success: false, Message: "request body is too large"})
+				}
+				return c.JSON(http.StatusBadRequest, response.ResponseFailed{Success: false, Message: err.Error()})
+			}
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+			sum := sha256.New()
+			sum.Write([]byte(req.Method + " " + req.URL.Path + "\n"))
+			sum.Write(body)
+			fingerprint := hex.EncodeToString(sum.Sum(nil))
+
+			ctx := req.Context()
+			key := clientKey(c) + "|" + idempotencyKey
+			rec, err := cfg.Store.Reserve(ctx, key, fingerprint, cfg.TTL)
+			if err != nil {
+				logrus.Error(err)
+				return next(c)
+			}
+			if rec.State != IdempotencyNew && rec.Fingerprint != fingerprint {
+				return c.JSON(http.StatusUnprocessableEntity, response.ResponseFailed{Success: false, Message: "idempotency key was already used with another payload"})
+			}
+			switch rec.State {
+			case IdempotencyInFlight:
+				c.Response().Header().Set("Retry-After", "1")
+				return c.JSON(http.StatusConflict, response.ResponseFailed{Success: false, Message: "a request with the same idempotency key is in progress"})
+			case IdempotencyCompleted:
+				c.Response().Header().Set(HeaderIdempotentReplayed, "true")
+				return c.Blob(rec.Status, rec.ContentType, rec.Body)
+			}
+
+			// the key is released unless the response is stored, a panicking
+			// handler included, so that the client can retry
+			stored := false
+			defer func() {
+				r := recover()
+				if !stored {
+					if errRelease := cfg.Store.Release(context.Background(), key); errRelease != nil {
+						logrus.Error(errRelease)
+					}
+				}
+				if r != nil {
+					panic(r)
+				}
+			}()
+
+			res := c.Response()
+			recorder := &responseRecorder{ResponseWriter: res.Writer}
+			res.Writer = recorder
+			err = next(c)
+			res.Writer = recorder.ResponseWriter
+
+			// errors and server failures are not stored so that the client can retry them
+			if err != nil || res.Status >= http.StatusInternalServerError {
+				return err
+			}
+			errComplete := cfg.Store.Complete(context.Background(), key, IdempotencyRecord{
+				State:       IdempotencyCompleted,
+				Fingerprint: fingerprint,
+				Status:      res.Status,
+				ContentType: res.Header().Get(echo.HeaderContentType),
+				Body:        recorder.body.Bytes(),
+			}, cfg.TTL)
+			if errComplete != nil {
+				logrus.Error(errComplete)
+				return nil
+			}
+			stored = true
+			return nil
+		}
+	}
+}
+
+// responseRecorder copy the response body while it is written to the client
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// memoryIdempotencyStore keep the records in process, it suits a single instance deployment
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]memoryIdempotencyRecord
+	sweptAt time.Time
+}
+
+type memoryIdempotencyRecord struct {
+	IdempotencyRecord
+	expiresAt time.Time
+}
+
+// NewMemoryIdempotencyStore will create an in memory IdempotencyStore
+func NewMemoryIdempotencyStore() IdempotencyStore {
+	return &memoryIdempotencyStore{
+		records: map[string]memoryIdempotencyRecord{},
+		sweptAt: time.Now(),
+	}
+}
+
+func (s *memoryIdempotencyStore) Reserve(ctx context.Context, key string, fingerprint string, ttl time.Duration) (IdempotencyRecord, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.sweptAt) >= time.Minute {
+		for k, rec := range s.records {
+			if now.After(rec.expiresAt) {
+				delete(s.records, k)
+			}
+		}
+		s.sweptAt = now
+	}
+	if rec, ok := s.records[key]; ok && now.Before(rec.expiresAt) {
+		return rec.IdempotencyRecord, nil
+	}
+	s.records[key] = memoryIdempotencyRecord{
+		IdempotencyRecord: IdempotencyRecord{State: IdempotencyInFlight, Fingerprint: fingerprint},
+		expiresAt:         now.Add(ttl),
+	}
+	return IdempotencyRecord{State: IdempotencyNew, Fingerprint: fingerprint}, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, key string, rec IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = memoryIdempotencyRecord{IdempotencyRecord: rec, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// idempotencyRequest is a request sent to the idempotent routes
+type idempotencyRequest struct {
+	path string
+	key  string
+	body string
+	ip   string
+}
+
+func (r idempotencyRequest) serve(e *echo.Echo) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, r.path, strings.NewReader(r.body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if r.key != "" {
+		req.Header.Set(HeaderIdempotencyKey, r.key)
+	}
+	req.RemoteAddr = r.ip + ":1234"
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+// newIdempotentEcho will create an echo serving POST /order, which answers
+// with the number of orders it has created, POST /secret, which does the same
+// on a route excluded from the middleware, and POST /fail, which fails with
+// 500, behind the idempotency middleware
+func newIdempotentEcho(calls *int) *echo.Echo {
+	idempotency := InitMiddleware().Idempotency(IdempotencyConfig{
+		Store:   NewMemoryIdempotencyStore(),
+		TTL:     time.Hour,
+		Exclude: map[string]bool{"POST /secret": true},
+	})
+	e := echo.New()
+	e.POST("/order", func(c echo.Context) error {
+		*calls++
+		return c.JSON(http.StatusCreated, map[string]int{"id": *calls})
+	}, idempotency)
+	e.POST("/secret", func(c echo.Context) error {
+		*calls++
+		return c.JSON(http.StatusCreated, map[string]int{"id": *calls})
+	}, idempotency)
+	e.POST("/fail", func(c echo.Context) error {
+		*calls++
+		return c.NoContent(http.StatusInternalServerError)
+	}, idempotency)
+	return e
+}
+
+func TestIdempotency(t *testing.T) {
+	first := idempotencyRequest{path: "/order", key: "key-1", body: `{"product_id":1}`, ip: "192.0.2.1"}
+	tests := []struct {
+		name  string
+		retry idempotencyRequest
+		// wantStatus and wantBody describe the response of the retry, wantCalls
+		// is the number of times the handler ran for both requests
+		wantStatus   int
+		wantBody     string
+		wantReplayed bool
+		wantCalls    int
+	}{
+		{
+			name:         "retry is replayed",
+			retry:        first,
+			wantStatus:   http.StatusCreated,
+			wantBody:     `{"id":1}`,
+			wantReplayed: true,
+			wantCalls:    1,
+		},
+		{
+			name:       "key reused with another payload",
+			retry:      idempotencyRequest{path: "/order", key: "key-1", body: `{"product_id":2}`, ip: "192.0.2.1"},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCalls:  1,
+		},
+		{
+			name:       "another key",
+			retry:      idempotencyRequest{path: "/order", key: "key-2", body: `{"product_id":1}`, ip: "192.0.2.1"},
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"id":2}`,
+			wantCalls:  2,
+		},
+		{
+			name:       "same key from another client",
+			retry:      idempotencyRequest{path: "/order", key: "key-1", body: `{"product_id":1}`, ip: "192.0.2.2"},
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"id":2}`,
+			wantCalls:  2,
+		},
+		{
+			name:       "without a key",
+			retry:      idempotencyRequest{path: "/order", body: `{"product_id":1}`, ip: "192.0.2.1"},
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"id":2}`,
+			wantCalls:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			e := newIdempotentEcho(&calls)
+			if rec := first.serve(e); rec.Code != http.StatusCreated {
+				t.Fatalf("first status = %d, want %d", rec.Code, http.StatusCreated)
+			}
+			rec := tt.retry.serve(e)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && strings.TrimSpace(rec.Body.String()) != tt.wantBody {
+				t.Fatalf("body = %s, want %s", rec.Body.String(), tt.wantBody)
+			}
+			if replayed := rec.Header().Get(HeaderIdempotentReplayed) == "true"; replayed != tt.wantReplayed {
+				t.Fatalf("replayed = %v, want %v", replayed, tt.wantReplayed)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyNotStored(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "server failure", path: "/fail"},
+		{name: "excluded route", path: "/secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			e := newIdempotentEcho(&calls)
+			r := idempotencyRequest{path: tt.path, key: "key-1", body: `{}`, ip: "192.0.2.1"}
+			for i := 1; i <= 2; i++ {
+				rec := r.serve(e)
+				if rec.Header().Get(HeaderIdempotentReplayed) != "" {
+					t.Fatalf("request %d was replayed", i)
+				}
+				if calls != i {
+					t.Fatalf("calls = %d, want %d", calls, i)
+				}
+			}
+		})
+	}
+}
+
+func TestIdempotencyInFlight(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	idempotency := InitMiddleware().Idempotency(IdempotencyConfig{Store: NewMemoryIdempotencyStore(), TTL: time.Hour})
+	e := echo.New()
+	calls := 0
+	e.POST("/order", func(c echo.Context) error {
+		calls++
+		close(started)
+		<-release
+		return c.JSON(http.StatusCreated, map[string]int{"id": calls})
+	}, idempotency)
+
+	r := idempotencyRequest{path: "/order", key: "key-1", body: `{"product_id":1}`, ip: "192.0.2.1"}
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- r.serve(e) }()
+	<-started
+
+	rec := r.serve(e)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status in flight = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Fatalf("Retry-After = %q, want %q", got, "1")
+	}
+	close(release)
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Fatalf("first status = %d, want %d", first.Code, http.StatusCreated)
+	}
+
+	// once the first request is done the retry is replayed
+	rec = r.serve(e)
+	if rec.Code != http.StatusCreated || rec.Header().Get(HeaderIdempotentReplayed) != "true" {
+		t.Fatalf("status after completion = %d, replayed = %q", rec.Code, rec.Header().Get(HeaderIdempotentReplayed))
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
+
+func TestIdempotencyKeyTooLong(t *testing.T) {
+	calls := 0
+	e := newIdempotentEcho(&calls)
+	rec := idempotencyRequest{path: "/order", key: strings.Repeat("k", maxIdempotencyKeyLength+1), body: `{}`, ip: "192.0.2.1"}.serve(e)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if calls != 0 {
+		t.Fatalf("calls = %d, want 0", calls)
+	}
+}
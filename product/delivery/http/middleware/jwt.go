@@ -0,0 +1,294 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+const (
+	// jwksCheckInterval is how often the JWKS file is checked for a rotation
+	jwksCheckInterval = 30 * time.Second
+	// jwksForcedCheckInterval bound how often an unknown kid may trigger a check of the JWKS file
+	jwksForcedCheckInterval = time.Second
+)
+
+// JWTConfig represent the settings to validate JWTs
+type JWTConfig struct {
+	// Issuer is the expected iss claim, empty means any issuer is accepted
+	Issuer string
+	// Audience is the expected aud claim, empty means any audience is accepted
+	Audience string
+	// Secret is the HS256 key used for tokens without a kid header
+	Secret string
+	// JWKSFile is the path of a JSON Web Key Set, it is reloaded when the file changes
+	JWKSFile string
+	// Leeway is the clock skew tolerated on exp and nbf
+	Leeway time.Duration
+}
+
+// JWTVerifier validate HS256 and RS256 JWTs into a domain.Principal
+type JWTVerifier struct {
+	cfg JWTConfig
+
+	mu        sync.RWMutex
+	keys      map[string]interface{}
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Issuer    string          `json:"iss"`
+	Subject   string          `json:"sub"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *int64          `json:"exp"`
+	NotBefore *int64          `json:"nbf"`
+	Roles     []string        `json:"roles"`
+	Scope     string          `json:"scope"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+// NewJWTVerifier will create a verifier, at least a secret or a JWKS file must be configured
+func NewJWTVerifier(cfg JWTConfig) (*JWTVerifier, error) {
+	if cfg.Secret == "" && cfg.JWKSFile == "" {
+		return nil, errors.New("jwt: either a secret or a JWKS file is required")
+	}
+	v := &JWTVerifier{cfg: cfg, keys: map[string]interface{}{}}
+	if cfg.JWKSFile != "" {
+		if err := v.reload(true); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// reload read the JWKS file again when it changed since the last load
+func (v *JWTVerifier) reload(force bool) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	interval := jwksCheckInterval
+	if force {
+		interval = jwksForcedCheckInterval
+	}
+	if time.Since(v.checkedAt) < interval {
+		return nil
+	}
+	v.checkedAt = time.Now()
+
+	info, err := os.Stat(v.cfg.JWKSFile)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(v.modTime) {
+		return nil
+	}
+	raw, err := ioutil.ReadFile(v.cfg.JWKSFile)
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(raw)
+	if err != nil {
+		return err
+	}
+	v.keys = keys
+	v.modTime = info.ModTime()
+	return nil
+}
+
+func parseJWKS(raw []byte) (map[string]interface{}, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("jwt: invalid JWKS: %w", err)
+	}
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, k := range set.Keys {
+		switch k.Kty {
+		case "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(k.N)
+			if err != nil {
+				return nil, fmt.Errorf("jwt: invalid modulus of key %q: %w", k.Kid, err)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(k.E)
+			if err != nil {
+				return nil, fmt.Errorf("jwt: invalid exponent of key %q: %w", k.Kid, err)
+			}
+			keys[k.Kid] = &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(k.K)
+			if err != nil {
+				return nil, fmt.Errorf("jwt: invalid secret of key %q: %w", k.Kid, err)
+			}
+			keys[k.Kid] = secret
+		}
+	}
+	return keys, nil
+}
+
+// key find the key of the given kid, an unknown kid trigger a check of the JWKS file
+func (v *JWTVerifier) key(kid string) (interface{}, bool) {
+	if v.cfg.JWKSFile == "" {
+		return nil, false
+	}
+	if err := v.reload(false); err != nil {
+		return nil, false
+	}
+	v.mu.RLock()
+	k, ok := v.keys[kid]
+	v.mu.RUnlock()
+	if ok {
+		return k, true
+	}
+	if err := v.reload(true); err != nil {
+		return nil, false
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	k, ok = v.keys[kid]
+	return k, ok
+}
+
+// Verify check the signature and the registered claims of the token
+func (v *JWTVerifier) Verify(token string) (p domain.Principal, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return p, domain.ErrUnauthorized
+	}
+	var header jwtHeader
+	if err = decodeSegment(parts[0], &header); err != nil {
+		return p, domain.ErrUnauthorized
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return p, domain.ErrUnauthorized
+	}
+
+	var key interface{}
+	if header.Kid != "" {
+		var ok bool
+		if key, ok = v.key(header.Kid); !ok {
+			return p, domain.ErrUnauthorized
+		}
+	} else if v.cfg.Secret != "" {
+		key = []byte(v.cfg.Secret)
+	} else {
+		return p, domain.ErrUnauthorized
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	digest := sha256.Sum256(signed)
+	switch header.Alg {
+	case "HS256":
+		secret, ok := key.([]byte)
+		if !ok {
+			return p, domain.ErrUnauthorized
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signed)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return p, domain.ErrUnauthorized
+		}
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return p, domain.ErrUnauthorized
+		}
+		if rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) != nil {
+			return p, domain.ErrUnauthorized
+		}
+	default:
+		return p, domain.ErrUnauthorized
+	}
+
+	var claims jwtClaims
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return p, domain.ErrUnauthorized
+	}
+	if err = v.validateClaims(&claims); err != nil {
+		return p, err
+	}
+
+	p = domain.Principal{
+		Subject:    claims.Subject,
+		Roles:      claims.Roles,
+		Scopes:     strings.Fields(claims.Scope),
+		AuthMethod: "jwt",
+	}
+	return p, nil
+}
+
+func (v *JWTVerifier) validateClaims(c *jwtClaims) error {
+	now := time.Now()
+	if c.Subject == "" {
+		return domain.ErrUnauthorized
+	}
+	if c.ExpiresAt == nil || now.After(time.Unix(*c.ExpiresAt, 0).Add(v.cfg.Leeway)) {
+		return domain.ErrUnauthorized
+	}
+	if c.NotBefore != nil && now.Before(time.Unix(*c.NotBefore, 0).Add(-v.cfg.Leeway)) {
+		return domain.ErrUnauthorized
+	}
+	if v.cfg.Issuer != "" && c.Issuer != v.cfg.Issuer {
+		return domain.ErrUnauthorized
+	}
+	if v.cfg.Audience != "" && !hasAudience(c.Audience, v.cfg.Audience) {
+		return domain.ErrUnauthorized
+	}
+	return nil
+}
+
+// hasAudience check the aud claim, which is either a single string or an array of strings
+func hasAudience(raw json.RawMessage, audience string) bool {
+	var single string
+	if json.Unmarshal(raw, &single) == nil {
+		return single == audience
+	}
+	var list []string
+	if json.Unmarshal(raw, &list) == nil {
+		for _, aud := range list {
+			if aud == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
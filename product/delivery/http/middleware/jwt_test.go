@@ -0,0 +1,242 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+const testSecret = "test-secret"
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func signHS256(secret []byte, signed string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, signed string) string {
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// writeJWKS write a key set holding the public key as "rsa-1" and the secret as "oct-1"
+func writeJWKS(t *testing.T, pub *rsa.PublicKey, secret []byte) string {
+	set := map[string]interface{}{
+		"keys": []jsonWebKey{
+			{
+				Kty: "RSA",
+				Kid: "rsa-1",
+				Alg: "RS256",
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			},
+			{Kty: "oct", Kid: "oct-1", Alg: "HS256", K: base64.RawURLEncoding.EncodeToString(secret)},
+		},
+	}
+	raw, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err = ioutil.WriteFile(path, raw, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestJWTVerifierVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+	octSecret := []byte("jwks-secret")
+
+	v, err := NewJWTVerifier(JWTConfig{
+		Issuer:   "bookhub",
+		Audience: "bookhub-api",
+		Secret:   testSecret,
+		JWKSFile: writeJWKS(t, &key.PublicKey, octSecret),
+		Leeway:   30 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().Unix()
+	claims := func(change func(c map[string]interface{})) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss":   "bookhub",
+			"sub":   "user:1",
+			"aud":   "bookhub-api",
+			"exp":   now + 60,
+			"roles": []string{"admin"},
+			"scope": "product:read product:write",
+		}
+		if change != nil {
+			change(c)
+		}
+		return c
+	}
+	// token build a token of the header and claims, sign turning its signed part into a signature
+	token := func(header map[string]interface{}, c map[string]interface{}, sign func(signed string) string) string {
+		signed := encodeSegment(t, header) + "." + encodeSegment(t, c)
+		return signed + "." + sign(signed)
+	}
+	hs256 := func(secret []byte) func(string) string {
+		return func(signed string) string { return signHS256(secret, signed) }
+	}
+	rs256 := func(signed string) string { return signRS256(t, key, signed) }
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{
+			name:  "HS256 with the secret",
+			token: token(map[string]interface{}{"alg": "HS256"}, claims(nil), hs256([]byte(testSecret))),
+			valid: true,
+		},
+		{
+			name:  "RS256 with a JWKS key",
+			token: token(map[string]interface{}{"alg": "RS256", "kid": "rsa-1"}, claims(nil), rs256),
+			valid: true,
+		},
+		{
+			name:  "HS256 with a JWKS secret",
+			token: token(map[string]interface{}{"alg": "HS256", "kid": "oct-1"}, claims(nil), hs256(octSecret)),
+			valid: true,
+		},
+		{
+			name:  "alg none",
+			token: token(map[string]interface{}{"alg": "none"}, claims(nil), func(string) string { return "" }),
+		},
+		{
+			name:  "alg none with a kid",
+			token: token(map[string]interface{}{"alg": "none", "kid": "rsa-1"}, claims(nil), func(string) string { return "" }),
+		},
+		{
+			name:  "HS256 signed with the PEM of the RSA public key",
+			token: token(map[string]interface{}{"alg": "HS256", "kid": "rsa-1"}, claims(nil), hs256(pubPEM)),
+		},
+		{
+			name:  "HS256 signed with the DER of the RSA public key",
+			token: token(map[string]interface{}{"alg": "HS256", "kid": "rsa-1"}, claims(nil), hs256(pubDER)),
+		},
+		{
+			name:  "RS256 claimed for the secret",
+			token: token(map[string]interface{}{"alg": "RS256"}, claims(nil), rs256),
+		},
+		{
+			name:  "unsupported alg",
+			token: token(map[string]interface{}{"alg": "HS512"}, claims(nil), hs256([]byte(testSecret))),
+		},
+		{
+			name:  "wrong secret",
+			token: token(map[string]interface{}{"alg": "HS256"}, claims(nil), hs256([]byte("other"))),
+		},
+		{
+			name: "tampered claims",
+			token: func() string {
+				signed := encodeSegment(t, map[string]interface{}{"alg": "HS256"}) + "." + encodeSegment(t, claims(nil))
+				forged := encodeSegment(t, claims(func(c map[string]interface{}) { c["sub"] = "user:2" }))
+				return encodeSegment(t, map[string]interface{}{"alg": "HS256"}) + "." + forged + "." + signHS256([]byte(testSecret), signed)
+			}(),
+		},
+		{
+			name:  "unknown kid",
+			token: token(map[string]interface{}{"alg": "RS256", "kid": "rsa-2"}, claims(nil), rs256),
+		},
+		{
+			name:  "expired",
+			token: token(map[string]interface{}{"alg": "HS256"}, claims(func(c map[string]interface{}) { c["exp"] = now - 60 }), hs256([]byte(testSecret))),
+		},
+		{
+			name:  "expired within the leeway",
+			token: token(map[string]interface{}{"alg": "HS256"}, claims(func(c map[string]interface{}) { c["exp"] = now - 10 }), hs256([]byte(testSecret))),
+			valid: true,
+		},
+		{
+			name:  "without exp",
+			token: token(map[string]interface{}{"alg": "HS256"}, claims(func(c map[string]interface{}) { delete(c, "exp") }), hs256([]byte(testSecret))),
+		},
+		{
+			name:  "not yet valid",
+			token: token(map[string]interface{}{"alg": "HS256"}, claims(func(c map[string]interface{}) { c["nbf"] = now + 60 }), hs256([]byte(testSecret))),
+		},
+		{
+			name:  "not yet valid within the leeway",
+			token: token(map[string]interface{}{"alg": "HS256"}, claims(func(c map[string]interface{}) { c["nbf"] = now + 10 }), hs256([]byte(testSecret))),
+			valid: true,
+		},
+		{
+			name:  "wrong issuer",
+			token: token(map[string]interface{}{"alg": "HS256"}, claims(func(c map[string]interface{}) { c["iss"] = "other" }), hs256([]byte(testSecret))),
+		},
+		{
+			name:  "audience in a list",
+			token: token(map[string]interface{}{"alg": "HS256"}, claims(func(c map[string]interface{}) { c["aud"] = []string{"other", "bookhub-api"} }), hs256([]byte(testSecret))),
+			valid: true,
+		},
+		{
+			name:  "wrong audience",
+			token: token(map[string]interface{}{"alg": "HS256"}, claims(func(c map[string]interface{}) { c["aud"] = "other" }), hs256([]byte(testSecret))),
+		},
+		{
+			name:  "without subject",
+			token: token(map[string]interface{}{"alg": "HS256"}, claims(func(c map[string]interface{}) { delete(c, "sub") }), hs256([]byte(testSecret))),
+		},
+		{
+			name:  "malformed",
+			token: "not.a.token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := v.Verify(tt.token)
+			if !tt.valid {
+				if !errors.Is(err, domain.ErrUnauthorized) {
+					t.Fatalf("Verify() error = %v, want %v", err, domain.ErrUnauthorized)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if p.Subject != "user:1" || p.AuthMethod != "jwt" || len(p.Scopes) != 2 {
+				t.Fatalf("Verify() = %+v", p)
+			}
+		})
+	}
+}
@@ -1,10 +1,47 @@
 package middleware
 
-import "github.com/labstack/echo/v4"
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+)
 
 // GoMiddleware represent the data-struct for middleware
 type GoMiddleware struct {
-	// another stuff , may be needed by middleware
+	jwt      *JWTVerifier
+	sessions domain.UserUsecase
+	apiKeys  domain.APIKeyUsecase
+}
+
+// Option configure the optional dependencies of GoMiddleware
+type Option func(*GoMiddleware)
+
+// WithJWTVerifier enable authentication with JWT bearer tokens
+func WithJWTVerifier(v *JWTVerifier) Option {
+	return func(m *GoMiddleware) {
+		m.jwt = v
+	}
+}
+
+// WithSessionAuthenticator enable authentication with the opaque session tokens issued at login
+func WithSessionAuthenticator(u domain.UserUsecase) Option {
+	return func(m *GoMiddleware) {
+		m.sessions = u
+	}
+}
+
+// WithAPIKeyAuthenticator enable authentication with "Authorization: ApiKey <key>" headers
+func WithAPIKeyAuthenticator(k domain.APIKeyUsecase) Option {
+	return func(m *GoMiddleware) {
+		m.apiKeys = k
+	}
 }
 
 // CORS will handle the CORS middleware
@@ -15,7 +52,140 @@ func (m *GoMiddleware) CORS(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+// authErrorKey is the echo context key of the error of the credentials Authenticate rejected
+const authErrorKey = "auth_error"
+
+// Authenticate will resolve the credentials of the request once, for the
+// middlewares and the routes after it: the principal is attached to the
+// request context, invalid credentials are left for Auth to reject.
+// Anonymous requests go through untouched
+func (m *GoMiddleware) Authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		header := req.Header.Get(echo.HeaderAuthorization)
+		if header == "" {
+			return next(c)
+		}
+		principal, err := m.authenticate(req.Context(), header)
+		if err != nil {
+			c.Set(authErrorKey, err)
+			return next(c)
+		}
+		c.SetRequest(req.WithContext(domain.NewContextWithPrincipal(req.Context(), principal)))
+		return next(c)
+	}
+}
+
+// Auth will reject the request unless it carries valid credentials, the
+// authenticated principal is attached to the request context. The outcome of
+// Authenticate is reused when it ran before
+func (m *GoMiddleware) Auth(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		if _, ok := domain.PrincipalFromContext(req.Context()); ok {
+			return next(c)
+		}
+		err, _ := c.Get(authErrorKey).(error)
+		var principal domain.Principal
+		if err == nil {
+			principal, err = m.authenticate(req.Context(), req.Header.Get(echo.HeaderAuthorization))
+		}
+		if err != nil {
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="bookhub-api"`)
+			if m.apiKeys != nil {
+				c.Response().Header().Add(echo.HeaderWWWAuthenticate, `ApiKey realm="bookhub-api"`)
+			}
+			// the cause is for the logs, the client is not told why its credentials failed
+			logrus.WithField("request_id", domain.RequestIDFromContext(req.Context())).Info(err)
+			return c.JSON(http.StatusUnauthorized, response.ResponseFailed{Success: false, Message: "unauthorized"})
+		}
+		c.SetRequest(req.WithContext(domain.NewContextWithPrincipal(req.Context(), principal)))
+		return next(c)
+	}
+}
+
+// RequirePermission will reject the requests of the principals not granted
+// perm before they reach the route, it runs after Auth
+func (m *GoMiddleware) RequirePermission(perm domain.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := domain.Authorize(c.Request().Context(), perm)
+			if errors.Is(err, domain.ErrUnauthorized) {
+				return c.JSON(http.StatusUnauthorized, response.ResponseFailed{Success: false, Message: "unauthorized"})
+			}
+			if err != nil {
+				c.Response().Header().Set(echo.HeaderContentType, response.ProblemContentType)
+				return c.JSON(http.StatusForbidden, response.NewProblem(http.StatusForbidden, err.Error()))
+			}
+			return next(c)
+		}
+	}
+}
+
+// OptionalAuth will attach the authenticated principal to the request context
+// when the request carries credentials, anonymous requests go through. Invalid
+// credentials are still rejected so that the client notices them
+func (m *GoMiddleware) OptionalAuth(next echo.HandlerFunc) echo.HandlerFunc {
+	auth := m.Auth(next)
+	return func(c echo.Context) error {
+		if c.Request().Header.Get(echo.HeaderAuthorization) == "" {
+			return next(c)
+		}
+		return auth(c)
+	}
+}
+
+// authenticate resolve the Authorization header into a principal
+func (m *GoMiddleware) authenticate(ctx context.Context, header string) (p domain.Principal, err error) {
+	scheme, credentials := splitAuthorization(header)
+	if credentials == "" {
+		return p, domain.ErrUnauthorized
+	}
+	if strings.EqualFold(scheme, "ApiKey") {
+		if m.apiKeys == nil {
+			return p, domain.ErrUnauthorized
+		}
+		return m.apiKeys.Authenticate(ctx, credentials)
+	}
+	if !strings.EqualFold(scheme, "Bearer") {
+		return p, domain.ErrUnauthorized
+	}
+	if strings.Count(credentials, ".") == 2 {
+		if m.jwt == nil {
+			return p, domain.ErrUnauthorized
+		}
+		return m.jwt.Verify(credentials)
+	}
+	if m.sessions == nil {
+		return p, domain.ErrUnauthorized
+	}
+	user, err := m.sessions.Authenticate(ctx, credentials)
+	if err != nil {
+		return p, domain.ErrUnauthorized
+	}
+	p = domain.Principal{
+		Subject:    "user:" + strconv.Itoa(user.ID),
+		Roles:      []string{user.Role},
+		AuthMethod: "session",
+	}
+	return p, nil
+}
+
+// splitAuthorization split an Authorization header into its scheme and credentials
+func splitAuthorization(header string) (scheme string, credentials string) {
+	header = strings.TrimSpace(header)
+	i := strings.IndexByte(header, ' ')
+	if i < 0 {
+		return header, ""
+	}
+	return header[:i], strings.TrimSpace(header[i+1:])
+}
+
 // InitMiddleware initialize the middleware
-func InitMiddleware() *GoMiddleware {
-	return &GoMiddleware{}
+func InitMiddleware(opts ...Option) *GoMiddleware {
+	m := &GoMiddleware{}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
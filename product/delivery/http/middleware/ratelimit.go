@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+)
+
+// RateLimitPolicy is a token bucket holding up to Burst requests and refilled with Rate requests per second
+type RateLimitPolicy struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitResult is the outcome of taking a token from a bucket
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+// RateLimitStore keep the token buckets of the clients
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error)
+}
+
+// RateLimitConfig represent the settings of the rate limiting middleware
+type RateLimitConfig struct {
+	Store RateLimitStore
+	// Default is the policy of the routes without an override
+	Default RateLimitPolicy
+	// Routes override the default policy, keyed by method and route path, e.g. "POST /product"
+	Routes map[string]RateLimitPolicy
+}
+
+// RateLimit will reject the requests of a client exceeding its policy with
+// 429, clients are identified by their credentials or else by their IP. It
+// runs after Authenticate
+func (m *GoMiddleware) RateLimit(cfg RateLimitConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			route := req.Method + " " + c.Path()
+			policy, override := cfg.Routes[route]
+			if !override {
+				policy = cfg.Default
+			}
+			key := clientKey(c)
+			if override {
+				key += "|" + route
+			}
+
+			res, err := cfg.Store.Take(req.Context(), key, policy)
+			if err != nil {
+				// a broken store must not take the API down
+				logrus.Error(err)
+				return next(c)
+			}
+			header := c.Response().Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(policy.Burst))
+			header.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+			if !res.Allowed {
+				header.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				return c.JSON(http.StatusTooManyRequests, response.ResponseFailed{Success: false, Message: "too many requests"})
+			}
+			return next(c)
+		}
+	}
+}
+
+// clientKey identify the caller, the principals authenticated by
+// Authenticate share a bucket whatever the address they come from, anything
+// else is limited per IP as told by the IP extractor of echo
+func clientKey(c echo.Context) string {
+	if p, ok := domain.PrincipalFromContext(c.Request().Context()); ok {
+		sum := sha256.Sum256([]byte(p.Subject))
+		return "principal:" + hex.EncodeToString(sum[:8])
+	}
+	return "ip:" + c.RealIP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// memoryRateLimitStore keep the buckets in process, it suits a single instance deployment
+type memoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	sweptAt   time.Time
+	sweepEach time.Duration
+}
+
+type bucket struct {
+	tokens   float64
+	policy   RateLimitPolicy
+	updateAt time.Time
+}
+
+// NewMemoryRateLimitStore will create an in memory RateLimitStore
+func NewMemoryRateLimitStore() RateLimitStore {
+	return &memoryRateLimitStore{
+		buckets:   map[string]*bucket{},
+		sweptAt:   time.Now(),
+		sweepEach: time.Minute,
+	}
+}
+
+func (s *memoryRateLimitStore) Take(ctx context.Context, key string, policy RateLimitPolicy) (res RateLimitResult, err error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.sweptAt) >= s.sweepEach {
+		s.sweep(now)
+	}
+	b, ok := s.buckets[key]
+	if !ok || b.policy != policy {
+		b = &bucket{tokens: float64(policy.Burst), policy: policy, updateAt: now}
+		s.buckets[key] = b
+	}
+	b.refill(now)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = b.timeUntil(1)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = b.timeUntil(float64(policy.Burst))
+	return res, nil
+}
+
+// sweep drop the buckets which are full again, they are the same as a new bucket
+func (s *memoryRateLimitStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.policy.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+	s.sweptAt = now
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updateAt).Seconds()
+	b.tokens = math.Min(float64(b.policy.Burst), b.tokens+elapsed*b.policy.Rate)
+	b.updateAt = now
+}
+
+// timeUntil is how long it takes for the bucket to hold the given number of tokens
+func (b *bucket) timeUntil(tokens float64) time.Duration {
+	missing := tokens - b.tokens
+	if missing <= 0 || b.policy.Rate <= 0 {
+		return 0
+	}
+	return time.Duration(missing / b.policy.Rate * float64(time.Second))
+}
@@ -0,0 +1,240 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestBucketRefill(t *testing.T) {
+	start := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		tokens  float64
+		policy  RateLimitPolicy
+		elapsed time.Duration
+		want    float64
+	}{
+		{name: "no time elapsed", tokens: 1, policy: RateLimitPolicy{Rate: 2, Burst: 5}, want: 1},
+		{name: "refilled at the rate", tokens: 1, policy: RateLimitPolicy{Rate: 2, Burst: 5}, elapsed: time.Second, want: 3},
+		{name: "refilled by a fraction", tokens: 0, policy: RateLimitPolicy{Rate: 2, Burst: 5}, elapsed: 250 * time.Millisecond, want: 0.5},
+		{name: "capped at the burst", tokens: 4, policy: RateLimitPolicy{Rate: 2, Burst: 5}, elapsed: time.Minute, want: 5},
+		{name: "no rate never refills", tokens: 0, policy: RateLimitPolicy{Burst: 5}, elapsed: time.Minute, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &bucket{tokens: tt.tokens, policy: tt.policy, updateAt: start}
+			b.refill(start.Add(tt.elapsed))
+			if b.tokens != tt.want {
+				t.Fatalf("refill() tokens = %v, want %v", b.tokens, tt.want)
+			}
+			if !b.updateAt.Equal(start.Add(tt.elapsed)) {
+				t.Fatalf("refill() updateAt = %v, want %v", b.updateAt, start.Add(tt.elapsed))
+			}
+		})
+	}
+}
+
+func TestBucketTimeUntil(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens float64
+		policy RateLimitPolicy
+		until  float64
+		want   time.Duration
+	}{
+		{name: "already there", tokens: 2, policy: RateLimitPolicy{Rate: 1, Burst: 5}, until: 1, want: 0},
+		{name: "one token", tokens: 0, policy: RateLimitPolicy{Rate: 2, Burst: 5}, until: 1, want: 500 * time.Millisecond},
+		{name: "part of a token", tokens: 0.5, policy: RateLimitPolicy{Rate: 1, Burst: 5}, until: 1, want: 500 * time.Millisecond},
+		{name: "full bucket", tokens: 1, policy: RateLimitPolicy{Rate: 2, Burst: 5}, until: 5, want: 2 * time.Second},
+		{name: "no rate", tokens: 0, policy: RateLimitPolicy{Burst: 5}, until: 1, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &bucket{tokens: tt.tokens, policy: tt.policy}
+			if got := b.timeUntil(tt.until); got != tt.want {
+				t.Fatalf("timeUntil() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryRateLimitStoreTake(t *testing.T) {
+	ctx := context.Background()
+	policy := RateLimitPolicy{Rate: 1, Burst: 3}
+	s := NewMemoryRateLimitStore().(*memoryRateLimitStore)
+
+	// the burst is allowed at once, then the bucket is empty
+	for want := 2; want >= 0; want-- {
+		res, err := s.Take(ctx, "ip:192.0.2.1", policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Allowed || res.Remaining != want {
+			t.Fatalf("Take() = %+v, want allowed with %d remaining", res, want)
+		}
+	}
+	res, err := s.Take(ctx, "ip:192.0.2.1", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Allowed || res.Remaining != 0 {
+		t.Fatalf("Take() = %+v, want denied", res)
+	}
+	if res.RetryAfter <= 900*time.Millisecond || res.RetryAfter > time.Second {
+		t.Fatalf("Take() RetryAfter = %v, want about 1s", res.RetryAfter)
+	}
+	if res.Reset <= 2900*time.Millisecond || res.Reset > 3*time.Second {
+		t.Fatalf("Take() Reset = %v, want about 3s", res.Reset)
+	}
+
+	// another client has a bucket of its own
+	res, err = s.Take(ctx, "ip:192.0.2.2", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Allowed || res.Remaining != 2 {
+		t.Fatalf("Take() of another client = %+v, want allowed with 2 remaining", res)
+	}
+
+	// a token and a half later a request goes through
+	b := s.buckets["ip:192.0.2.1"]
+	b.updateAt = b.updateAt.Add(-1500 * time.Millisecond)
+	res, err = s.Take(ctx, "ip:192.0.2.1", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("Take() after a refill = %+v, want allowed with 0 remaining", res)
+	}
+
+	// a long wait refills up to the burst only
+	b.updateAt = b.updateAt.Add(-time.Hour)
+	res, err = s.Take(ctx, "ip:192.0.2.1", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Allowed || res.Remaining != 2 {
+		t.Fatalf("Take() after a long wait = %+v, want allowed with 2 remaining", res)
+	}
+
+	// a new policy starts from a full bucket
+	res, err = s.Take(ctx, "ip:192.0.2.1", RateLimitPolicy{Rate: 1, Burst: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Allowed || res.Remaining != 9 {
+		t.Fatalf("Take() with a new policy = %+v, want allowed with 9 remaining", res)
+	}
+}
+
+// fakeRateLimitStore answer every Take with res and record the keys taken
+type fakeRateLimitStore struct {
+	res  RateLimitResult
+	err  error
+	keys []string
+}
+
+func (s *fakeRateLimitStore) Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	s.keys = append(s.keys, key)
+	return s.res, s.err
+}
+
+func TestRateLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		res        RateLimitResult
+		err        error
+		method     string
+		wantStatus int
+		wantKey    string
+		// wantHeader are the expected headers, an empty value means the header is not set
+		wantHeader map[string]string
+	}{
+		{
+			name:       "allowed",
+			res:        RateLimitResult{Allowed: true, Remaining: 4, Reset: 1500 * time.Millisecond},
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantKey:    "ip:192.0.2.1",
+			wantHeader: map[string]string{
+				"RateLimit-Limit":     "10",
+				"RateLimit-Remaining": "4",
+				"RateLimit-Reset":     "2",
+				"Retry-After":         "",
+			},
+		},
+		{
+			name:       "denied",
+			res:        RateLimitResult{RetryAfter: 1200 * time.Millisecond, Reset: 10 * time.Second},
+			method:     http.MethodGet,
+			wantStatus: http.StatusTooManyRequests,
+			wantKey:    "ip:192.0.2.1",
+			wantHeader: map[string]string{
+				"RateLimit-Limit":     "10",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "10",
+				"Retry-After":         "2",
+			},
+		},
+		{
+			name:       "route override",
+			res:        RateLimitResult{Allowed: true, Remaining: 1, Reset: time.Second},
+			method:     http.MethodPost,
+			wantStatus: http.StatusOK,
+			wantKey:    "ip:192.0.2.1|POST /product",
+			wantHeader: map[string]string{
+				"RateLimit-Limit":     "2",
+				"RateLimit-Remaining": "1",
+				"RateLimit-Reset":     "1",
+			},
+		},
+		{
+			name:       "broken store",
+			err:        errors.New("store is down"),
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantKey:    "ip:192.0.2.1",
+			wantHeader: map[string]string{
+				"RateLimit-Limit": "",
+				"Retry-After":     "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeRateLimitStore{res: tt.res, err: tt.err}
+			limit := InitMiddleware().RateLimit(RateLimitConfig{
+				Store:   store,
+				Default: RateLimitPolicy{Rate: 1, Burst: 10},
+				Routes:  map[string]RateLimitPolicy{"POST /product": {Rate: 0.1, Burst: 2}},
+			})
+			e := echo.New()
+			ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+			e.GET("/product", ok, limit)
+			e.POST("/product", ok, limit)
+
+			req := httptest.NewRequest(tt.method, "/product", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if len(store.keys) != 1 || store.keys[0] != tt.wantKey {
+				t.Fatalf("keys = %v, want [%s]", store.keys, tt.wantKey)
+			}
+			for name, want := range tt.wantHeader {
+				if got := rec.Header().Get(name); got != want {
+					t.Fatalf("header %s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
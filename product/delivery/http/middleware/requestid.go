@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/labstack/echo/v4"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// maxRequestIDLength bound the request ids accepted from the clients
+const maxRequestIDLength = 128
+
+// isRequestID report whether a client supplied id is short and printable
+func isRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestID will attach an id to every request context, the one of the
+// X-Request-ID header when valid or a random one, and echo it in the response
+func (m *GoMiddleware) RequestID(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		id := req.Header.Get(echo.HeaderXRequestID)
+		if !isRequestID(id) {
+			b := make([]byte, 16)
+			if _, err := rand.Read(b); err != nil {
+				return err
+			}
+			id = hex.EncodeToString(b)
+		}
+		c.Response().Header().Set(echo.HeaderXRequestID, id)
+		c.SetRequest(req.WithContext(domain.NewContextWithRequestID(req.Context(), id)))
+		return next(c)
+	}
+}
@@ -1,52 +1,132 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	validator "gopkg.in/go-playground/validator.v9"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/conditional"
+	"github.com/wdwiramadhan/bookhub-api/helper/negotiate"
 	"github.com/wdwiramadhan/bookhub-api/helper/response"
 )
 
-var successResponse response.ResponseSuccess = response.ResponseSuccess{Success: true, Data: nil}
-var failedResponse response.ResponseFailed = response.ResponseFailed{Success: false, Message: ""}
-
 // ProductHandler  represent the httphandler for product
 type ProductHandler struct {
 	PUsecase domain.ProductUseCase
+	CUsecase domain.CurrencyUsecase
 }
 
-// NewProductHandler will initialize the product/ resources endpoint
-func NewProductHandler(e *echo.Echo, us domain.ProductUseCase) {
+// NewProductHandler will initialize the product/ resources endpoint, prices
+// are converted with cu into the requested currency. The writes require their
+// permission on top of auth
+func NewProductHandler(e *echo.Echo, us domain.ProductUseCase, cu domain.CurrencyUsecase, auth echo.MiddlewareFunc, require func(domain.Permission) echo.MiddlewareFunc) {
 	handler := &ProductHandler{
 		PUsecase: us,
+		CUsecase: cu,
 	}
 	e.GET("/product", handler.FetchProduct)
-	e.POST("/product", handler.Store)
+	e.POST("/product", handler.Store, auth, require(domain.PermissionProductWrite))
 	e.GET("/product/:productId", handler.GetByID)
-	e.PUT("/product/:productId", handler.Update)
-	e.DELETE("/product/:productId", handler.Delete)
+	e.PUT("/product/:productId", handler.Update, auth, require(domain.PermissionProductWrite))
+	e.DELETE("/product/:productId", handler.Delete, auth, require(domain.PermissionProductDelete))
 }
 
-// FetchProduct will fetch the article based on given params
+// FetchProduct will fetch the products matching the author, category, price
+// and year query params along with the facet counts, every param may be
+// repeated or hold comma separated values. Prices are in the currency query
+// param or else the one negotiated with the Accept-Currency header
 func (p *ProductHandler) FetchProduct(c echo.Context) error {
+	filter := domain.ProductFilter{
+		Categories:   queryValues(c, "category"),
+		PriceBuckets: queryValues(c, "price"),
+	}
+	var err error
+	if filter.AuthorIDs, err = queryInts(c, "author"); err != nil {
+		return failed(c, domain.ErrBadParamInput)
+	}
+	if filter.Years, err = queryInts(c, "year"); err != nil {
+		return failed(c, domain.ErrBadParamInput)
+	}
 	ctx := c.Request().Context()
-	listProduct, err := p.PUsecase.Fetch(ctx)
+	listProduct, facets, err := p.PUsecase.FetchFaceted(ctx, filter)
 	if err != nil {
-		failedResponse.Message = err.Error()
-		return c.JSON(getStatusCode(err), failedResponse)
+		return failed(c, err)
+	}
+	rate, err := negotiate.Localize(c, p.CUsecase, listProduct)
+	if err != nil {
+		return failed(c, err)
+	}
+	// the facets of a filtered listing count products outside of the listing,
+	// only the whole catalog can be validated from the listed products
+	modified := conditional.Latest(lastModified(listProduct...), rate.UpdatedAt)
+	if isUnfiltered(filter) && conditional.NotModifiedVariant(c, modified, len(listProduct), variant(rate)) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.JSON(http.StatusOK, response.ResponseFaceted{Success: true, Data: listProduct, Facets: facets})
+}
+
+func isUnfiltered(filter domain.ProductFilter) bool {
+	return len(filter.AuthorIDs) == 0 && len(filter.Categories) == 0 && len(filter.PriceBuckets) == 0 && len(filter.Years) == 0
+}
+
+// lastModified return the latest modification of the products, including their embedded author
+func lastModified(products ...domain.Product) time.Time {
+	var latest time.Time
+	for _, p := range products {
+		latest = conditional.Latest(latest, p.UpdatedAt, p.Author.UpdatedAt)
+	}
+	return latest
+}
+
+// variant identify the representation priced with the rate, which changes
+// along with the currency and the version of its rate
+func variant(rate domain.ExchangeRate) string {
+	return fmt.Sprintf("%s.%x", rate.Currency, rate.UpdatedAt.Unix())
+}
+
+// inBaseCurrency report whether a product sent by a client is priced in the base currency,
+// it is when it does not tell
+func (p *ProductHandler) inBaseCurrency(product domain.Product) bool {
+	return product.Currency == "" || strings.EqualFold(product.Currency, p.CUsecase.Base())
+}
+
+// queryValues collect the values of a repeated or comma separated query param
+func queryValues(c echo.Context, name string) []string {
+	values := []string{}
+	for _, v := range c.QueryParams()[name] {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
 	}
-	successResponse.Data = listProduct
-	return c.JSON(http.StatusOK, successResponse)
+	return values
+}
+
+func queryInts(c echo.Context, name string) ([]int, error) {
+	values := queryValues(c, name)
+	ints := make([]int, len(values))
+	for i, v := range values {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+		ints[i] = n
+	}
+	return ints, nil
 }
 
 // Store will store the article by given request body
 func (p *ProductHandler) Store(c echo.Context) (err error) {
-	var product domain.Product
+	// a product is available unless the body says otherwise
+	product := domain.Product{Available: true}
 	err = c.Bind(&product)
 	if err != nil {
 		return c.JSON(http.StatusUnprocessableEntity, err.Error())
@@ -56,14 +136,16 @@ func (p *ProductHandler) Store(c echo.Context) (err error) {
 	if ok, err = isRequestValid(&product); !ok {
 		return c.JSON(http.StatusBadRequest, err.Error())
 	}
+	if !p.inBaseCurrency(product) {
+		return failed(c, domain.ErrBadParamInput)
+	}
 
 	ctx := c.Request().Context()
 	err = p.PUsecase.Store(ctx, &product)
 	if err != nil {
-		failedResponse.Message = err.Error()
-		return c.JSON(getStatusCode(err), failedResponse)
+		return failed(c, err)
 	}
-	return c.JSON(http.StatusCreated, successResponse)
+	return c.JSON(http.StatusCreated, response.ResponseSuccess{Success: true})
 }
 
 // GetByID will get product by given id
@@ -72,17 +154,25 @@ func (p *ProductHandler) GetByID(c echo.Context) error {
 	ctx := c.Request().Context()
 	product, err := p.PUsecase.GetByID(ctx, id)
 	if err != nil {
-		failedResponse.Message = err.Error()
-		return c.JSON(getStatusCode(err), failedResponse)
+		return failed(c, err)
 	}
-	successResponse.Data = product
-	return c.JSON(http.StatusOK, successResponse)
+	products := []domain.Product{product}
+	rate, err := negotiate.Localize(c, p.CUsecase, products)
+	if err != nil {
+		return failed(c, err)
+	}
+	product = products[0]
+	if conditional.NotModifiedVariant(c, conditional.Latest(lastModified(product), rate.UpdatedAt), 1, variant(rate)) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: product})
 }
 
 // Update will update the product by given request body and params id
 func (p *ProductHandler) Update(c echo.Context) (err error) {
 	id, _ := strconv.Atoi(c.Param("productId"))
-	var product domain.Product
+	// a product is available unless the body says otherwise
+	product := domain.Product{Available: true}
 	err = c.Bind(&product)
 	if err != nil {
 		return c.JSON(http.StatusUnprocessableEntity, err.Error())
@@ -91,14 +181,16 @@ func (p *ProductHandler) Update(c echo.Context) (err error) {
 	if ok, err = isRequestValid(&product); !ok {
 		return c.JSON(http.StatusBadRequest, err.Error())
 	}
+	if !p.inBaseCurrency(product) {
+		return failed(c, domain.ErrBadParamInput)
+	}
 	ctx := c.Request().Context()
 	err = p.PUsecase.Update(ctx, &product, id)
 	if err != nil {
-		failedResponse.Message = err.Error()
-		return c.JSON(getStatusCode(err), failedResponse)
+		return failed(c, err)
 	}
 
-	return c.JSON(http.StatusOK, successResponse)
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true})
 }
 
 // Delete will delete product by given param
@@ -107,10 +199,9 @@ func (p *ProductHandler) Delete(c echo.Context) (err error) {
 	ctx := c.Request().Context()
 	err = p.PUsecase.Delete(ctx, id)
 	if err != nil {
-		failedResponse.Message = err.Error()
-		return c.JSON(getStatusCode(err), failedResponse)
+		return failed(c, err)
 	}
-	return c.JSON(http.StatusOK, successResponse)
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true})
 }
 
 func isRequestValid(m *domain.Product) (bool, error) {
@@ -122,6 +213,16 @@ func isRequestValid(m *domain.Product) (bool, error) {
 	return true, nil
 }
 
+// failed write the error response of err, a denied permission is described with a problem detail
+func failed(c echo.Context, err error) error {
+	status := getStatusCode(err)
+	if status == http.StatusForbidden {
+		c.Response().Header().Set(echo.HeaderContentType, response.ProblemContentType)
+		return c.JSON(status, response.NewProblem(status, err.Error()))
+	}
+	return c.JSON(status, response.ResponseFailed{Success: false, Message: err.Error()})
+}
+
 func getStatusCode(err error) int {
 	if err == nil {
 		return http.StatusOK
@@ -135,6 +236,12 @@ func getStatusCode(err error) int {
 		return http.StatusNotFound
 	case domain.ErrConflict:
 		return http.StatusConflict
+	case domain.ErrBadParamInput:
+		return http.StatusBadRequest
+	case domain.ErrUnauthorized:
+		return http.StatusUnauthorized
+	case domain.ErrForbidden:
+		return http.StatusForbidden
 	default:
 		return http.StatusInternalServerError
 	}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/cache"
+	"github.com/wdwiramadhan/bookhub-api/helper/sqltx"
+)
+
+// CacheProductRepository serve the product lookups by id from an in-process
+// cache. It is a domain.CatalogListener so that the products embedding an
+// author are invalidated when the author changes
+type CacheProductRepository struct {
+	domain.ProductRepository
+	cache *cache.Cache
+}
+
+// NewCacheProductRepository will wrap a domain.ProductRepository with a read-through cache
+func NewCacheProductRepository(next domain.ProductRepository, c *cache.Cache) *CacheProductRepository {
+	return &CacheProductRepository{ProductRepository: next, cache: c}
+}
+
+func key(id int) string {
+	return strconv.Itoa(id)
+}
+
+// GetByID serve the product from the cache, unless in a transaction where the
+// product is read back from the database along with the uncommitted changes
+func (c *CacheProductRepository) GetByID(ctx context.Context, id int) (domain.Product, error) {
+	if _, ok := sqltx.Tx(ctx); ok {
+		return c.ProductRepository.GetByID(ctx, id)
+	}
+	v, err := c.cache.GetOrLoad(ctx, key(id), func(ctx context.Context) (interface{}, error) {
+		return c.ProductRepository.GetByID(ctx, id)
+	})
+	if err != nil {
+		return domain.Product{}, err
+	}
+	return v.(domain.Product), nil
+}
+
+func (c *CacheProductRepository) Update(ctx context.Context, p *domain.Product, id int) error {
+	defer c.cache.Delete(key(id))
+	return c.ProductRepository.Update(ctx, p, id)
+}
+
+func (c *CacheProductRepository) UpdateImage(ctx context.Context, id int, image string) error {
+	defer c.cache.Delete(key(id))
+	return c.ProductRepository.UpdateImage(ctx, id, image)
+}
+
+func (c *CacheProductRepository) UpdatePrice(ctx context.Context, id int, price int64) error {
+	defer c.cache.Delete(key(id))
+	return c.ProductRepository.UpdatePrice(ctx, id, price)
+}
+
+func (c *CacheProductRepository) Delete(ctx context.Context, id int) error {
+	defer c.cache.Delete(key(id))
+	return c.ProductRepository.Delete(ctx, id)
+}
+
+// ProductChanged implements domain.CatalogListener
+func (c *CacheProductRepository) ProductChanged(ctx context.Context, id int) {
+	c.cache.Delete(key(id))
+}
+
+// AuthorChanged implements domain.CatalogListener
+func (c *CacheProductRepository) AuthorChanged(ctx context.Context, id int) {
+	authorID := strconv.Itoa(id)
+	c.cache.DeleteFunc(func(_ string, v interface{}) bool {
+		return v.(domain.Product).Author.ID == authorID
+	})
+}
@@ -3,10 +3,13 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/sqltx"
 )
 
 // mysqlProductRepository represent the connection database struct
@@ -19,8 +22,15 @@ func NewMysqlProductRepository(Conn *sql.DB) domain.ProductRepository {
 	return &mysqlProductRepository{Conn: Conn}
 }
 
+// selectProduct list the columns in the order scanned by fetch
+const selectProduct = `SELECT product.id, product.name, product.price, product.author_id, product.description,
+	product.image, product.category, product.tax_class, product.published_year, product.rating_average, product.rating_count, product.available,
+	product.updated_at, product.created_at,
+	author.id, author.name, author.date_of_birth, author.updated_at, author.created_at
+	FROM product JOIN author ON product.author_id = author.id`
+
 func (m *mysqlProductRepository) fetch(ctx context.Context, query string, args ...interface{}) (result []domain.Product, err error) {
-	rows, err := m.Conn.QueryContext(ctx, query, args...)
+	rows, err := sqltx.From(ctx, m.Conn).QueryContext(ctx, query, args...)
 	if err != nil {
 		logrus.Error(err)
 		return nil, err
@@ -42,6 +52,12 @@ func (m *mysqlProductRepository) fetch(ctx context.Context, query string, args .
 			&t.AuthorID,
 			&t.Description,
 			&t.Image,
+			&t.Category,
+			&t.TaxClass,
+			&t.PublishedYear,
+			&t.RatingAverage,
+			&t.RatingCount,
+			&t.Available,
 			&t.UpdatedAt,
 			&t.CreatedAt,
 			&t.Author.ID,
@@ -60,30 +76,190 @@ func (m *mysqlProductRepository) fetch(ctx context.Context, query string, args .
 }
 
 func (m *mysqlProductRepository) Fetch(ctx context.Context) (res []domain.Product, err error) {
-	query := `SELECT * FROM product JOIN author ON product.author_id = author.id`
-	res, err = m.fetch(ctx, query)
+	res, err = m.fetch(ctx, selectProduct)
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// filterConditions build the WHERE clause of the filter, the selections of
+// the skipped facet are left out to count the alternatives of that facet
+func filterConditions(filter domain.ProductFilter, skip string) (string, []interface{}) {
+	conds := []string{}
+	args := []interface{}{}
+	in := func(column string, values []interface{}) {
+		conds = append(conds, fmt.Sprintf("%s IN (?%s)", column, strings.Repeat(",?", len(values)-1)))
+		args = append(args, values...)
+	}
+	if len(filter.AuthorIDs) > 0 && skip != "author" {
+		values := make([]interface{}, len(filter.AuthorIDs))
+		for i, v := range filter.AuthorIDs {
+			values[i] = v
+		}
+		in("product.author_id", values)
+	}
+	if len(filter.Categories) > 0 && skip != "category" {
+		values := make([]interface{}, len(filter.Categories))
+		for i, v := range filter.Categories {
+			values[i] = v
+		}
+		in("product.category", values)
+	}
+	if len(filter.Years) > 0 && skip != "year" {
+		values := make([]interface{}, len(filter.Years))
+		for i, v := range filter.Years {
+			values[i] = v
+		}
+		in("product.published_year", values)
+	}
+	if len(filter.PriceBuckets) > 0 && skip != "price" {
+		ranges := []string{}
+		for _, key := range filter.PriceBuckets {
+			for _, b := range domain.PriceBuckets {
+				if b.Key != key {
+					continue
+				}
+				if b.Max > 0 {
+					ranges = append(ranges, "(product.price >= ? AND product.price < ?)")
+					args = append(args, b.Min, b.Max)
+				} else {
+					ranges = append(ranges, "product.price >= ?")
+					args = append(args, b.Min)
+				}
+			}
+		}
+		if len(ranges) > 0 {
+			conds = append(conds, "("+strings.Join(ranges, " OR ")+")")
+		}
+	}
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// priceBucketExpr is the SQL expression giving the price bucket key of a product
+func priceBucketExpr() string {
+	var b strings.Builder
+	b.WriteString("CASE")
+	for _, bucket := range domain.PriceBuckets {
+		if bucket.Max > 0 {
+			fmt.Fprintf(&b, " WHEN product.price < %d THEN '%s'", bucket.Max, bucket.Key)
+		} else {
+			fmt.Fprintf(&b, " ELSE '%s'", bucket.Key)
+		}
+	}
+	b.WriteString(" END")
+	return b.String()
+}
+
+func (m *mysqlProductRepository) FetchFiltered(ctx context.Context, filter domain.ProductFilter) (res []domain.Product, err error) {
+	where, args := filterConditions(filter, "")
+	query := selectProduct + where + ` ORDER BY product.id`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+	res, err = m.fetch(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	return
 }
 
+// countFacet run a GROUP BY query returning the value, the label and the count of a facet
+func (m *mysqlProductRepository) countFacet(ctx context.Context, query string, args ...interface{}) (result []domain.FacetValue, err error) {
+	rows, err := m.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.FacetValue, 0)
+	for rows.Next() {
+		t := domain.FacetValue{}
+		err = rows.Scan(&t.Value, &t.Label, &t.Count)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// FetchFacets count the products of every facet value, each facet is counted without its own selections
+func (m *mysqlProductRepository) FetchFacets(ctx context.Context, filter domain.ProductFilter) (res domain.ProductFacets, err error) {
+	from := ` FROM product JOIN author ON product.author_id = author.id`
+
+	where, args := filterConditions(filter, "author")
+	res.Author, err = m.countFacet(ctx, `SELECT author.id, author.name, COUNT(*)`+from+where+
+		` GROUP BY author.id, author.name ORDER BY COUNT(*) DESC, author.name`, args...)
+	if err != nil {
+		return
+	}
+
+	where, args = filterConditions(filter, "category")
+	res.Category, err = m.countFacet(ctx, `SELECT product.category, '', COUNT(*)`+from+where+
+		andCondition(where, "product.category <> ''")+` GROUP BY product.category ORDER BY COUNT(*) DESC, product.category`, args...)
+	if err != nil {
+		return
+	}
+
+	where, args = filterConditions(filter, "year")
+	res.Year, err = m.countFacet(ctx, `SELECT product.published_year, '', COUNT(*)`+from+where+
+		andCondition(where, "product.published_year > 0")+` GROUP BY product.published_year ORDER BY product.published_year DESC`, args...)
+	if err != nil {
+		return
+	}
+
+	where, args = filterConditions(filter, "price")
+	bucket := priceBucketExpr()
+	res.Price, err = m.countFacet(ctx, `SELECT `+bucket+`, '', COUNT(*)`+from+where+` GROUP BY 1`, args...)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// andCondition append a condition to a possibly empty WHERE clause
+func andCondition(where string, cond string) string {
+	if where == "" {
+		return " WHERE " + cond
+	}
+	return " AND " + cond
+}
+
 func (m *mysqlProductRepository) Store(ctx context.Context, p *domain.Product) (err error) {
-	query := `INSERT INTO product VALUES(?,?,?,?,?,?,?,?)`
-	stmt, err := m.Conn.PrepareContext(ctx, query)
+	query := `INSERT INTO product (id, name, price, author_id, description, image, category, tax_class, published_year, available, updated_at, created_at)
+		VALUES(?,?,?,?,?,?,?,?,?,?,?,?)`
+	stmt, err := sqltx.From(ctx, m.Conn).PrepareContext(ctx, query)
 	if err != nil {
 		return
 	}
-	_, err = stmt.ExecContext(ctx, p.ID, p.Name, p.Price, p.AuthorID, p.Description, p.Image, time.Now(), time.Now())
+	result, err := stmt.ExecContext(ctx, p.ID, p.Name, p.Price, p.AuthorID, p.Description, p.Image, p.Category, p.TaxClass, p.PublishedYear, p.Available, time.Now(), time.Now())
 	if err != nil {
 		return
 	}
+	// the id is generated unless the client gave one
+	if p.ID == 0 {
+		lastID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		p.ID = int(lastID)
+	}
 	return
 }
 
 func (m *mysqlProductRepository) GetByID(ctx context.Context, id int) (res domain.Product, err error) {
-	query := `SELECT * FROM product JOIN author ON product.author_id = author.id WHERE product.id=?`
-	list, err := m.fetch(ctx, query, id)
+	list, err := m.fetch(ctx, selectProduct+` WHERE product.id=?`, id)
 	if err != nil {
 		return
 	}
@@ -96,22 +272,106 @@ func (m *mysqlProductRepository) GetByID(ctx context.Context, id int) (res domai
 	return
 }
 
-func (m *mysqlProductRepository) Update(ctx context.Context, p *domain.Product, id int) (err error) {
-	query := `UPDATE product SET name=?, price=?, author_id=?, description=?, updated_at=? WHERE id=?`
-	stmt, err := m.Conn.PrepareContext(ctx, query)
+func (m *mysqlProductRepository) GetByIDForUpdate(ctx context.Context, id int) (res domain.Product, err error) {
+	list, err := m.fetch(ctx, selectProduct+` WHERE product.id=? FOR UPDATE`, id)
 	if err != nil {
 		return
 	}
-	_, err = stmt.ExecContext(ctx, p.Name, p.Price, p.AuthorID, p.Description, time.Now(), id)
+	if len(list) == 0 {
+		return res, domain.ErrNotFound
+	}
+	return list[0], nil
+}
+
+// withTx run fn in a transaction, rolled back when fn fails. fn joins the
+// transaction of ctx when there is one
+func (m *mysqlProductRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) (err error) {
+	if tx, ok := sqltx.Tx(ctx); ok {
+		return fn(tx)
+	}
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(); errRollback != nil {
+				logrus.Error(errRollback)
+			}
+		}
+	}()
+	if err = fn(tx); err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+// lockPrice lock the product until the end of the transaction and return its current price
+func lockPrice(ctx context.Context, tx *sql.Tx, id int) (price int64, err error) {
+	err = tx.QueryRowContext(ctx, `SELECT price FROM product WHERE id=? FOR UPDATE`, id).Scan(&price)
+	if err == sql.ErrNoRows {
+		return 0, domain.ErrNotFound
+	}
+	return
+}
+
+// recordPrice append the price change to the history, made by the principal of ctx
+func recordPrice(ctx context.Context, tx *sql.Tx, id int, old int64, price int64, now time.Time) error {
+	if old == price {
+		return nil
+	}
+	changedBy := ""
+	if principal, ok := domain.PrincipalFromContext(ctx); ok {
+		changedBy = principal.Subject
+	}
+	query := `INSERT INTO product_price_history (product_id, old_price, new_price, changed_by, changed_at) VALUES(?,?,?,?,?)`
+	_, err := tx.ExecContext(ctx, query, id, old, price, changedBy, now)
+	return err
+}
+
+func (m *mysqlProductRepository) Update(ctx context.Context, p *domain.Product, id int) (err error) {
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		old, err := lockPrice(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		query := `UPDATE product SET name=?, price=?, author_id=?, description=?, category=?, tax_class=?, published_year=?, available=?, updated_at=? WHERE id=?`
+		_, err = tx.ExecContext(ctx, query, p.Name, p.Price, p.AuthorID, p.Description, p.Category, p.TaxClass, p.PublishedYear, p.Available, now, id)
+		if err != nil {
+			return err
+		}
+		return recordPrice(ctx, tx, id, old, p.Price, now)
+	})
+}
+
+func (m *mysqlProductRepository) UpdatePrice(ctx context.Context, id int, price int64) (err error) {
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		old, err := lockPrice(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if _, err = tx.ExecContext(ctx, `UPDATE product SET price=?, updated_at=? WHERE id=?`, price, now, id); err != nil {
+			return err
+		}
+		return recordPrice(ctx, tx, id, old, price, now)
+	})
+}
+
+func (m *mysqlProductRepository) UpdateImage(ctx context.Context, id int, image string) (err error) {
+	query := `UPDATE product SET image=?, updated_at=? WHERE id=?`
+	stmt, err := sqltx.From(ctx, m.Conn).PrepareContext(ctx, query)
 	if err != nil {
 		return
 	}
+	_, err = stmt.ExecContext(ctx, image, time.Now(), id)
 	return
 }
 
 func (m *mysqlProductRepository) Delete(ctx context.Context, id int) (err error) {
 	query := `DELETE FROM product WHERE id=?`
-	stmt, err := m.Conn.PrepareContext(ctx, query)
+	stmt, err := sqltx.From(ctx, m.Conn).PrepareContext(ctx, query)
 	if err != nil {
 		return
 	}
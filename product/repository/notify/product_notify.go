@@ -0,0 +1,69 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/sqltx"
+)
+
+// notifyProductRepository notify the catalog listeners of every successful product mutation
+type notifyProductRepository struct {
+	domain.ProductRepository
+	listeners []domain.CatalogListener
+}
+
+// NewNotifyProductRepository will wrap a domain.ProductRepository so that the listeners are notified of its mutations
+func NewNotifyProductRepository(next domain.ProductRepository, listeners ...domain.CatalogListener) domain.ProductRepository {
+	return &notifyProductRepository{ProductRepository: next, listeners: listeners}
+}
+
+// notify tell the listeners about the change once it is committed, the
+// listeners reading the catalog back must not see it before
+func (n *notifyProductRepository) notify(ctx context.Context, id int) {
+	sqltx.AfterCommit(ctx, func() {
+		for _, l := range n.listeners {
+			l.ProductChanged(ctx, id)
+		}
+	})
+}
+
+func (n *notifyProductRepository) Store(ctx context.Context, p *domain.Product) (err error) {
+	if err = n.ProductRepository.Store(ctx, p); err != nil {
+		return
+	}
+	n.notify(ctx, p.ID)
+	return
+}
+
+func (n *notifyProductRepository) Update(ctx context.Context, p *domain.Product, id int) (err error) {
+	if err = n.ProductRepository.Update(ctx, p, id); err != nil {
+		return
+	}
+	n.notify(ctx, id)
+	return
+}
+
+func (n *notifyProductRepository) UpdateImage(ctx context.Context, id int, image string) (err error) {
+	if err = n.ProductRepository.UpdateImage(ctx, id, image); err != nil {
+		return
+	}
+	n.notify(ctx, id)
+	return
+}
+
+func (n *notifyProductRepository) UpdatePrice(ctx context.Context, id int, price int64) (err error) {
+	if err = n.ProductRepository.UpdatePrice(ctx, id, price); err != nil {
+		return
+	}
+	n.notify(ctx, id)
+	return
+}
+
+func (n *notifyProductRepository) Delete(ctx context.Context, id int) (err error) {
+	if err = n.ProductRepository.Delete(ctx, id); err != nil {
+		return
+	}
+	n.notify(ctx, id)
+	return
+}
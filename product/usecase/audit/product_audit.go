@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// auditProductUsecase record an audit entry of every successful product mutation
+type auditProductUsecase struct {
+	domain.ProductUseCase
+	productRepo domain.ProductRepository
+	audit       domain.AuditUsecase
+	transactor  domain.Transactor
+}
+
+// NewAuditProductUsecase will wrap a domain.ProductUseCase so that its mutations are audited,
+// the entry being written in the transaction of the mutation. The product is
+// read and locked through r before the mutation, so that no other writer
+// changes it between the read and the mutation
+func NewAuditProductUsecase(next domain.ProductUseCase, r domain.ProductRepository, a domain.AuditUsecase, t domain.Transactor) domain.ProductUseCase {
+	return &auditProductUsecase{ProductUseCase: next, productRepo: r, audit: a, transactor: t}
+}
+
+func (u *auditProductUsecase) record(ctx context.Context, action domain.AuditAction, id int, before interface{}, after interface{}) error {
+	return u.audit.Record(ctx, action, domain.EntityProduct, strconv.Itoa(id), before, after)
+}
+
+func (u *auditProductUsecase) Store(c context.Context, m *domain.Product) error {
+	return u.transactor.WithinTransaction(c, func(ctx context.Context) error {
+		if err := u.ProductUseCase.Store(ctx, m); err != nil {
+			return err
+		}
+		after, err := u.ProductUseCase.GetByID(ctx, m.ID)
+		if err != nil {
+			return err
+		}
+		return u.record(ctx, domain.AuditCreate, m.ID, nil, after)
+	})
+}
+
+func (u *auditProductUsecase) Update(c context.Context, m *domain.Product, id int) error {
+	return u.transactor.WithinTransaction(c, func(ctx context.Context) error {
+		before, err := u.productRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = u.ProductUseCase.Update(ctx, m, id); err != nil {
+			return err
+		}
+		after, err := u.ProductUseCase.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return u.record(ctx, domain.AuditUpdate, id, before, after)
+	})
+}
+
+func (u *auditProductUsecase) Delete(c context.Context, id int) error {
+	return u.transactor.WithinTransaction(c, func(ctx context.Context) error {
+		before, err := u.productRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = u.ProductUseCase.Delete(ctx, id); err != nil {
+			return err
+		}
+		return u.record(ctx, domain.AuditDelete, id, before, nil)
+	})
+}
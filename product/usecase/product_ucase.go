@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"github.com/wdwiramadhan/bookhub-api/domain"
@@ -10,17 +11,31 @@ import (
 // ProductUseCase represent the product use case struct
 type ProductUseCase struct {
 	productRepo    domain.ProductRepository
+	transactor     domain.Transactor
+	events         domain.EventEmitter
 	contextTimeout time.Duration
 }
 
-// NewProductUsecase will create new an productUsecase object representation of domain.ProductUsecase interface
-func NewProductUsecase(p domain.ProductRepository, timeout time.Duration) domain.ProductUseCase {
+// NewProductUsecase will create new an productUsecase object representation of domain.ProductUsecase interface,
+// the mutations emit their events in the transaction making them
+func NewProductUsecase(p domain.ProductRepository, tx domain.Transactor, events domain.EventEmitter, timeout time.Duration) domain.ProductUseCase {
 	return &ProductUseCase{
 		productRepo:    p,
+		transactor:     tx,
+		events:         events,
 		contextTimeout: timeout,
 	}
 }
 
+// emit record an event about the product, in the transaction of ctx
+func (p *ProductUseCase) emit(ctx context.Context, eventType string, id int, payload interface{}) error {
+	e, err := domain.NewEvent(eventType, domain.EntityProduct, strconv.Itoa(id), payload)
+	if err != nil {
+		return err
+	}
+	return p.events.Emit(ctx, e)
+}
+
 func (p *ProductUseCase) Fetch(c context.Context) (res []domain.Product, err error) {
 	ctx, cancel := context.WithTimeout(c, p.contextTimeout)
 	defer cancel()
@@ -29,42 +44,187 @@ func (p *ProductUseCase) Fetch(c context.Context) (res []domain.Product, err err
 	if err != nil {
 		return nil, err
 	}
+	withThumbnails(res)
 	return
 }
 
-func (p *ProductUseCase) Store(c context.Context, m *domain.Product) (err error) {
+// withThumbnails fill the thumbnail URLs of the uploaded covers
+func withThumbnails(products []domain.Product) {
+	for i := range products {
+		products[i].Thumbnails = domain.CoverThumbnails(products[i].Image)
+	}
+}
+
+// FetchFaceted will fetch the products matching the facet selections along with the facet counts
+func (p *ProductUseCase) FetchFaceted(c context.Context, filter domain.ProductFilter) (res []domain.Product, facets domain.ProductFacets, err error) {
 	ctx, cancel := context.WithTimeout(c, p.contextTimeout)
 	defer cancel()
-	err = p.productRepo.Store(ctx, m)
+
+	for _, key := range filter.PriceBuckets {
+		if !isPriceBucket(key) {
+			return nil, facets, domain.ErrBadParamInput
+		}
+	}
+	res, err = p.productRepo.FetchFiltered(ctx, filter)
+	if err != nil {
+		return nil, facets, err
+	}
+	withThumbnails(res)
+	facets, err = p.productRepo.FetchFacets(ctx, filter)
+	if err != nil {
+		return nil, facets, err
+	}
+
+	authorIDs := make([]string, len(filter.AuthorIDs))
+	for i, id := range filter.AuthorIDs {
+		authorIDs[i] = strconv.Itoa(id)
+	}
+	years := make([]string, len(filter.Years))
+	for i, year := range filter.Years {
+		years[i] = strconv.Itoa(year)
+	}
+	markSelected(facets.Author, authorIDs)
+	markSelected(facets.Category, filter.Categories)
+	markSelected(facets.Year, years)
+	facets.Price = priceFacet(facets.Price, filter.PriceBuckets)
 	return
 }
 
-func (p *ProductUseCase) GetByID(c context.Context, id int) (res domain.Product, err error) {
+// FetchFiltered will fetch the products matching the facet selections, up to the limit of the filter
+func (p *ProductUseCase) FetchFiltered(c context.Context, filter domain.ProductFilter) (res []domain.Product, err error) {
+	ctx, cancel := context.WithTimeout(c, p.contextTimeout)
+	defer cancel()
+
+	for _, key := range filter.PriceBuckets {
+		if !isPriceBucket(key) {
+			return nil, domain.ErrBadParamInput
+		}
+	}
+	res, err = p.productRepo.FetchFiltered(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	withThumbnails(res)
+	return
+}
+
+func isPriceBucket(key string) bool {
+	for _, b := range domain.PriceBuckets {
+		if b.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func markSelected(values []domain.FacetValue, selected []string) {
+	for i := range values {
+		for _, s := range selected {
+			if values[i].Value == s {
+				values[i].Selected = true
+			}
+		}
+	}
+}
+
+// priceFacet list every price bucket in order, including the empty ones
+func priceFacet(counts []domain.FacetValue, selected []string) []domain.FacetValue {
+	res := make([]domain.FacetValue, len(domain.PriceBuckets))
+	for i, b := range domain.PriceBuckets {
+		res[i] = domain.FacetValue{Value: b.Key}
+		for _, c := range counts {
+			if c.Value == b.Key {
+				res[i].Count = c.Count
+			}
+		}
+	}
+	markSelected(res, selected)
+	return res
+}
+
+// withTaxClass put the products that do not tell their tax class in the standard one
+func withTaxClass(m *domain.Product) {
+	if m.TaxClass == "" {
+		m.TaxClass = domain.TaxClassStandard
+	}
+}
+
+func (p *ProductUseCase) Store(c context.Context, m *domain.Product) (err error) {
+	if err = domain.Authorize(c, domain.PermissionProductWrite); err != nil {
+		return
+	}
 	ctx, cancel := context.WithTimeout(c, p.contextTimeout)
 	defer cancel()
+	withTaxClass(m)
+	return p.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := p.productRepo.Store(ctx, m); err != nil {
+			return err
+		}
+		stored, err := p.reload(ctx, m.ID)
+		if err != nil {
+			return err
+		}
+		*m = stored
+		return p.emit(ctx, domain.EventProductCreated, m.ID, m)
+	})
+}
+
+// reload read the product back in the transaction of ctx, as stored, so that
+// the events carry the whole product rather than what the caller sent
+func (p *ProductUseCase) reload(ctx context.Context, id int) (res domain.Product, err error) {
 	res, err = p.productRepo.GetByID(ctx, id)
 	if err != nil {
 		return
 	}
+	res.Thumbnails = domain.CoverThumbnails(res.Image)
 	return
 }
 
-func (p *ProductUseCase) Update(c context.Context, m *domain.Product, id int) (err error) {
+func (p *ProductUseCase) GetByID(c context.Context, id int) (res domain.Product, err error) {
 	ctx, cancel := context.WithTimeout(c, p.contextTimeout)
 	defer cancel()
-	err = p.productRepo.Update(ctx, m, id)
+	res, err = p.productRepo.GetByID(ctx, id)
 	if err != nil {
 		return
 	}
+	res.Thumbnails = domain.CoverThumbnails(res.Image)
 	return
 }
 
+func (p *ProductUseCase) Update(c context.Context, m *domain.Product, id int) (err error) {
+	if err = domain.Authorize(c, domain.PermissionProductWrite); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, p.contextTimeout)
+	defer cancel()
+	withTaxClass(m)
+	return p.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := p.productRepo.Update(ctx, m, id); err != nil {
+			return err
+		}
+		stored, err := p.reload(ctx, id)
+		if err != nil {
+			return err
+		}
+		*m = stored
+		return p.emit(ctx, domain.EventProductUpdated, id, m)
+	})
+}
+
 func (p *ProductUseCase) Delete(c context.Context, id int) (err error) {
+	if err = domain.Authorize(c, domain.PermissionProductDelete); err != nil {
+		return
+	}
 	ctx, cancel := context.WithTimeout(c, p.contextTimeout)
 	defer cancel()
-	err = p.productRepo.Delete(ctx, id)
+	existing, err := p.productRepo.GetByID(ctx, id)
 	if err != nil {
 		return
 	}
-	return
+	return p.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := p.productRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return p.emit(ctx, domain.EventProductDeleted, id, existing)
+	})
 }
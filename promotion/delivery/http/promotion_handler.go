@@ -0,0 +1,184 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+// PromotionHandler represent the httphandler for promotion
+type PromotionHandler struct {
+	PUsecase domain.PromotionUsecase
+}
+
+// NewPromotionHandler will initialize the promotion endpoint
+func NewPromotionHandler(e *echo.Echo, us domain.PromotionUsecase, auth echo.MiddlewareFunc) {
+	handler := &PromotionHandler{
+		PUsecase: us,
+	}
+	e.GET("/product/:productId/price", handler.Quote)
+	e.POST("/quote", handler.QuoteCart)
+	e.GET("/admin/promotion", handler.Fetch, auth)
+	e.POST("/admin/promotion", handler.Store, auth)
+	e.GET("/admin/promotion/:promotionId", handler.GetByID, auth)
+	e.PUT("/admin/promotion/:promotionId", handler.Update, auth)
+	e.DELETE("/admin/promotion/:promotionId", handler.Delete, auth)
+}
+
+// Quote will price the quantity query param of the product with the running
+// promotions and the coupon query param, telling which promotions applied and
+// why the others did not. It is taxed when the country query param is given
+func (p *PromotionHandler) Quote(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("productId"))
+	opts := domain.QuoteOptions{
+		CouponCode: c.QueryParam("coupon"),
+		TaxJurisdiction: domain.TaxJurisdiction{
+			Country: c.QueryParam("country"),
+			Region:  c.QueryParam("region"),
+		},
+	}
+	if q := c.QueryParam("quantity"); q != "" {
+		quantity, err := strconv.Atoi(q)
+		if err != nil || quantity < 1 {
+			return failed(c, domain.ErrBadParamInput)
+		}
+		opts.Quantity = quantity
+	}
+	ctx := c.Request().Context()
+	quote, err := p.PUsecase.Quote(ctx, id, opts)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: quote})
+}
+
+// QuoteCart will price the lines of the request body together, with their tax breakdown
+func (p *PromotionHandler) QuoteCart(c echo.Context) (err error) {
+	var req domain.CartQuoteRequest
+	if err = c.Bind(&req); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&req); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	quote, err := p.PUsecase.QuoteCart(ctx, &req)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: quote})
+}
+
+// Fetch will fetch every promotion
+func (p *PromotionHandler) Fetch(c echo.Context) error {
+	ctx := c.Request().Context()
+	promotions, err := p.PUsecase.Fetch(ctx)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: promotions})
+}
+
+// GetByID will get a promotion by its id
+func (p *PromotionHandler) GetByID(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("promotionId"))
+	ctx := c.Request().Context()
+	promotion, err := p.PUsecase.GetByID(ctx, id)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: promotion})
+}
+
+// Store will add a promotion
+func (p *PromotionHandler) Store(c echo.Context) (err error) {
+	var promotion domain.Promotion
+	if err = c.Bind(&promotion); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&promotion); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	if err = p.PUsecase.Store(ctx, &promotion); err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusCreated, response.ResponseSuccess{Success: true, Data: promotion})
+}
+
+// Update will replace the rule of a promotion
+func (p *PromotionHandler) Update(c echo.Context) (err error) {
+	id, _ := strconv.Atoi(c.Param("promotionId"))
+	var promotion domain.Promotion
+	if err = c.Bind(&promotion); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&promotion); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	if err = p.PUsecase.Update(ctx, id, &promotion); err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: promotion})
+}
+
+// Delete will remove a promotion
+func (p *PromotionHandler) Delete(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("promotionId"))
+	ctx := c.Request().Context()
+	if err := p.PUsecase.Delete(ctx, id); err != nil {
+		return failed(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func isRequestValid(m interface{}) (bool, error) {
+	validate := validator.New()
+	err := validate.Struct(m)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// failed write the error response of err, a denied permission is described with a problem detail
+func failed(c echo.Context, err error) error {
+	status := getStatusCode(err)
+	if status == http.StatusForbidden {
+		c.Response().Header().Set(echo.HeaderContentType, response.ProblemContentType)
+		return c.JSON(status, response.NewProblem(status, err.Error()))
+	}
+	return c.JSON(status, response.ResponseFailed{Success: false, Message: err.Error()})
+}
+
+func getStatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	logrus.Error(err)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrBadParamInput):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,177 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/mysqlerr"
+)
+
+const selectPromotion = `SELECT id, name, type, value, scope, scope_value, starts_at, ends_at, coupon_code,
+	usage_limit, usage_count, stackable, priority, active, updated_at, created_at FROM promotion`
+
+// mysqlPromotionRepository represent the connection database struct
+type mysqlPromotionRepository struct {
+	Conn *sql.DB
+}
+
+// NewMysqlPromotionRepository will create an object that represent the promotion.Repository interface
+func NewMysqlPromotionRepository(Conn *sql.DB) domain.PromotionRepository {
+	return &mysqlPromotionRepository{Conn: Conn}
+}
+
+// nullString store an empty coupon code as NULL, the unique key allows many of them
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func (m *mysqlPromotionRepository) fetch(ctx context.Context, query string, args ...interface{}) (result []domain.Promotion, err error) {
+	rows, err := m.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.Promotion, 0)
+	for rows.Next() {
+		t := domain.Promotion{}
+		var startsAt, endsAt sql.NullTime
+		var coupon sql.NullString
+		err = rows.Scan(
+			&t.ID,
+			&t.Name,
+			&t.Type,
+			&t.Value,
+			&t.Scope,
+			&t.ScopeValue,
+			&startsAt,
+			&endsAt,
+			&coupon,
+			&t.UsageLimit,
+			&t.UsageCount,
+			&t.Stackable,
+			&t.Priority,
+			&t.Active,
+			&t.UpdatedAt,
+			&t.CreatedAt,
+		)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		if startsAt.Valid {
+			t.StartsAt = &startsAt.Time
+		}
+		if endsAt.Valid {
+			t.EndsAt = &endsAt.Time
+		}
+		t.CouponCode = coupon.String
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+func (m *mysqlPromotionRepository) Fetch(ctx context.Context) ([]domain.Promotion, error) {
+	return m.fetch(ctx, selectPromotion+` ORDER BY priority DESC, id`)
+}
+
+func (m *mysqlPromotionRepository) FetchApplicable(ctx context.Context, product domain.Product) ([]domain.Promotion, error) {
+	query := selectPromotion + ` WHERE active=1 AND (scope=?
+		OR (scope=? AND scope_value=?) OR (scope=? AND scope_value=?) OR (scope=? AND scope_value=?))
+		ORDER BY priority DESC, id`
+	return m.fetch(ctx, query,
+		domain.PromotionScopeAll,
+		domain.PromotionScopeProduct, strconv.Itoa(product.ID),
+		domain.PromotionScopeAuthor, strconv.Itoa(product.AuthorID),
+		domain.PromotionScopeCategory, product.Category,
+	)
+}
+
+func (m *mysqlPromotionRepository) GetByID(ctx context.Context, id int) (res domain.Promotion, err error) {
+	list, err := m.fetch(ctx, selectPromotion+` WHERE id=?`, id)
+	if err != nil {
+		return
+	}
+	if len(list) == 0 {
+		return res, domain.ErrNotFound
+	}
+	return list[0], nil
+}
+
+func (m *mysqlPromotionRepository) Store(ctx context.Context, p *domain.Promotion) error {
+	query := `INSERT INTO promotion (name, type, value, scope, scope_value, starts_at, ends_at, coupon_code,
+		usage_limit, usage_count, stackable, priority, active, updated_at, created_at) VALUES(?,?,?,?,?,?,?,?,?,0,?,?,?,?,?)`
+	now := time.Now()
+	result, err := m.Conn.ExecContext(ctx, query, p.Name, p.Type, p.Value, p.Scope, p.ScopeValue, p.StartsAt, p.EndsAt,
+		nullString(p.CouponCode), p.UsageLimit, p.Stackable, p.Priority, p.Active, now, now)
+	if err != nil {
+		if mysqlerr.IsDuplicate(err) {
+			return domain.ErrConflict
+		}
+		return err
+	}
+	lastID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	p.ID = int(lastID)
+	p.UsageCount = 0
+	p.UpdatedAt = now
+	p.CreatedAt = now
+	return nil
+}
+
+// Update change the rule of a promotion, its usage count is left alone
+func (m *mysqlPromotionRepository) Update(ctx context.Context, p *domain.Promotion) error {
+	query := `UPDATE promotion SET name=?, type=?, value=?, scope=?, scope_value=?, starts_at=?, ends_at=?, coupon_code=?,
+		usage_limit=?, stackable=?, priority=?, active=?, updated_at=? WHERE id=?`
+	p.UpdatedAt = time.Now()
+	_, err := m.Conn.ExecContext(ctx, query, p.Name, p.Type, p.Value, p.Scope, p.ScopeValue, p.StartsAt, p.EndsAt,
+		nullString(p.CouponCode), p.UsageLimit, p.Stackable, p.Priority, p.Active, p.UpdatedAt, p.ID)
+	if err != nil && mysqlerr.IsDuplicate(err) {
+		return domain.ErrConflict
+	}
+	return err
+}
+
+func (m *mysqlPromotionRepository) Delete(ctx context.Context, id int) error {
+	_, err := m.Conn.ExecContext(ctx, `DELETE FROM promotion WHERE id=?`, id)
+	return err
+}
+
+// Redeem count a use of the coupon in a single statement, so that concurrent
+// redemptions never exceed the usage limit nor redeem a coupon deactivated or
+// expired since it was quoted
+func (m *mysqlPromotionRepository) Redeem(ctx context.Context, coupon string) error {
+	query := `UPDATE promotion SET usage_count=usage_count+1
+		WHERE coupon_code=? AND active=1 AND (usage_limit=0 OR usage_count<usage_limit)
+		AND (starts_at IS NULL OR starts_at<=?) AND (ends_at IS NULL OR ends_at>?)`
+	now := time.Now()
+	result, err := m.Conn.ExecContext(ctx, query, coupon, now, now)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrConflict
+	}
+	return nil
+}
+
+func (m *mysqlPromotionRepository) Release(ctx context.Context, coupon string) error {
+	query := `UPDATE promotion SET usage_count=usage_count-1 WHERE coupon_code=? AND usage_count>0`
+	_, err := m.Conn.ExecContext(ctx, query, coupon)
+	return err
+}
@@ -0,0 +1,207 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// PromotionUsecase represent the promotion use case struct
+type PromotionUsecase struct {
+	promotionRepo  domain.PromotionRepository
+	productRepo    domain.ProductRepository
+	taxes          domain.TaxUsecase
+	contextTimeout time.Duration
+}
+
+// NewPromotionUsecase will create new a promotion usecase object representation of domain.PromotionUsecase interface,
+// the quotes are taxed with t
+func NewPromotionUsecase(pr domain.PromotionRepository, p domain.ProductRepository, t domain.TaxUsecase, timeout time.Duration) domain.PromotionUsecase {
+	return &PromotionUsecase{
+		promotionRepo:  pr,
+		productRepo:    p,
+		taxes:          t,
+		contextTimeout: timeout,
+	}
+}
+
+// normalizeCoupon make coupon codes case insensitive
+func normalizeCoupon(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// validate check the rule of a promotion beyond what the struct tags can tell
+func validate(p *domain.Promotion) error {
+	p.CouponCode = normalizeCoupon(p.CouponCode)
+	switch {
+	case !p.Type.IsValid(), !p.Scope.IsValid():
+		return domain.ErrBadParamInput
+	case p.Type == domain.DiscountPercent && p.Value > 100:
+		return domain.ErrBadParamInput
+	case p.Scope != domain.PromotionScopeAll && p.ScopeValue == "":
+		return domain.ErrBadParamInput
+	case p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt):
+		return domain.ErrBadParamInput
+	case p.UsageLimit < 0:
+		return domain.ErrBadParamInput
+	}
+	if p.Scope == domain.PromotionScopeAll {
+		p.ScopeValue = ""
+	}
+	return nil
+}
+
+// Fetch will get every promotion, coupons included
+func (u *PromotionUsecase) Fetch(c context.Context) (res []domain.Promotion, err error) {
+	if err = domain.Authorize(c, domain.PermissionPromotionManage); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	return u.promotionRepo.Fetch(ctx)
+}
+
+// Store will add a promotion, coupon codes are unique
+func (u *PromotionUsecase) Store(c context.Context, p *domain.Promotion) (err error) {
+	if err = domain.Authorize(c, domain.PermissionPromotionManage); err != nil {
+		return
+	}
+	if err = validate(p); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	return u.promotionRepo.Store(ctx, p)
+}
+
+// GetByID will get a promotion by its id
+func (u *PromotionUsecase) GetByID(c context.Context, id int) (res domain.Promotion, err error) {
+	if err = domain.Authorize(c, domain.PermissionPromotionManage); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	return u.promotionRepo.GetByID(ctx, id)
+}
+
+// Update will replace the rule of a promotion, keeping how many times its coupon was used
+func (u *PromotionUsecase) Update(c context.Context, id int, p *domain.Promotion) (err error) {
+	if err = domain.Authorize(c, domain.PermissionPromotionManage); err != nil {
+		return
+	}
+	if err = validate(p); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	existing, err := u.promotionRepo.GetByID(ctx, id)
+	if err != nil {
+		return
+	}
+	p.ID = existing.ID
+	p.UsageCount = existing.UsageCount
+	p.CreatedAt = existing.CreatedAt
+	return u.promotionRepo.Update(ctx, p)
+}
+
+// Delete will remove a promotion
+func (u *PromotionUsecase) Delete(c context.Context, id int) (err error) {
+	if err = domain.Authorize(c, domain.PermissionPromotionManage); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if _, err = u.promotionRepo.GetByID(ctx, id); err != nil {
+		return
+	}
+	return u.promotionRepo.Delete(ctx, id)
+}
+
+// Quote will price a quantity of a product, one by default, with the
+// promotions running now and the coupon, if any
+func (u *PromotionUsecase) Quote(c context.Context, productID int, opts domain.QuoteOptions) (res domain.PriceQuote, err error) {
+	if opts.Quantity == 0 {
+		opts.Quantity = 1
+	}
+	cart, err := u.QuoteCart(c, &domain.CartQuoteRequest{
+		CouponCode: opts.CouponCode,
+		Country:    opts.Country,
+		Region:     opts.Region,
+		Lines:      []domain.CartQuoteLine{{ProductID: productID, Quantity: opts.Quantity}},
+	})
+	if err != nil {
+		return
+	}
+	res = cart.Lines[0]
+	res.Tax = cart.Tax
+	return
+}
+
+// QuoteCart will price every line with the promotions running now and the
+// coupon, if any, then tax the lines in the requested jurisdiction
+func (u *PromotionUsecase) QuoteCart(c context.Context, req *domain.CartQuoteRequest) (res domain.CartQuote, err error) {
+	if len(req.Lines) == 0 {
+		return res, domain.ErrBadParamInput
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	now := time.Now()
+	coupon := normalizeCoupon(req.CouponCode)
+	res = domain.CartQuote{
+		CouponCode: coupon,
+		Lines:      make([]domain.PriceQuote, 0, len(req.Lines)),
+		QuotedAt:   now,
+	}
+	taxable := make([]domain.TaxableLine, 0, len(req.Lines))
+	spent := map[int]bool{}
+	for _, line := range req.Lines {
+		if line.Quantity < 1 {
+			return res, domain.ErrBadParamInput
+		}
+		product, err := u.productRepo.GetByID(ctx, line.ProductID)
+		if err != nil {
+			return res, err
+		}
+		promotions, err := u.promotionRepo.FetchApplicable(ctx, product)
+		if err != nil {
+			return res, err
+		}
+		q := quote(product, promotions, coupon, line.Quantity, spent, now)
+		q.Quantity = line.Quantity
+		q.LineTotal = q.FinalPrice * int64(line.Quantity)
+		res.CouponApplied = res.CouponApplied || q.CouponApplied
+		res.Subtotal += q.LineTotal
+		res.Lines = append(res.Lines, q)
+		taxable = append(taxable, domain.TaxableLine{ProductID: product.ID, TaxClass: product.TaxClass, Amount: q.LineTotal})
+	}
+	res.Total = res.Subtotal
+	if req.Country != "" {
+		tax, err := u.taxes.Calculate(ctx, domain.TaxJurisdiction{Country: req.Country, Region: req.Region}, taxable)
+		if err != nil {
+			return res, err
+		}
+		res.Tax = &tax
+		res.Total = tax.Gross
+	}
+	return
+}
+
+// Redeem will count a use of the coupon, it is called when placing an order
+// so it needs no permission of its own
+func (u *PromotionUsecase) Redeem(c context.Context, coupon string) error {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	return u.promotionRepo.Redeem(ctx, normalizeCoupon(coupon))
+}
+
+// Release will give back a use of the coupon
+func (u *PromotionUsecase) Release(c context.Context, coupon string) error {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	return u.promotionRepo.Release(ctx, normalizeCoupon(coupon))
+}
@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// unavailable tell why a promotion in scope does not apply at the given time, if it does not
+func unavailable(p domain.Promotion, now time.Time) string {
+	switch {
+	case p.StartsAt != nil && now.Before(*p.StartsAt):
+		return "not started yet"
+	case p.EndsAt != nil && !now.Before(*p.EndsAt):
+		return "expired"
+	case p.UsageLimit > 0 && p.UsageCount >= p.UsageLimit:
+		return "usage limit reached"
+	}
+	return ""
+}
+
+func applied(p domain.Promotion, amount int64) domain.AppliedPromotion {
+	return domain.AppliedPromotion{PromotionID: p.ID, Name: p.Name, Type: p.Type, Value: p.Value, Amount: amount}
+}
+
+func skipped(p domain.Promotion, reason string) domain.SkippedPromotion {
+	return domain.SkippedPromotion{PromotionID: p.ID, Name: p.Name, Reason: reason}
+}
+
+// quote price a unit of the product bought in quantity with the promotions,
+// sorted by decreasing priority. The stackable promotions apply one after the
+// other on the discounted price, a non stackable one applies alone: the best of
+// them is kept when it beats the whole stack. Coupon promotions are only
+// considered, and only mentioned, when their code is given.
+// A fixed amount coupon is worth its value once per cart: it is spread over
+// the units of the line, rounded down, and recorded in spent once applied so
+// that the following lines skip it
+func quote(product domain.Product, promotions []domain.Promotion, coupon string, quantity int, spent map[int]bool, now time.Time) domain.PriceQuote {
+	q := domain.PriceQuote{
+		ProductID:   product.ID,
+		ProductName: product.Name,
+		CouponCode:  coupon,
+		BasePrice:   product.Price,
+		Applied:     make([]domain.AppliedPromotion, 0),
+		Skipped:     make([]domain.SkippedPromotion, 0),
+		QuotedAt:    now,
+	}
+	couponFound := false
+	var stack, exclusive []domain.Promotion
+	for _, p := range promotions {
+		if p.CouponCode != "" {
+			if p.CouponCode != coupon {
+				continue
+			}
+			couponFound = true
+		}
+		if !p.AppliesTo(product) {
+			continue
+		}
+		if reason := unavailable(p, now); reason != "" {
+			q.Skipped = append(q.Skipped, skipped(p, reason))
+			continue
+		}
+		if p.CouponCode != "" && p.Type == domain.DiscountFixed {
+			if spent[p.ID] {
+				q.Skipped = append(q.Skipped, skipped(p, "coupon already applied to another line"))
+				continue
+			}
+			if quantity > 1 {
+				p.Value /= int64(quantity)
+			}
+		}
+		if p.Stackable {
+			stack = append(stack, p)
+		} else {
+			exclusive = append(exclusive, p)
+		}
+	}
+	if coupon != "" && !couponFound {
+		q.Skipped = append(q.Skipped, domain.SkippedPromotion{Name: coupon, Reason: "coupon does not apply to this product"})
+	}
+
+	price := product.Price
+	stacked := make([]domain.AppliedPromotion, 0, len(stack))
+	for _, p := range stack {
+		amount := p.Discount(price)
+		price -= amount
+		stacked = append(stacked, applied(p, amount))
+	}
+	best := -1
+	var bestAmount int64
+	for i, p := range exclusive {
+		if amount := p.Discount(product.Price); best < 0 || amount > bestAmount {
+			best, bestAmount = i, amount
+		}
+	}
+
+	exclusiveWins := best >= 0 && bestAmount > product.Price-price
+	winners := stack
+	if exclusiveWins {
+		winners = exclusive[best : best+1]
+		q.Applied = append(q.Applied, applied(exclusive[best], bestAmount))
+		for _, p := range stack {
+			q.Skipped = append(q.Skipped, skipped(p, "a promotion that does not stack gives a better discount"))
+		}
+		price = product.Price - bestAmount
+	} else {
+		q.Applied = append(q.Applied, stacked...)
+	}
+	for i, p := range exclusive {
+		if !exclusiveWins || i != best {
+			q.Skipped = append(q.Skipped, skipped(p, "does not stack and a better discount applies"))
+		}
+	}
+	for _, p := range winners {
+		if coupon != "" && p.CouponCode == coupon {
+			q.CouponApplied = true
+			if p.Type == domain.DiscountFixed {
+				spent[p.ID] = true
+			}
+		}
+	}
+	q.Discount = product.Price - price
+	q.FinalPrice = price
+	return q
+}
@@ -0,0 +1,284 @@
+package usecase
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+func TestQuote(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	yesterday := now.Add(-24 * time.Hour)
+	product := domain.Product{ID: 1, Name: "Bumi", Price: 100000, AuthorID: 2, Category: "novel"}
+	promotion := func(id int, typ domain.DiscountType, value int64, stackable bool) domain.Promotion {
+		return domain.Promotion{ID: id, Name: "promotion", Type: typ, Value: value, Scope: domain.PromotionScopeAll, Stackable: stackable, Active: true}
+	}
+	coupon := func(p domain.Promotion, code string) domain.Promotion {
+		p.CouponCode = code
+		return p
+	}
+
+	tests := []struct {
+		name       string
+		promotions []domain.Promotion
+		coupon     string
+		quantity   int
+		spent      map[int]bool
+		// wantFinal is the unit price once discounted, wantApplied and
+		// wantSkipped the ids of the promotions applied and skipped
+		wantFinal   int64
+		wantApplied []int
+		wantSkipped []int
+		wantCoupon  bool
+		wantSpent   map[int]bool
+	}{
+		{
+			name:        "no promotion",
+			quantity:    1,
+			wantFinal:   100000,
+			wantApplied: []int{},
+			wantSkipped: []int{},
+		},
+		{
+			name: "stackable promotions apply on the discounted price",
+			promotions: []domain.Promotion{
+				promotion(1, domain.DiscountPercent, 10, true),
+				promotion(2, domain.DiscountFixed, 5000, true),
+			},
+			quantity:    1,
+			wantFinal:   85000,
+			wantApplied: []int{1, 2},
+			wantSkipped: []int{},
+		},
+		{
+			name: "stackable promotions apply in priority order",
+			promotions: []domain.Promotion{
+				promotion(2, domain.DiscountFixed, 5000, true),
+				promotion(1, domain.DiscountPercent, 10, true),
+			},
+			quantity:    1,
+			wantFinal:   85500,
+			wantApplied: []int{2, 1},
+			wantSkipped: []int{},
+		},
+		{
+			name: "a discount never goes below 0",
+			promotions: []domain.Promotion{
+				promotion(1, domain.DiscountFixed, 80000, true),
+				promotion(2, domain.DiscountFixed, 80000, true),
+			},
+			quantity:    1,
+			wantFinal:   0,
+			wantApplied: []int{1, 2},
+			wantSkipped: []int{},
+		},
+		{
+			name: "exclusive wins over a smaller stack",
+			promotions: []domain.Promotion{
+				promotion(1, domain.DiscountPercent, 10, true),
+				promotion(2, domain.DiscountFixed, 5000, true),
+				promotion(3, domain.DiscountPercent, 20, false),
+			},
+			quantity:    1,
+			wantFinal:   80000,
+			wantApplied: []int{3},
+			wantSkipped: []int{1, 2},
+		},
+		{
+			name: "stack wins over a smaller exclusive",
+			promotions: []domain.Promotion{
+				promotion(1, domain.DiscountPercent, 10, true),
+				promotion(2, domain.DiscountPercent, 10, true),
+				promotion(3, domain.DiscountPercent, 15, false),
+			},
+			quantity:    1,
+			wantFinal:   81000,
+			wantApplied: []int{1, 2},
+			wantSkipped: []int{3},
+		},
+		{
+			name: "a tie keeps the stack",
+			promotions: []domain.Promotion{
+				promotion(1, domain.DiscountPercent, 10, true),
+				promotion(2, domain.DiscountFixed, 10000, false),
+			},
+			quantity:    1,
+			wantFinal:   90000,
+			wantApplied: []int{1},
+			wantSkipped: []int{2},
+		},
+		{
+			name: "the best exclusive wins",
+			promotions: []domain.Promotion{
+				promotion(1, domain.DiscountPercent, 15, false),
+				promotion(2, domain.DiscountFixed, 20000, false),
+			},
+			quantity:    1,
+			wantFinal:   80000,
+			wantApplied: []int{2},
+			wantSkipped: []int{1},
+		},
+		{
+			name: "out of scope promotions are ignored",
+			promotions: []domain.Promotion{
+				{ID: 1, Type: domain.DiscountPercent, Value: 10, Scope: domain.PromotionScopeProduct, ScopeValue: "9", Stackable: true},
+				{ID: 2, Type: domain.DiscountPercent, Value: 10, Scope: domain.PromotionScopeAuthor, ScopeValue: "2", Stackable: true},
+				{ID: 3, Type: domain.DiscountPercent, Value: 10, Scope: domain.PromotionScopeCategory, ScopeValue: "poetry", Stackable: true},
+			},
+			quantity:    1,
+			wantFinal:   90000,
+			wantApplied: []int{2},
+			wantSkipped: []int{},
+		},
+		{
+			name: "expired and used up promotions are skipped",
+			promotions: []domain.Promotion{
+				{ID: 1, Type: domain.DiscountPercent, Value: 10, Scope: domain.PromotionScopeAll, EndsAt: &yesterday},
+				{ID: 2, Type: domain.DiscountPercent, Value: 10, Scope: domain.PromotionScopeAll, UsageLimit: 5, UsageCount: 5},
+			},
+			quantity:    1,
+			wantFinal:   100000,
+			wantApplied: []int{},
+			wantSkipped: []int{1, 2},
+		},
+		{
+			name: "a coupon applies when its code is given",
+			promotions: []domain.Promotion{
+				coupon(promotion(1, domain.DiscountPercent, 10, true), "SAVE10"),
+			},
+			coupon:      "SAVE10",
+			quantity:    1,
+			wantFinal:   90000,
+			wantApplied: []int{1},
+			wantSkipped: []int{},
+			wantCoupon:  true,
+		},
+		{
+			name: "a coupon is ignored without its code",
+			promotions: []domain.Promotion{
+				coupon(promotion(1, domain.DiscountPercent, 10, true), "SAVE10"),
+			},
+			quantity:    1,
+			wantFinal:   100000,
+			wantApplied: []int{},
+			wantSkipped: []int{},
+		},
+		{
+			name: "an unknown coupon is skipped",
+			promotions: []domain.Promotion{
+				coupon(promotion(1, domain.DiscountPercent, 10, true), "SAVE10"),
+			},
+			coupon:      "SAVE20",
+			quantity:    1,
+			wantFinal:   100000,
+			wantApplied: []int{},
+			wantSkipped: []int{0},
+		},
+		{
+			name: "a coupon beaten by an exclusive is not applied",
+			promotions: []domain.Promotion{
+				coupon(promotion(1, domain.DiscountFixed, 5000, true), "MINUS5K"),
+				promotion(2, domain.DiscountPercent, 20, false),
+			},
+			coupon:      "MINUS5K",
+			quantity:    1,
+			spent:       map[int]bool{},
+			wantFinal:   80000,
+			wantApplied: []int{2},
+			wantSkipped: []int{1},
+			wantSpent:   map[int]bool{},
+		},
+		{
+			name: "a fixed coupon is spread over the units of the line",
+			promotions: []domain.Promotion{
+				coupon(promotion(1, domain.DiscountFixed, 30000, true), "MINUS30K"),
+			},
+			coupon:      "MINUS30K",
+			quantity:    3,
+			spent:       map[int]bool{},
+			wantFinal:   90000,
+			wantApplied: []int{1},
+			wantSkipped: []int{},
+			wantCoupon:  true,
+			wantSpent:   map[int]bool{1: true},
+		},
+		{
+			name: "a fixed coupon spread over the units is rounded down",
+			promotions: []domain.Promotion{
+				coupon(promotion(1, domain.DiscountFixed, 10000, true), "MINUS10K"),
+			},
+			coupon:      "MINUS10K",
+			quantity:    3,
+			spent:       map[int]bool{},
+			wantFinal:   96667,
+			wantApplied: []int{1},
+			wantSkipped: []int{},
+			wantCoupon:  true,
+			wantSpent:   map[int]bool{1: true},
+		},
+		{
+			name: "a spent fixed coupon is skipped",
+			promotions: []domain.Promotion{
+				coupon(promotion(1, domain.DiscountFixed, 30000, true), "MINUS30K"),
+			},
+			coupon:      "MINUS30K",
+			quantity:    1,
+			spent:       map[int]bool{1: true},
+			wantFinal:   100000,
+			wantApplied: []int{},
+			wantSkipped: []int{1},
+			wantSpent:   map[int]bool{1: true},
+		},
+		{
+			name: "a percent coupon is never spent",
+			promotions: []domain.Promotion{
+				coupon(promotion(1, domain.DiscountPercent, 10, true), "SAVE10"),
+			},
+			coupon:      "SAVE10",
+			quantity:    2,
+			spent:       map[int]bool{},
+			wantFinal:   90000,
+			wantApplied: []int{1},
+			wantSkipped: []int{},
+			wantCoupon:  true,
+			wantSpent:   map[int]bool{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spent := tt.spent
+			if spent == nil {
+				spent = map[int]bool{}
+			}
+			q := quote(product, tt.promotions, tt.coupon, tt.quantity, spent, now)
+			if q.FinalPrice != tt.wantFinal {
+				t.Fatalf("FinalPrice = %d, want %d", q.FinalPrice, tt.wantFinal)
+			}
+			if q.Discount != product.Price-tt.wantFinal {
+				t.Fatalf("Discount = %d, want %d", q.Discount, product.Price-tt.wantFinal)
+			}
+			appliedIDs := []int{}
+			for _, a := range q.Applied {
+				appliedIDs = append(appliedIDs, a.PromotionID)
+			}
+			if !reflect.DeepEqual(appliedIDs, tt.wantApplied) {
+				t.Fatalf("Applied = %v, want %v", appliedIDs, tt.wantApplied)
+			}
+			skippedIDs := []int{}
+			for _, s := range q.Skipped {
+				skippedIDs = append(skippedIDs, s.PromotionID)
+			}
+			if !reflect.DeepEqual(skippedIDs, tt.wantSkipped) {
+				t.Fatalf("Skipped = %v, want %v", skippedIDs, tt.wantSkipped)
+			}
+			if q.CouponApplied != tt.wantCoupon {
+				t.Fatalf("CouponApplied = %v, want %v", q.CouponApplied, tt.wantCoupon)
+			}
+			if tt.wantSpent != nil && !reflect.DeepEqual(spent, tt.wantSpent) {
+				t.Fatalf("spent = %v, want %v", spent, tt.wantSpent)
+			}
+		})
+	}
+}
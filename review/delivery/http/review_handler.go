@@ -0,0 +1,195 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+// ReviewHandler represent the httphandler for review
+type ReviewHandler struct {
+	RUsecase domain.ReviewUsecase
+}
+
+// NewReviewHandler will initialize the review endpoint
+func NewReviewHandler(e *echo.Echo, us domain.ReviewUsecase, auth echo.MiddlewareFunc) {
+	handler := &ReviewHandler{
+		RUsecase: us,
+	}
+	e.GET("/product/:productId/review", handler.FetchByProduct)
+	e.POST("/product/:productId/review", handler.Store, auth)
+	e.PUT("/product/:productId/review", handler.Update, auth)
+	e.DELETE("/review/:reviewId", handler.Delete, auth)
+	e.POST("/review/:reviewId/helpful", handler.VoteHelpful, auth)
+	e.GET("/user/me/review", handler.FetchMine, auth)
+	e.GET("/admin/review", handler.Fetch, auth)
+	e.PUT("/admin/review/:reviewId/status", handler.Moderate, auth)
+}
+
+// FetchByProduct will fetch the approved reviews of a product
+func (r *ReviewHandler) FetchByProduct(c echo.Context) error {
+	productID, _ := strconv.Atoi(c.Param("productId"))
+	ctx := c.Request().Context()
+	reviews, err := r.RUsecase.Fetch(ctx, domain.ReviewFilter{ProductID: productID, Status: domain.ReviewApproved})
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: reviews})
+}
+
+// FetchMine will fetch the reviews of the authenticated user, whatever their status
+func (r *ReviewHandler) FetchMine(c echo.Context) error {
+	ctx := c.Request().Context()
+	principal, _ := domain.PrincipalFromContext(ctx)
+	userID, ok := principal.UserID()
+	if !ok {
+		return failed(c, domain.ErrForbidden)
+	}
+	reviews, err := r.RUsecase.Fetch(ctx, domain.ReviewFilter{UserID: userID})
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: reviews})
+}
+
+// Fetch will fetch the reviews to moderate, filtered by the status and product_id query params
+func (r *ReviewHandler) Fetch(c echo.Context) error {
+	productID, _ := strconv.Atoi(c.QueryParam("product_id"))
+	filter := domain.ReviewFilter{
+		ProductID: productID,
+		Status:    domain.ReviewStatus(c.QueryParam("status")),
+	}
+	ctx := c.Request().Context()
+	reviews, err := r.RUsecase.Fetch(ctx, filter)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: reviews})
+}
+
+func (r *ReviewHandler) bind(c echo.Context) (review domain.Review, err error) {
+	if err = c.Bind(&review); err != nil {
+		return
+	}
+	review.ProductID, _ = strconv.Atoi(c.Param("productId"))
+	return
+}
+
+// Store will add the review of the authenticated user on the product
+func (r *ReviewHandler) Store(c echo.Context) error {
+	review, err := r.bind(c)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&review); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	if err = r.RUsecase.Store(ctx, &review); err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusCreated, response.ResponseSuccess{Success: true, Data: review})
+}
+
+// Update will replace the review of the authenticated user on the product
+func (r *ReviewHandler) Update(c echo.Context) error {
+	review, err := r.bind(c)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&review); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	if err = r.RUsecase.Update(ctx, &review); err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: review})
+}
+
+// Delete will remove a review
+func (r *ReviewHandler) Delete(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("reviewId"))
+	ctx := c.Request().Context()
+	if err := r.RUsecase.Delete(ctx, id); err != nil {
+		return failed(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// VoteHelpful will count the authenticated user finding the review helpful
+func (r *ReviewHandler) VoteHelpful(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("reviewId"))
+	ctx := c.Request().Context()
+	if err := r.RUsecase.VoteHelpful(ctx, id); err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: nil})
+}
+
+// Moderate will approve or reject the review as given in the request body
+func (r *ReviewHandler) Moderate(c echo.Context) (err error) {
+	id, _ := strconv.Atoi(c.Param("reviewId"))
+	var moderation domain.ReviewModeration
+	if err = c.Bind(&moderation); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&moderation); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	if err = r.RUsecase.Moderate(ctx, id, &moderation); err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: nil})
+}
+
+func isRequestValid(m interface{}) (bool, error) {
+	validate := validator.New()
+	err := validate.Struct(m)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// failed write the error response of err, a denied permission is described with a problem detail
+func failed(c echo.Context, err error) error {
+	status := getStatusCode(err)
+	if status == http.StatusForbidden {
+		c.Response().Header().Set(echo.HeaderContentType, response.ProblemContentType)
+		return c.JSON(status, response.NewProblem(status, err.Error()))
+	}
+	return c.JSON(status, response.ResponseFailed{Success: false, Message: err.Error()})
+}
+
+func getStatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	logrus.Error(err)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrBadParamInput):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
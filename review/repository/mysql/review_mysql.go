@@ -0,0 +1,221 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/mysqlerr"
+	"github.com/wdwiramadhan/bookhub-api/helper/sqltx"
+)
+
+const selectReview = `SELECT id, product_id, user_id, rating, title, body, status, helpful_count, updated_at, created_at FROM review`
+
+// mysqlReviewRepository represent the connection database struct
+type mysqlReviewRepository struct {
+	Conn *sql.DB
+}
+
+// NewMysqlReviewRepository will create an object that represent the review.Repository interface
+func NewMysqlReviewRepository(Conn *sql.DB) domain.ReviewRepository {
+	return &mysqlReviewRepository{Conn: Conn}
+}
+
+func (m *mysqlReviewRepository) fetch(ctx context.Context, query string, args ...interface{}) (result []domain.Review, err error) {
+	rows, err := sqltx.From(ctx, m.Conn).QueryContext(ctx, query, args...)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.Review, 0)
+	for rows.Next() {
+		t := domain.Review{}
+		err = rows.Scan(
+			&t.ID,
+			&t.ProductID,
+			&t.UserID,
+			&t.Rating,
+			&t.Title,
+			&t.Body,
+			&t.Status,
+			&t.HelpfulCount,
+			&t.UpdatedAt,
+			&t.CreatedAt,
+		)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+func (m *mysqlReviewRepository) getOne(ctx context.Context, query string, args ...interface{}) (res domain.Review, err error) {
+	list, err := m.fetch(ctx, query, args...)
+	if err != nil {
+		return
+	}
+	if len(list) == 0 {
+		return res, domain.ErrNotFound
+	}
+	return list[0], nil
+}
+
+// Fetch list the most helpful reviews first
+func (m *mysqlReviewRepository) Fetch(ctx context.Context, filter domain.ReviewFilter) ([]domain.Review, error) {
+	query := selectReview
+	conds := []string{}
+	args := []interface{}{}
+	if filter.ProductID != 0 {
+		conds = append(conds, "product_id=?")
+		args = append(args, filter.ProductID)
+	}
+	if filter.UserID != 0 {
+		conds = append(conds, "user_id=?")
+		args = append(args, filter.UserID)
+	}
+	if filter.Status != "" {
+		conds = append(conds, "status=?")
+		args = append(args, filter.Status)
+	}
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY helpful_count DESC, created_at DESC"
+	return m.fetch(ctx, query, args...)
+}
+
+func (m *mysqlReviewRepository) GetByID(ctx context.Context, id int) (domain.Review, error) {
+	return m.getOne(ctx, selectReview+` WHERE id=?`, id)
+}
+
+func (m *mysqlReviewRepository) GetByUserAndProduct(ctx context.Context, userID int, productID int) (domain.Review, error) {
+	return m.getOne(ctx, selectReview+` WHERE user_id=? AND product_id=?`, userID, productID)
+}
+
+// withTx run fn in a transaction, rolled back when fn fails. fn joins the
+// transaction of ctx when there is one
+func (m *mysqlReviewRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) (err error) {
+	if tx, ok := sqltx.Tx(ctx); ok {
+		return fn(tx)
+	}
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(); errRollback != nil {
+				logrus.Error(errRollback)
+			}
+		}
+	}()
+	if err = fn(tx); err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+// refreshRating recompute the rating aggregates of the product from its approved reviews
+func refreshRating(ctx context.Context, tx *sql.Tx, productID int) error {
+	query := `UPDATE product SET
+		rating_average=(SELECT COALESCE(AVG(rating), 0) FROM review WHERE product_id=? AND status=?),
+		rating_count=(SELECT COUNT(*) FROM review WHERE product_id=? AND status=?),
+		updated_at=? WHERE id=?`
+	_, err := tx.ExecContext(ctx, query, productID, domain.ReviewApproved, productID, domain.ReviewApproved, time.Now(), productID)
+	return err
+}
+
+func (m *mysqlReviewRepository) Store(ctx context.Context, r *domain.Review) error {
+	query := `INSERT INTO review (product_id, user_id, rating, title, body, status, helpful_count, updated_at, created_at) VALUES(?,?,?,?,?,?,0,?,?)`
+	now := time.Now()
+	result, err := sqltx.From(ctx, m.Conn).ExecContext(ctx, query, r.ProductID, r.UserID, r.Rating, r.Title, r.Body, r.Status, now, now)
+	if err != nil {
+		if mysqlerr.IsDuplicate(err) {
+			return domain.ErrConflict
+		}
+		return err
+	}
+	lastID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	r.ID = int(lastID)
+	r.UpdatedAt = now
+	r.CreatedAt = now
+	return nil
+}
+
+func (m *mysqlReviewRepository) Update(ctx context.Context, r *domain.Review) error {
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		query := `UPDATE review SET rating=?, title=?, body=?, status=?, updated_at=? WHERE id=?`
+		r.UpdatedAt = time.Now()
+		if _, err := tx.ExecContext(ctx, query, r.Rating, r.Title, r.Body, r.Status, r.UpdatedAt, r.ID); err != nil {
+			return err
+		}
+		return refreshRating(ctx, tx, r.ProductID)
+	})
+}
+
+// productOf return the product of a review, locking the review until the end of the transaction
+func productOf(ctx context.Context, tx *sql.Tx, id int) (productID int, err error) {
+	err = tx.QueryRowContext(ctx, `SELECT product_id FROM review WHERE id=? FOR UPDATE`, id).Scan(&productID)
+	if err == sql.ErrNoRows {
+		return 0, domain.ErrNotFound
+	}
+	return
+}
+
+func (m *mysqlReviewRepository) UpdateStatus(ctx context.Context, id int, status domain.ReviewStatus) error {
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		productID, err := productOf(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		query := `UPDATE review SET status=?, updated_at=? WHERE id=?`
+		if _, err = tx.ExecContext(ctx, query, status, time.Now(), id); err != nil {
+			return err
+		}
+		return refreshRating(ctx, tx, productID)
+	})
+}
+
+func (m *mysqlReviewRepository) Delete(ctx context.Context, id int) error {
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		productID, err := productOf(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, `DELETE FROM review_vote WHERE review_id=?`, id); err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, `DELETE FROM review WHERE id=?`, id); err != nil {
+			return err
+		}
+		return refreshRating(ctx, tx, productID)
+	})
+}
+
+func (m *mysqlReviewRepository) AddHelpfulVote(ctx context.Context, id int, userID int) error {
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		query := `INSERT INTO review_vote (review_id, user_id, created_at) VALUES(?,?,?)`
+		if _, err := tx.ExecContext(ctx, query, id, userID, time.Now()); err != nil {
+			if mysqlerr.IsDuplicate(err) {
+				return domain.ErrConflict
+			}
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `UPDATE review SET helpful_count=helpful_count+1 WHERE id=?`, id)
+		return err
+	})
+}
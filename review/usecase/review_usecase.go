@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// ReviewUsecase represent the review use case struct
+type ReviewUsecase struct {
+	reviewRepo     domain.ReviewRepository
+	productRepo    domain.ProductRepository
+	listeners      []domain.CatalogListener
+	contextTimeout time.Duration
+}
+
+// NewReviewUsecase will create new a review usecase object representation of domain.ReviewUsecase interface,
+// the listeners are notified when the rating of a product changes
+func NewReviewUsecase(r domain.ReviewRepository, p domain.ProductRepository, timeout time.Duration, listeners ...domain.CatalogListener) domain.ReviewUsecase {
+	return &ReviewUsecase{
+		reviewRepo:     r,
+		productRepo:    p,
+		listeners:      listeners,
+		contextTimeout: timeout,
+	}
+}
+
+// currentUser return the id of the registered user calling, only users may write reviews
+func currentUser(ctx context.Context) (int, error) {
+	p, ok := domain.PrincipalFromContext(ctx)
+	if !ok {
+		return 0, domain.ErrUnauthorized
+	}
+	id, ok := p.UserID()
+	if !ok {
+		return 0, domain.ErrForbidden
+	}
+	return id, nil
+}
+
+// canModerate report whether the caller may see and change the reviews of anybody
+func canModerate(ctx context.Context) bool {
+	p, ok := domain.PrincipalFromContext(ctx)
+	return ok && p.Can(domain.PermissionReviewModerate)
+}
+
+func (u *ReviewUsecase) ratingChanged(ctx context.Context, productID int) {
+	for _, l := range u.listeners {
+		l.ProductChanged(ctx, productID)
+	}
+}
+
+// Fetch will get the reviews matching the filter, only the approved ones
+// are public, authors see their own and moderators see everything
+func (u *ReviewUsecase) Fetch(c context.Context, filter domain.ReviewFilter) (res []domain.Review, err error) {
+	if filter.Status != domain.ReviewApproved && !canModerate(c) {
+		userID, errUser := currentUser(c)
+		if errUser != nil {
+			return nil, errUser
+		}
+		if filter.UserID != userID {
+			return nil, domain.ErrForbidden
+		}
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	return u.reviewRepo.Fetch(ctx, filter)
+}
+
+// Store will add the review of the calling user, it awaits moderation
+func (u *ReviewUsecase) Store(c context.Context, r *domain.Review) (err error) {
+	userID, err := currentUser(c)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if _, err = u.productRepo.GetByID(ctx, r.ProductID); err != nil {
+		return
+	}
+	_, err = u.reviewRepo.GetByUserAndProduct(ctx, userID, r.ProductID)
+	if err == nil {
+		return domain.ErrConflict
+	}
+	if err != domain.ErrNotFound {
+		return
+	}
+	r.UserID = userID
+	r.Status = domain.ReviewPending
+	r.HelpfulCount = 0
+	return u.reviewRepo.Store(ctx, r)
+}
+
+// Update will replace the review of the calling user on the product, the
+// edited review awaits moderation again
+func (u *ReviewUsecase) Update(c context.Context, r *domain.Review) (err error) {
+	userID, err := currentUser(c)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	existing, err := u.reviewRepo.GetByUserAndProduct(ctx, userID, r.ProductID)
+	if err != nil {
+		return
+	}
+	existing.Rating = r.Rating
+	existing.Title = r.Title
+	existing.Body = r.Body
+	existing.Status = domain.ReviewPending
+	if err = u.reviewRepo.Update(ctx, &existing); err != nil {
+		return
+	}
+	*r = existing
+	u.ratingChanged(ctx, r.ProductID)
+	return
+}
+
+// Delete will remove a review, by its author or a moderator
+func (u *ReviewUsecase) Delete(c context.Context, id int) (err error) {
+	if _, ok := domain.PrincipalFromContext(c); !ok {
+		return domain.ErrUnauthorized
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	existing, err := u.reviewRepo.GetByID(ctx, id)
+	if err != nil {
+		return
+	}
+	if !canModerate(c) {
+		if userID, errUser := currentUser(c); errUser != nil || userID != existing.UserID {
+			return domain.ErrForbidden
+		}
+	}
+	if err = u.reviewRepo.Delete(ctx, id); err != nil {
+		return
+	}
+	u.ratingChanged(ctx, existing.ProductID)
+	return
+}
+
+// Moderate will approve or reject a review
+func (u *ReviewUsecase) Moderate(c context.Context, id int, m *domain.ReviewModeration) (err error) {
+	if err = domain.Authorize(c, domain.PermissionReviewModerate); err != nil {
+		return
+	}
+	if !m.Status.IsValid() {
+		return domain.ErrBadParamInput
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	existing, err := u.reviewRepo.GetByID(ctx, id)
+	if err != nil {
+		return
+	}
+	if err = u.reviewRepo.UpdateStatus(ctx, id, m.Status); err != nil {
+		return
+	}
+	u.ratingChanged(ctx, existing.ProductID)
+	return
+}
+
+// VoteHelpful will count the calling user finding an approved review helpful,
+// once per user and never on their own review
+func (u *ReviewUsecase) VoteHelpful(c context.Context, id int) (err error) {
+	userID, err := currentUser(c)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	existing, err := u.reviewRepo.GetByID(ctx, id)
+	if err != nil {
+		return
+	}
+	if existing.Status != domain.ReviewApproved {
+		return domain.ErrNotFound
+	}
+	if existing.UserID == userID {
+		return domain.ErrForbidden
+	}
+	return u.reviewRepo.AddHelpfulVote(ctx, id, userID)
+}
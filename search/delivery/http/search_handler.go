@@ -0,0 +1,85 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/negotiate"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+)
+
+// SearchHandler represent the httphandler for search
+type SearchHandler struct {
+	SUsecase domain.SearchUsecase
+	CUsecase domain.CurrencyUsecase
+}
+
+// NewSearchHandler will initialize the search endpoint, the prices of the
+// products found are converted with cu into the requested currency
+func NewSearchHandler(e *echo.Echo, us domain.SearchUsecase, cu domain.CurrencyUsecase) {
+	handler := &SearchHandler{
+		SUsecase: us,
+		CUsecase: cu,
+	}
+	e.GET("/search", handler.Search)
+}
+
+// Search will search the products and authors by the q query param, prices
+// are in the currency query param or else the one negotiated with the
+// Accept-Currency header
+func (s *SearchHandler) Search(c echo.Context) error {
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	q := domain.SearchQuery{
+		Q:     c.QueryParam("q"),
+		Limit: limit,
+	}
+	ctx := c.Request().Context()
+	hits, err := s.SUsecase.Search(ctx, q)
+	if err != nil {
+		return c.JSON(getStatusCode(err), response.ResponseFailed{Success: false, Message: err.Error()})
+	}
+	if err = s.localize(c, hits); err != nil {
+		return c.JSON(getStatusCode(err), response.ResponseFailed{Success: false, Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: hits})
+}
+
+// localize convert the price of the products found
+func (s *SearchHandler) localize(c echo.Context, hits []domain.SearchHit) error {
+	products := []domain.Product{}
+	for _, hit := range hits {
+		if hit.Product != nil {
+			products = append(products, *hit.Product)
+		}
+	}
+	if _, err := negotiate.Localize(c, s.CUsecase, products); err != nil {
+		return err
+	}
+	for i := range hits {
+		if hits[i].Product != nil {
+			hits[i].Product = &products[0]
+			products = products[1:]
+		}
+	}
+	return nil
+}
+
+func getStatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	logrus.Error(err)
+	switch {
+	case errors.Is(err, domain.ErrBadParamInput):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
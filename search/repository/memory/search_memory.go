@@ -0,0 +1,349 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/debounce"
+	"github.com/wdwiramadhan/bookhub-api/helper/text"
+)
+
+const (
+	// applyDelay coalesce the catalog changes happening in a burst into one update
+	applyDelay = 500 * time.Millisecond
+	// applyTimeout bound the time spent reading the changed entries
+	applyTimeout = time.Minute
+
+	prefixWeight = 0.8
+	typoWeight   = 0.5
+)
+
+// fieldBoost weight a match by the field it was found in
+var fieldBoost = map[string]float64{
+	"name":        3,
+	"author":      2,
+	"description": 1,
+}
+
+// docKey identify a document of the index
+type docKey struct {
+	typ string
+	id  int
+}
+
+type posting struct {
+	doc   docKey
+	field string
+	tf    int
+}
+
+// index is an inverted index, its documents are replaced one at a time
+type index struct {
+	docs     map[docKey]domain.SearchHit
+	postings map[string][]posting
+	// terms are the indexed terms in order, for the prefix matches
+	terms []string
+	// docTerms are the terms of every document, to remove its postings
+	docTerms map[docKey][]string
+}
+
+func newIndex() *index {
+	return &index{
+		docs:     map[docKey]domain.SearchHit{},
+		postings: map[string][]posting{},
+		docTerms: map[docKey][]string{},
+	}
+}
+
+// MemorySearchRepository is an in-process inverted index of the catalog, it
+// implements domain.SearchRepository and is kept up to date as a domain.CatalogListener
+type MemorySearchRepository struct {
+	productRepo domain.ProductRepository
+	authorRepo  domain.AuthorRepository
+
+	mu  sync.RWMutex
+	idx *index
+
+	// pendingProducts and pendingAuthors are the changes waiting for the next update
+	pendingMu       sync.Mutex
+	pendingProducts map[int]bool
+	pendingAuthors  map[int]bool
+	apply           *debounce.Debouncer
+}
+
+// NewMemorySearchRepository will create an empty index, call Rebuild to load the catalog
+func NewMemorySearchRepository(p domain.ProductRepository, a domain.AuthorRepository) *MemorySearchRepository {
+	m := &MemorySearchRepository{
+		productRepo:     p,
+		authorRepo:      a,
+		idx:             newIndex(),
+		pendingProducts: map[int]bool{},
+		pendingAuthors:  map[int]bool{},
+	}
+	m.apply = debounce.New(applyDelay, applyTimeout, m.applyPending)
+	return m
+}
+
+// put index the document, replacing its previous version
+func (idx *index) put(hit domain.SearchHit, fields map[string]string) {
+	key := docKey{typ: hit.Type, id: hit.ID}
+	idx.remove(key)
+	idx.docs[key] = hit
+	terms := []string{}
+	for field, value := range fields {
+		tf := map[string]int{}
+		for _, term := range text.Terms(value) {
+			tf[term]++
+		}
+		for term, n := range tf {
+			if _, ok := idx.postings[term]; !ok {
+				i := sort.SearchStrings(idx.terms, term)
+				idx.terms = append(idx.terms, "")
+				copy(idx.terms[i+1:], idx.terms[i:])
+				idx.terms[i] = term
+			}
+			idx.postings[term] = append(idx.postings[term], posting{doc: key, field: field, tf: n})
+			terms = append(terms, term)
+		}
+	}
+	idx.docTerms[key] = terms
+}
+
+// remove drop the document and its postings, the terms left without postings are dropped too
+func (idx *index) remove(key docKey) {
+	if _, ok := idx.docs[key]; !ok {
+		return
+	}
+	for _, term := range idx.docTerms[key] {
+		postings := idx.postings[term][:0]
+		for _, p := range idx.postings[term] {
+			if p.doc != key {
+				postings = append(postings, p)
+			}
+		}
+		if len(postings) > 0 {
+			idx.postings[term] = postings
+			continue
+		}
+		delete(idx.postings, term)
+		if i := sort.SearchStrings(idx.terms, term); i < len(idx.terms) && idx.terms[i] == term {
+			idx.terms = append(idx.terms[:i], idx.terms[i+1:]...)
+		}
+	}
+	delete(idx.docs, key)
+	delete(idx.docTerms, key)
+}
+
+func (idx *index) putProduct(p domain.Product) {
+	idx.put(domain.SearchHit{Type: domain.SearchTypeProduct, ID: p.ID, Product: &p}, map[string]string{
+		"name":        p.Name,
+		"author":      p.Author.Name,
+		"description": p.Description,
+	})
+}
+
+func (idx *index) putAuthor(a domain.Author) {
+	id, _ := strconv.Atoi(a.ID)
+	idx.put(domain.SearchHit{Type: domain.SearchTypeAuthor, ID: id, Author: &a}, map[string]string{
+		"name": a.Name,
+	})
+}
+
+// Rebuild load the whole catalog into a new index
+func (m *MemorySearchRepository) Rebuild(ctx context.Context) error {
+	products, err := m.productRepo.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+	authors, err := m.authorRepo.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	idx := newIndex()
+	for _, p := range products {
+		idx.putProduct(p)
+	}
+	for _, a := range authors {
+		idx.putAuthor(a)
+	}
+
+	m.mu.Lock()
+	m.idx = idx
+	m.mu.Unlock()
+	return nil
+}
+
+// ProductChanged implements domain.CatalogListener
+func (m *MemorySearchRepository) ProductChanged(ctx context.Context, id int) {
+	m.pendingMu.Lock()
+	m.pendingProducts[id] = true
+	m.pendingMu.Unlock()
+	m.apply.Trigger()
+}
+
+// AuthorChanged implements domain.CatalogListener
+func (m *MemorySearchRepository) AuthorChanged(ctx context.Context, id int) {
+	m.pendingMu.Lock()
+	m.pendingAuthors[id] = true
+	m.pendingMu.Unlock()
+	m.apply.Trigger()
+}
+
+// applyPending read the changed products and authors back and replace or
+// remove their documents. The products embed their author so the products
+// of a changed author are reindexed too. The changes failing to be read
+// are kept for the update following the next change
+func (m *MemorySearchRepository) applyPending(ctx context.Context) (err error) {
+	m.pendingMu.Lock()
+	products, authors := m.pendingProducts, m.pendingAuthors
+	m.pendingProducts, m.pendingAuthors = map[int]bool{}, map[int]bool{}
+	m.pendingMu.Unlock()
+
+	for id := range products {
+		p, errGet := m.productRepo.GetByID(ctx, id)
+		if errGet != nil && !errors.Is(errGet, domain.ErrNotFound) {
+			m.pendingMu.Lock()
+			m.pendingProducts[id] = true
+			m.pendingMu.Unlock()
+			err = errGet
+			continue
+		}
+		m.mu.Lock()
+		if errGet != nil {
+			m.idx.remove(docKey{typ: domain.SearchTypeProduct, id: id})
+		} else {
+			m.idx.putProduct(p)
+		}
+		m.mu.Unlock()
+	}
+	for id := range authors {
+		a, errGet := m.authorRepo.GetAuthorById(ctx, id)
+		if errGet != nil && !errors.Is(errGet, domain.ErrNotFound) {
+			m.pendingMu.Lock()
+			m.pendingAuthors[id] = true
+			m.pendingMu.Unlock()
+			err = errGet
+			continue
+		}
+		m.mu.Lock()
+		if errGet != nil {
+			m.idx.remove(docKey{typ: domain.SearchTypeAuthor, id: id})
+			m.mu.Unlock()
+			continue
+		}
+		m.idx.putAuthor(a)
+		books := []domain.Product{}
+		for _, hit := range m.idx.docs {
+			if hit.Product != nil && hit.Product.AuthorID == id {
+				books = append(books, *hit.Product)
+			}
+		}
+		for _, p := range books {
+			p.Author = a
+			m.idx.putProduct(p)
+		}
+		m.mu.Unlock()
+	}
+	return
+}
+
+// expand find the indexed terms matching a query term, exactly, as a prefix or with typos
+func (idx *index) expand(term string) map[string]float64 {
+	matches := map[string]float64{}
+	if _, ok := idx.postings[term]; ok {
+		matches[term] = 1
+	}
+	if len(term) >= 2 {
+		for i := sort.SearchStrings(idx.terms, term); i < len(idx.terms) && strings.HasPrefix(idx.terms[i], term); i++ {
+			if _, ok := matches[idx.terms[i]]; !ok {
+				matches[idx.terms[i]] = prefixWeight
+			}
+		}
+	}
+	if maxTypos := text.MaxTypos(term); maxTypos > 0 {
+		for _, candidate := range idx.terms {
+			if _, ok := matches[candidate]; ok {
+				continue
+			}
+			if d := text.Distance(term, candidate, maxTypos); d <= maxTypos {
+				matches[candidate] = typoWeight / float64(d)
+			}
+		}
+	}
+	return matches
+}
+
+// Search rank the documents by a tf-idf score weighted by field, the share of
+// query terms matched by a document is squared into the score so that
+// documents matching every term come first
+func (m *MemorySearchRepository) Search(ctx context.Context, q domain.SearchQuery) ([]domain.SearchHit, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	idx := m.idx
+
+	queryTerms := text.Terms(q.Q)
+	if len(queryTerms) == 0 || len(idx.docs) == 0 {
+		return []domain.SearchHit{}, nil
+	}
+
+	scores := map[docKey]float64{}
+	matchedQuery := map[docKey]map[int]bool{}
+	matchedTerms := map[docKey]map[string]bool{}
+	n := float64(len(idx.docs))
+	for qi, queryTerm := range queryTerms {
+		for term, weight := range idx.expand(queryTerm) {
+			postings := idx.postings[term]
+			idf := math.Log(1 + n/float64(len(postings)))
+			for _, p := range postings {
+				tf := float64(p.tf)
+				scores[p.doc] += weight * fieldBoost[p.field] * idf * tf / (tf + 1.2)
+				if matchedQuery[p.doc] == nil {
+					matchedQuery[p.doc] = map[int]bool{}
+					matchedTerms[p.doc] = map[string]bool{}
+				}
+				matchedQuery[p.doc][qi] = true
+				matchedTerms[p.doc][term] = true
+			}
+		}
+	}
+
+	hits := make([]domain.SearchHit, 0, len(scores))
+	for doc, score := range scores {
+		coverage := float64(len(matchedQuery[doc])) / float64(len(queryTerms))
+		hit := idx.docs[doc]
+		hit.Score = score * coverage * coverage
+		if hit.Product != nil {
+			p := *hit.Product
+			hit.Product = &p
+		}
+		if hit.Author != nil {
+			a := *hit.Author
+			hit.Author = &a
+		}
+		for term := range matchedTerms[doc] {
+			hit.MatchedTerms = append(hit.MatchedTerms, term)
+		}
+		hits = append(hits, hit)
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].Type != hits[j].Type {
+			return hits[i].Type > hits[j].Type
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	return hits, nil
+}
@@ -0,0 +1,155 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/text"
+)
+
+// mysqlSearchRepository search the catalog with the MySQL FULLTEXT indexes
+// on product(name), product(name, description) and author(name). Every
+// query term also matches as a prefix, tolerating typos is left to the
+// in-memory implementation
+type mysqlSearchRepository struct {
+	Conn *sql.DB
+}
+
+// NewMysqlSearchRepository will create an object that represent the search.Repository interface
+func NewMysqlSearchRepository(Conn *sql.DB) domain.SearchRepository {
+	return &mysqlSearchRepository{Conn: Conn}
+}
+
+// booleanQuery turn the query terms into an optional prefix match of each term
+func booleanQuery(terms []string) string {
+	words := make([]string, len(terms))
+	for i, t := range terms {
+		words[i] = t + "*"
+	}
+	return strings.Join(words, " ")
+}
+
+func (m *mysqlSearchRepository) searchProducts(ctx context.Context, against string, limit int) (result []domain.SearchHit, err error) {
+	query := `SELECT product.id, product.name, product.price, product.author_id, product.description, product.image,
+		product.category, product.tax_class, product.published_year, product.rating_average, product.rating_count, product.available,
+		product.updated_at, product.created_at,
+		author.id, author.name, author.date_of_birth, author.updated_at, author.created_at,
+		MATCH(product.name) AGAINST(? IN BOOLEAN MODE) * 3
+			+ MATCH(author.name) AGAINST(? IN BOOLEAN MODE) * 2
+			+ MATCH(product.name, product.description) AGAINST(? IN BOOLEAN MODE) AS score
+		FROM product JOIN author ON product.author_id = author.id
+		WHERE MATCH(product.name, product.description) AGAINST(? IN BOOLEAN MODE)
+			OR MATCH(author.name) AGAINST(? IN BOOLEAN MODE)
+		ORDER BY score DESC LIMIT ?`
+	rows, err := m.Conn.QueryContext(ctx, query, against, against, against, against, against, limit)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.SearchHit, 0)
+	for rows.Next() {
+		t := domain.Product{}
+		hit := domain.SearchHit{Type: domain.SearchTypeProduct}
+		err = rows.Scan(
+			&t.ID,
+			&t.Name,
+			&t.Price,
+			&t.AuthorID,
+			&t.Description,
+			&t.Image,
+			&t.Category,
+			&t.TaxClass,
+			&t.PublishedYear,
+			&t.RatingAverage,
+			&t.RatingCount,
+			&t.Available,
+			&t.UpdatedAt,
+			&t.CreatedAt,
+			&t.Author.ID,
+			&t.Author.Name,
+			&t.Author.DateOfBirth,
+			&t.Author.UpdatedAt,
+			&t.Author.CreatedAt,
+			&hit.Score,
+		)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		hit.ID = t.ID
+		hit.Product = &t
+		result = append(result, hit)
+	}
+	return result, nil
+}
+
+func (m *mysqlSearchRepository) searchAuthors(ctx context.Context, against string, limit int) (result []domain.SearchHit, err error) {
+	query := `SELECT id, name, date_of_birth, updated_at, created_at,
+		MATCH(name) AGAINST(? IN BOOLEAN MODE) * 3 AS score
+		FROM author WHERE MATCH(name) AGAINST(? IN BOOLEAN MODE)
+		ORDER BY score DESC LIMIT ?`
+	rows, err := m.Conn.QueryContext(ctx, query, against, against, limit)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.SearchHit, 0)
+	for rows.Next() {
+		t := domain.Author{}
+		hit := domain.SearchHit{Type: domain.SearchTypeAuthor}
+		err = rows.Scan(&t.ID, &t.Name, &t.DateOfBirth, &t.UpdatedAt, &t.CreatedAt, &hit.Score)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		hit.ID, _ = strconv.Atoi(t.ID)
+		hit.Author = &t
+		result = append(result, hit)
+	}
+	return result, nil
+}
+
+func (m *mysqlSearchRepository) Search(ctx context.Context, q domain.SearchQuery) ([]domain.SearchHit, error) {
+	terms := text.Terms(q.Q)
+	if len(terms) == 0 {
+		return []domain.SearchHit{}, nil
+	}
+	against := booleanQuery(terms)
+	products, err := m.searchProducts(ctx, against, q.Limit)
+	if err != nil {
+		return nil, err
+	}
+	authors, err := m.searchAuthors(ctx, against, q.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := append(products, authors...)
+	for i := range hits {
+		hits[i].MatchedTerms = terms
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+	if len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	return hits, nil
+}
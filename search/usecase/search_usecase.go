@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/text"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+	// snippetWords is the number of words kept around the first match of a long field
+	snippetWords  = 24
+	highlightPre  = "<em>"
+	highlightPost = "</em>"
+)
+
+// SearchUsecase represent the search use case struct
+type SearchUsecase struct {
+	searchRepo     domain.SearchRepository
+	contextTimeout time.Duration
+}
+
+// NewSearchUsecase will create new a search usecase object representation of domain.SearchUsecase interface
+func NewSearchUsecase(s domain.SearchRepository, timeout time.Duration) domain.SearchUsecase {
+	return &SearchUsecase{
+		searchRepo:     s,
+		contextTimeout: timeout,
+	}
+}
+
+// Search will rank the products and authors matching the query and highlight the matches
+func (s *SearchUsecase) Search(c context.Context, q domain.SearchQuery) (res []domain.SearchHit, err error) {
+	ctx, cancel := context.WithTimeout(c, s.contextTimeout)
+	defer cancel()
+
+	q.Q = strings.TrimSpace(q.Q)
+	if q.Q == "" {
+		return nil, domain.ErrBadParamInput
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultLimit
+	}
+	if q.Limit > maxLimit {
+		q.Limit = maxLimit
+	}
+	res, err = s.searchRepo.Search(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	for i := range res {
+		res[i].Highlights = highlights(&res[i])
+	}
+	return
+}
+
+// highlights build the highlighted snippet of every field of the hit containing a match
+func highlights(hit *domain.SearchHit) map[string]string {
+	fields := map[string]string{}
+	switch {
+	case hit.Product != nil:
+		fields["name"] = hit.Product.Name
+		fields["author"] = hit.Product.Author.Name
+		fields["description"] = hit.Product.Description
+	case hit.Author != nil:
+		fields["name"] = hit.Author.Name
+	}
+	res := map[string]string{}
+	for field, value := range fields {
+		if snippet, ok := highlight(value, hit.MatchedTerms); ok {
+			res[field] = snippet
+		}
+	}
+	return res
+}
+
+// isMatch report whether a word of the text matches one of the terms, exactly or as a prefix
+func isMatch(term string, matched []string) bool {
+	for _, m := range matched {
+		if strings.HasPrefix(term, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// highlight wrap the matching words of s, a long text is cut to a window around its first match
+func highlight(s string, matched []string) (string, bool) {
+	tokens := text.Tokenize(s)
+	first := -1
+	for i, t := range tokens {
+		if isMatch(t.Term, matched) {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return "", false
+	}
+
+	from, to := 0, len(tokens)
+	if len(tokens) > snippetWords {
+		from = first - snippetWords/4
+		if from < 0 {
+			from = 0
+		}
+		to = from + snippetWords
+		if to > len(tokens) {
+			to = len(tokens)
+		}
+	}
+	start, end := 0, len(s)
+	if from > 0 {
+		start = tokens[from].Start
+	}
+	if to < len(tokens) {
+		end = tokens[to-1].End
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, t := range tokens[from:to] {
+		if !isMatch(t.Term, matched) {
+			continue
+		}
+		b.WriteString(html.EscapeString(s[pos:t.Start]))
+		b.WriteString(highlightPre)
+		b.WriteString(html.EscapeString(s[t.Start:t.End]))
+		b.WriteString(highlightPost)
+		pos = t.End
+	}
+	b.WriteString(html.EscapeString(s[pos:end]))
+	if end < len(s) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
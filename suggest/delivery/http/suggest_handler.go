@@ -0,0 +1,50 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+)
+
+// SuggestHandler represent the httphandler for suggestions
+type SuggestHandler struct {
+	SUsecase domain.SuggestUsecase
+}
+
+// NewSuggestHandler will initialize the suggest endpoint
+func NewSuggestHandler(e *echo.Echo, us domain.SuggestUsecase) {
+	handler := &SuggestHandler{
+		SUsecase: us,
+	}
+	e.GET("/suggest", handler.Suggest)
+}
+
+// Suggest will complete the q query param with product and author names
+func (s *SuggestHandler) Suggest(c echo.Context) error {
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	ctx := c.Request().Context()
+	suggestions, err := s.SUsecase.Suggest(ctx, c.QueryParam("q"), limit)
+	if err != nil {
+		return c.JSON(getStatusCode(err), response.ResponseFailed{Success: false, Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: suggestions})
+}
+
+func getStatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	logrus.Error(err)
+	switch {
+	case errors.Is(err, domain.ErrBadParamInput):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
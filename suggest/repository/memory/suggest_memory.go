@@ -0,0 +1,405 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/debounce"
+	"github.com/wdwiramadhan/bookhub-api/helper/text"
+)
+
+const (
+	// applyDelay coalesce the catalog changes happening in a burst into one update
+	applyDelay = 500 * time.Millisecond
+	// applyTimeout bound the time spent reading the changed entries
+	applyTimeout = time.Minute
+	// topK is the number of suggestions kept on every node, it bounds the limit of a lookup
+	topK = 20
+	// wordStartWeight rank the matches on a later word below the matches on the first word
+	wordStartWeight = 0.5
+)
+
+// suggestionKey identify a suggestion of the trie
+type suggestionKey struct {
+	typ string
+	id  int
+}
+
+// entry is a candidate suggestion with its weight at a node
+type entry struct {
+	suggestion suggestionKey
+	weight     float64
+}
+
+// node is a node of the trie, it keeps its best suggestions so that a lookup
+// costs the length of the prefix whatever the size of the catalog
+type node struct {
+	children map[rune]*node
+	// ends are the entries whose key ends at the node, with the best entries
+	// of the children they let top be recomputed when an entry is removed
+	ends []entry
+	top  []entry
+}
+
+// trie is a prefix index whose suggestions are replaced one at a time
+type trie struct {
+	root        *node
+	suggestions map[suggestionKey]domain.Suggestion
+	weights     map[suggestionKey]float64
+	// books are the number of products of every author, and authors the author of every product
+	books   map[int]int
+	authors map[int]int
+}
+
+func newTrie() *trie {
+	return &trie{
+		root:        &node{},
+		suggestions: map[suggestionKey]domain.Suggestion{},
+		weights:     map[suggestionKey]float64{},
+		books:       map[int]int{},
+		authors:     map[int]int{},
+	}
+}
+
+// MemorySuggestRepository is an in-process prefix index of the product names
+// and author names, it implements domain.SuggestRepository and is kept up to
+// date as a domain.CatalogListener
+type MemorySuggestRepository struct {
+	productRepo domain.ProductRepository
+	authorRepo  domain.AuthorRepository
+
+	mu sync.RWMutex
+	t  *trie
+
+	// pendingProducts and pendingAuthors are the changes waiting for the next update
+	pendingMu       sync.Mutex
+	pendingProducts map[int]bool
+	pendingAuthors  map[int]bool
+	apply           *debounce.Debouncer
+}
+
+// NewMemorySuggestRepository will create an empty index, call Rebuild to load the catalog
+func NewMemorySuggestRepository(p domain.ProductRepository, a domain.AuthorRepository) *MemorySuggestRepository {
+	m := &MemorySuggestRepository{
+		productRepo:     p,
+		authorRepo:      a,
+		t:               newTrie(),
+		pendingProducts: map[int]bool{},
+		pendingAuthors:  map[int]bool{},
+	}
+	m.apply = debounce.New(applyDelay, applyTimeout, m.applyPending)
+	return m
+}
+
+// normalize lower case s and keep its words separated by a single space
+func normalize(s string) string {
+	tokens := text.Tokenize(s)
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = t.Term
+	}
+	return strings.Join(words, " ")
+}
+
+// keys are the keys a text is indexed by, its whole text and each of its
+// later words, with the factor of the weight of each
+func keys(s string) map[string]float64 {
+	key := normalize(s)
+	res := map[string]float64{key: 1}
+	for i := 1; i < len(key); i++ {
+		if key[i-1] == ' ' {
+			if _, ok := res[key[i:]]; !ok {
+				res[key[i:]] = wordStartWeight
+			}
+		}
+	}
+	return res
+}
+
+func (t *trie) insert(key string, e entry) {
+	n := t.root
+	n.offer(e)
+	for _, r := range key {
+		if n.children == nil {
+			n.children = map[rune]*node{}
+		}
+		child, ok := n.children[r]
+		if !ok {
+			child = &node{}
+			n.children[r] = child
+		}
+		n = child
+		n.offer(e)
+	}
+	n.ends = append(n.ends, e)
+}
+
+// delete remove the entries of the suggestion indexed by key, the nodes of
+// the path it was among the best of get their best entries recomputed
+func (t *trie) delete(key string, suggestion suggestionKey) {
+	path := []*node{t.root}
+	n := t.root
+	for _, r := range key {
+		child, ok := n.children[r]
+		if !ok {
+			return
+		}
+		n = child
+		path = append(path, n)
+	}
+	ends := n.ends[:0]
+	for _, e := range n.ends {
+		if e.suggestion != suggestion {
+			ends = append(ends, e)
+		}
+	}
+	n.ends = ends
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i].has(suggestion) {
+			path[i].recompute()
+		}
+	}
+}
+
+// offer keep the entry if it is one of the best of the node
+func (n *node) offer(e entry) {
+	for i := range n.top {
+		if n.top[i].suggestion == e.suggestion {
+			if e.weight > n.top[i].weight {
+				n.top[i].weight = e.weight
+				n.sort()
+			}
+			return
+		}
+	}
+	if len(n.top) == topK && e.weight <= n.top[topK-1].weight {
+		return
+	}
+	n.top = append(n.top, e)
+	n.sort()
+	if len(n.top) > topK {
+		n.top = n.top[:topK]
+	}
+}
+
+func (n *node) has(suggestion suggestionKey) bool {
+	for _, e := range n.top {
+		if e.suggestion == suggestion {
+			return true
+		}
+	}
+	return false
+}
+
+// recompute rebuild the best entries of the node from its own entries and the
+// best entries of its children, which hold the best entries of their subtree
+func (n *node) recompute() {
+	n.top = nil
+	for _, e := range n.ends {
+		n.offer(e)
+	}
+	for _, child := range n.children {
+		for _, e := range child.top {
+			n.offer(e)
+		}
+	}
+}
+
+func (n *node) sort() {
+	sort.SliceStable(n.top, func(i, j int) bool {
+		if n.top[i].weight != n.top[j].weight {
+			return n.top[i].weight > n.top[j].weight
+		}
+		if n.top[i].suggestion.typ != n.top[j].suggestion.typ {
+			return n.top[i].suggestion.typ > n.top[j].suggestion.typ
+		}
+		return n.top[i].suggestion.id < n.top[j].suggestion.id
+	})
+}
+
+// put index the suggestion by its keys, replacing its previous version
+func (t *trie) put(s domain.Suggestion, weight float64) {
+	t.remove(s.Type, s.ID)
+	k := suggestionKey{typ: s.Type, id: s.ID}
+	t.suggestions[k] = s
+	t.weights[k] = weight
+	for key, factor := range keys(s.Text) {
+		t.insert(key, entry{suggestion: k, weight: weight * factor})
+	}
+}
+
+func (t *trie) remove(typ string, id int) {
+	k := suggestionKey{typ: typ, id: id}
+	s, ok := t.suggestions[k]
+	if !ok {
+		return
+	}
+	for key := range keys(s.Text) {
+		t.delete(key, k)
+	}
+	delete(t.suggestions, k)
+	delete(t.weights, k)
+}
+
+// authorWeight rank the authors by their number of books
+func (t *trie) authorWeight(id int) float64 {
+	return 1 + float64(t.books[id])/10
+}
+
+// putProduct index the product, the authors it moved between are reweighted
+func (t *trie) putProduct(p domain.Product) {
+	t.removeProduct(p.ID)
+	t.put(domain.Suggestion{Type: domain.SearchTypeProduct, ID: p.ID, Text: p.Name}, 1)
+	t.authors[p.ID] = p.AuthorID
+	t.books[p.AuthorID]++
+	t.reweightAuthor(p.AuthorID)
+}
+
+func (t *trie) removeProduct(id int) {
+	t.remove(domain.SearchTypeProduct, id)
+	author, ok := t.authors[id]
+	if !ok {
+		return
+	}
+	delete(t.authors, id)
+	t.books[author]--
+	t.reweightAuthor(author)
+}
+
+func (t *trie) putAuthor(a domain.Author) {
+	id, _ := strconv.Atoi(a.ID)
+	t.put(domain.Suggestion{Type: domain.SearchTypeAuthor, ID: id, Text: a.Name}, t.authorWeight(id))
+}
+
+// reweightAuthor reindex the author if its number of books changed its weight
+func (t *trie) reweightAuthor(id int) {
+	k := suggestionKey{typ: domain.SearchTypeAuthor, id: id}
+	s, ok := t.suggestions[k]
+	if !ok || t.weights[k] == t.authorWeight(id) {
+		return
+	}
+	t.put(s, t.authorWeight(id))
+}
+
+// Rebuild load the whole catalog into a new index, authors weigh by their number of books
+func (m *MemorySuggestRepository) Rebuild(ctx context.Context) error {
+	products, err := m.productRepo.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+	authors, err := m.authorRepo.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := newTrie()
+	for _, p := range products {
+		t.putProduct(p)
+	}
+	for _, a := range authors {
+		t.putAuthor(a)
+	}
+
+	m.mu.Lock()
+	m.t = t
+	m.mu.Unlock()
+	return nil
+}
+
+// ProductChanged implements domain.CatalogListener
+func (m *MemorySuggestRepository) ProductChanged(ctx context.Context, id int) {
+	m.pendingMu.Lock()
+	m.pendingProducts[id] = true
+	m.pendingMu.Unlock()
+	m.apply.Trigger()
+}
+
+// AuthorChanged implements domain.CatalogListener
+func (m *MemorySuggestRepository) AuthorChanged(ctx context.Context, id int) {
+	m.pendingMu.Lock()
+	m.pendingAuthors[id] = true
+	m.pendingMu.Unlock()
+	m.apply.Trigger()
+}
+
+// applyPending read the changed products and authors back and replace or
+// remove their suggestions. The changes failing to be read are kept for the
+// update following the next change
+func (m *MemorySuggestRepository) applyPending(ctx context.Context) (err error) {
+	m.pendingMu.Lock()
+	products, authors := m.pendingProducts, m.pendingAuthors
+	m.pendingProducts, m.pendingAuthors = map[int]bool{}, map[int]bool{}
+	m.pendingMu.Unlock()
+
+	for id := range products {
+		p, errGet := m.productRepo.GetByID(ctx, id)
+		if errGet != nil && !errors.Is(errGet, domain.ErrNotFound) {
+			m.pendingMu.Lock()
+			m.pendingProducts[id] = true
+			m.pendingMu.Unlock()
+			err = errGet
+			continue
+		}
+		m.mu.Lock()
+		if errGet != nil {
+			m.t.removeProduct(id)
+		} else {
+			m.t.putProduct(p)
+		}
+		m.mu.Unlock()
+	}
+	for id := range authors {
+		a, errGet := m.authorRepo.GetAuthorById(ctx, id)
+		if errGet != nil && !errors.Is(errGet, domain.ErrNotFound) {
+			m.pendingMu.Lock()
+			m.pendingAuthors[id] = true
+			m.pendingMu.Unlock()
+			err = errGet
+			continue
+		}
+		m.mu.Lock()
+		if errGet != nil {
+			m.t.remove(domain.SearchTypeAuthor, id)
+		} else {
+			m.t.putAuthor(a)
+		}
+		m.mu.Unlock()
+	}
+	return
+}
+
+// Suggest return the best suggestions starting with the prefix, or having a word starting with it
+func (m *MemorySuggestRepository) Suggest(ctx context.Context, prefix string, limit int) ([]domain.Suggestion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t := m.t
+
+	res := []domain.Suggestion{}
+	key := normalize(prefix)
+	if key == "" {
+		return res, nil
+	}
+	n := t.root
+	for _, r := range key {
+		child, ok := n.children[r]
+		if !ok {
+			return res, nil
+		}
+		n = child
+	}
+	for _, e := range n.top {
+		if len(res) == limit {
+			break
+		}
+		s := t.suggestions[e.suggestion]
+		s.Score = e.weight
+		res = append(res, s)
+	}
+	return res, nil
+}
@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+const (
+	defaultLimit = 8
+	maxLimit     = 20
+)
+
+// SuggestUsecase represent the suggestion use case struct
+type SuggestUsecase struct {
+	suggestRepo    domain.SuggestRepository
+	contextTimeout time.Duration
+}
+
+// NewSuggestUsecase will create new a suggest usecase object representation of domain.SuggestUsecase interface
+func NewSuggestUsecase(s domain.SuggestRepository, timeout time.Duration) domain.SuggestUsecase {
+	return &SuggestUsecase{
+		suggestRepo:    s,
+		contextTimeout: timeout,
+	}
+}
+
+// Suggest will complete the prefix with the best matching product and author names
+func (s *SuggestUsecase) Suggest(c context.Context, prefix string, limit int) ([]domain.Suggestion, error) {
+	ctx, cancel := context.WithTimeout(c, s.contextTimeout)
+	defer cancel()
+
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return nil, domain.ErrBadParamInput
+	}
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	return s.suggestRepo.Suggest(ctx, prefix, limit)
+}
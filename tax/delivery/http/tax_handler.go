@@ -0,0 +1,125 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+// TaxHandler represent the httphandler for tax rates
+type TaxHandler struct {
+	TUsecase domain.TaxUsecase
+}
+
+// NewTaxHandler will initialize the tax rate endpoints
+func NewTaxHandler(e *echo.Echo, us domain.TaxUsecase, auth echo.MiddlewareFunc) {
+	handler := &TaxHandler{
+		TUsecase: us,
+	}
+	e.GET("/tax/rate", handler.FetchRates)
+	e.POST("/admin/tax/rate", handler.StoreRate, auth)
+	e.PUT("/admin/tax/rate/:rateId", handler.UpdateRate, auth)
+	e.DELETE("/admin/tax/rate/:rateId", handler.DeleteRate, auth)
+}
+
+// FetchRates will list the tax rates, of the country query param when given
+func (t *TaxHandler) FetchRates(c echo.Context) error {
+	ctx := c.Request().Context()
+	rates, err := t.TUsecase.FetchRates(ctx, c.QueryParam("country"))
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: rates})
+}
+
+// StoreRate will add the tax rate of the request body
+func (t *TaxHandler) StoreRate(c echo.Context) (err error) {
+	var rate domain.TaxRate
+	if err = c.Bind(&rate); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&rate); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	if err = t.TUsecase.StoreRate(ctx, &rate); err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusCreated, response.ResponseSuccess{Success: true, Data: rate})
+}
+
+// UpdateRate will replace a tax rate with the request body
+func (t *TaxHandler) UpdateRate(c echo.Context) (err error) {
+	id, _ := strconv.Atoi(c.Param("rateId"))
+	var rate domain.TaxRate
+	if err = c.Bind(&rate); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&rate); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	if err = t.TUsecase.UpdateRate(ctx, id, &rate); err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: rate})
+}
+
+// DeleteRate will remove a tax rate
+func (t *TaxHandler) DeleteRate(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("rateId"))
+	ctx := c.Request().Context()
+	if err := t.TUsecase.DeleteRate(ctx, id); err != nil {
+		return failed(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func isRequestValid(m interface{}) (bool, error) {
+	validate := validator.New()
+	err := validate.Struct(m)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// failed write the error response of err, a denied permission is described with a problem detail
+func failed(c echo.Context, err error) error {
+	status := getStatusCode(err)
+	if status == http.StatusForbidden {
+		c.Response().Header().Set(echo.HeaderContentType, response.ProblemContentType)
+		return c.JSON(status, response.NewProblem(status, err.Error()))
+	}
+	return c.JSON(status, response.ResponseFailed{Success: false, Message: err.Error()})
+}
+
+func getStatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	logrus.Error(err)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrBadParamInput):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
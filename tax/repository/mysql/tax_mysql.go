@@ -0,0 +1,117 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/mysqlerr"
+)
+
+const selectTaxRate = `SELECT id, country, region, tax_class, name, rate, updated_at, created_at FROM tax_rate`
+
+// mysqlTaxRepository represent the connection database struct
+type mysqlTaxRepository struct {
+	Conn *sql.DB
+}
+
+// NewMysqlTaxRepository will create an object that represent the tax Repository interface
+func NewMysqlTaxRepository(Conn *sql.DB) domain.TaxRepository {
+	return &mysqlTaxRepository{Conn: Conn}
+}
+
+// fetch read the rates, the DECIMAL column is scanned as text to stay exact
+func (m *mysqlTaxRepository) fetch(ctx context.Context, query string, args ...interface{}) (result []domain.TaxRate, err error) {
+	rows, err := m.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.TaxRate, 0)
+	for rows.Next() {
+		t := domain.TaxRate{}
+		err = rows.Scan(&t.ID, &t.Country, &t.Region, &t.TaxClass, &t.Name, &t.Rate, &t.UpdatedAt, &t.CreatedAt)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+func (m *mysqlTaxRepository) Fetch(ctx context.Context, country string) ([]domain.TaxRate, error) {
+	if country == "" {
+		return m.fetch(ctx, selectTaxRate+` ORDER BY country, region, tax_class, id`)
+	}
+	return m.fetch(ctx, selectTaxRate+` WHERE country=? ORDER BY region, tax_class, id`, country)
+}
+
+func (m *mysqlTaxRepository) FetchApplicable(ctx context.Context, j domain.TaxJurisdiction, classes []string) ([]domain.TaxRate, error) {
+	if len(classes) == 0 {
+		return []domain.TaxRate{}, nil
+	}
+	args := []interface{}{j.Country, j.Region}
+	for _, class := range classes {
+		args = append(args, class)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(classes)), ",")
+	query := selectTaxRate + ` WHERE country=? AND (region='' OR region=?) AND tax_class IN (` + placeholders + `)
+		ORDER BY region, id`
+	return m.fetch(ctx, query, args...)
+}
+
+func (m *mysqlTaxRepository) GetByID(ctx context.Context, id int) (res domain.TaxRate, err error) {
+	list, err := m.fetch(ctx, selectTaxRate+` WHERE id=?`, id)
+	if err != nil {
+		return
+	}
+	if len(list) == 0 {
+		return res, domain.ErrNotFound
+	}
+	return list[0], nil
+}
+
+func (m *mysqlTaxRepository) Store(ctx context.Context, r *domain.TaxRate) error {
+	query := `INSERT INTO tax_rate (country, region, tax_class, name, rate, updated_at, created_at) VALUES(?,?,?,?,?,?,?)`
+	now := time.Now()
+	result, err := m.Conn.ExecContext(ctx, query, r.Country, r.Region, r.TaxClass, r.Name, r.Rate, now, now)
+	if err != nil {
+		if mysqlerr.IsDuplicate(err) {
+			return domain.ErrConflict
+		}
+		return err
+	}
+	lastID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	r.ID = int(lastID)
+	r.UpdatedAt = now
+	r.CreatedAt = now
+	return nil
+}
+
+func (m *mysqlTaxRepository) Update(ctx context.Context, r *domain.TaxRate) error {
+	query := `UPDATE tax_rate SET country=?, region=?, tax_class=?, name=?, rate=?, updated_at=? WHERE id=?`
+	r.UpdatedAt = time.Now()
+	_, err := m.Conn.ExecContext(ctx, query, r.Country, r.Region, r.TaxClass, r.Name, r.Rate, r.UpdatedAt, r.ID)
+	if err != nil && mysqlerr.IsDuplicate(err) {
+		return domain.ErrConflict
+	}
+	return err
+}
+
+func (m *mysqlTaxRepository) Delete(ctx context.Context, id int) error {
+	_, err := m.Conn.ExecContext(ctx, `DELETE FROM tax_rate WHERE id=?`, id)
+	return err
+}
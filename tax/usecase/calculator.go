@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"math/big"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// taxLine compute the tax of a line at the rates of its class. Inclusive
+// amounts are split into net and taxes, the rounded taxes of the line add
+// up exactly to the difference so the price paid never changes
+func taxLine(line domain.TaxableLine, rates []domain.TaxRate, fractions []*big.Rat, inclusive bool) domain.TaxedLine {
+	t := domain.TaxedLine{
+		ProductID: line.ProductID,
+		TaxClass:  line.TaxClass,
+		Taxes:     make([]domain.TaxAmount, 0, len(rates)),
+	}
+	net := line.Amount
+	if inclusive {
+		total := big.NewRat(1, 1)
+		for _, f := range fractions {
+			total.Add(total, f)
+		}
+		net = domain.RoundHalfAway(new(big.Rat).Quo(new(big.Rat).SetInt64(line.Amount), total))
+	}
+	for i, r := range rates {
+		amount := domain.RoundHalfAway(new(big.Rat).Mul(new(big.Rat).SetInt64(net), fractions[i]))
+		t.Taxes = append(t.Taxes, domain.TaxAmount{TaxRateID: r.ID, Name: r.Name, Rate: r.Rate, Amount: amount})
+		t.Tax += amount
+	}
+	if inclusive && len(t.Taxes) > 0 {
+		diff := line.Amount - net - t.Tax
+		t.Taxes[len(t.Taxes)-1].Amount += diff
+		t.Tax += diff
+	}
+	t.Net = net
+	t.Gross = net + t.Tax
+	return t
+}
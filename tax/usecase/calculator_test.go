@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+func TestTaxLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    int64
+		rates     []string
+		inclusive bool
+		wantNet   int64
+		wantTax   int64
+		wantGross int64
+		// wantTaxes are the amounts of the rates, in order
+		wantTaxes []int64
+	}{
+		{
+			name:      "exclusive",
+			amount:    100000,
+			rates:     []string{"11"},
+			wantNet:   100000,
+			wantTax:   11000,
+			wantGross: 111000,
+			wantTaxes: []int64{11000},
+		},
+		{
+			name:      "exclusive half rounds away from 0",
+			amount:    15,
+			rates:     []string{"10"},
+			wantNet:   15,
+			wantTax:   2,
+			wantGross: 17,
+			wantTaxes: []int64{2},
+		},
+		{
+			name:      "exclusive below half rounds down",
+			amount:    14,
+			rates:     []string{"10"},
+			wantNet:   14,
+			wantTax:   1,
+			wantGross: 15,
+			wantTaxes: []int64{1},
+		},
+		{
+			name:      "exclusive rates are rounded one by one",
+			amount:    30,
+			rates:     []string{"5", "7.5"},
+			wantNet:   30,
+			wantTax:   4,
+			wantGross: 34,
+			wantTaxes: []int64{2, 2},
+		},
+		{
+			name:      "exclusive without rates",
+			amount:    100,
+			wantNet:   100,
+			wantGross: 100,
+			wantTaxes: []int64{},
+		},
+		{
+			name:      "inclusive",
+			amount:    111000,
+			rates:     []string{"11"},
+			inclusive: true,
+			wantNet:   100000,
+			wantTax:   11000,
+			wantGross: 111000,
+			wantTaxes: []int64{11000},
+		},
+		{
+			name:      "inclusive net is rounded",
+			amount:    100,
+			rates:     []string{"10"},
+			inclusive: true,
+			wantNet:   91,
+			wantTax:   9,
+			wantGross: 100,
+			wantTaxes: []int64{9},
+		},
+		{
+			name:      "inclusive taxes rounded up give the excess back on the last rate",
+			amount:    57,
+			rates:     []string{"7", "7"},
+			inclusive: true,
+			wantNet:   50,
+			wantTax:   7,
+			wantGross: 57,
+			wantTaxes: []int64{4, 3},
+		},
+		{
+			name:      "inclusive taxes rounded down put the remainder on the last rate",
+			amount:    105,
+			rates:     []string{"2.4", "2.4"},
+			inclusive: true,
+			wantNet:   100,
+			wantTax:   5,
+			wantGross: 105,
+			wantTaxes: []int64{2, 3},
+		},
+		{
+			name:      "inclusive at a zero rate",
+			amount:    100,
+			rates:     []string{"0"},
+			inclusive: true,
+			wantNet:   100,
+			wantGross: 100,
+			wantTaxes: []int64{0},
+		},
+		{
+			name:      "inclusive without rates",
+			amount:    100,
+			inclusive: true,
+			wantNet:   100,
+			wantGross: 100,
+			wantTaxes: []int64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rates := make([]domain.TaxRate, len(tt.rates))
+			fractions := make([]*big.Rat, len(tt.rates))
+			for i, rate := range tt.rates {
+				rates[i] = domain.TaxRate{ID: i + 1, Name: "tax", Rate: rate}
+				f, ok := rates[i].Fraction()
+				if !ok {
+					t.Fatalf("Fraction() of %q is not valid", rate)
+				}
+				fractions[i] = f
+			}
+			got := taxLine(domain.TaxableLine{ProductID: 1, TaxClass: "standard", Amount: tt.amount}, rates, fractions, tt.inclusive)
+			if got.Net != tt.wantNet || got.Tax != tt.wantTax || got.Gross != tt.wantGross {
+				t.Fatalf("taxLine() net, tax, gross = %d, %d, %d, want %d, %d, %d", got.Net, got.Tax, got.Gross, tt.wantNet, tt.wantTax, tt.wantGross)
+			}
+			taxes := []int64{}
+			for _, a := range got.Taxes {
+				taxes = append(taxes, a.Amount)
+			}
+			if !reflect.DeepEqual(taxes, tt.wantTaxes) {
+				t.Fatalf("taxLine() taxes = %v, want %v", taxes, tt.wantTaxes)
+			}
+		})
+	}
+}
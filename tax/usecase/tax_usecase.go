@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// TaxUsecase represent the tax use case struct
+type TaxUsecase struct {
+	taxRepo          domain.TaxRepository
+	pricesIncludeTax bool
+	contextTimeout   time.Duration
+}
+
+// NewTaxUsecase will create new a tax usecase object representation of domain.TaxUsecase interface,
+// pricesIncludeTax tells whether the amounts to tax are gross or net
+func NewTaxUsecase(r domain.TaxRepository, pricesIncludeTax bool, timeout time.Duration) domain.TaxUsecase {
+	return &TaxUsecase{
+		taxRepo:          r,
+		pricesIncludeTax: pricesIncludeTax,
+		contextTimeout:   timeout,
+	}
+}
+
+// isCountry report whether code looks like an ISO 3166-1 alpha-2 country code
+func isCountry(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func normalize(j *domain.TaxJurisdiction) error {
+	j.Country = strings.ToUpper(strings.TrimSpace(j.Country))
+	j.Region = strings.ToUpper(strings.TrimSpace(j.Region))
+	if !isCountry(j.Country) {
+		return domain.ErrBadParamInput
+	}
+	return nil
+}
+
+// validate check and normalize a rate sent by a client
+func validate(r *domain.TaxRate) error {
+	j := domain.TaxJurisdiction{Country: r.Country, Region: r.Region}
+	if err := normalize(&j); err != nil {
+		return err
+	}
+	r.Country, r.Region = j.Country, j.Region
+	r.TaxClass = strings.ToLower(strings.TrimSpace(r.TaxClass))
+	if _, ok := r.Fraction(); !ok || r.TaxClass == "" {
+		return domain.ErrBadParamInput
+	}
+	return nil
+}
+
+// FetchRates will get the tax rates of a country, or of every country when none is given
+func (u *TaxUsecase) FetchRates(c context.Context, country string) ([]domain.TaxRate, error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	return u.taxRepo.Fetch(ctx, strings.ToUpper(country))
+}
+
+// StoreRate will add a tax rate
+func (u *TaxUsecase) StoreRate(c context.Context, r *domain.TaxRate) (err error) {
+	if err = domain.Authorize(c, domain.PermissionTaxManage); err != nil {
+		return
+	}
+	if err = validate(r); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	return u.taxRepo.Store(ctx, r)
+}
+
+// UpdateRate will replace a tax rate
+func (u *TaxUsecase) UpdateRate(c context.Context, id int, r *domain.TaxRate) (err error) {
+	if err = domain.Authorize(c, domain.PermissionTaxManage); err != nil {
+		return
+	}
+	if err = validate(r); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	existing, err := u.taxRepo.GetByID(ctx, id)
+	if err != nil {
+		return
+	}
+	r.ID = existing.ID
+	r.CreatedAt = existing.CreatedAt
+	return u.taxRepo.Update(ctx, r)
+}
+
+// DeleteRate will remove a tax rate
+func (u *TaxUsecase) DeleteRate(c context.Context, id int) (err error) {
+	if err = domain.Authorize(c, domain.PermissionTaxManage); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	if _, err = u.taxRepo.GetByID(ctx, id); err != nil {
+		return
+	}
+	return u.taxRepo.Delete(ctx, id)
+}
+
+// Calculate will tax every line at the rates of its class in the
+// jurisdiction, a class without rates there is not taxed
+func (u *TaxUsecase) Calculate(c context.Context, j domain.TaxJurisdiction, lines []domain.TaxableLine) (res domain.TaxBreakdown, err error) {
+	if err = normalize(&j); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	classes := []string{}
+	seen := map[string]bool{}
+	for i := range lines {
+		if lines[i].TaxClass == "" {
+			lines[i].TaxClass = domain.TaxClassStandard
+		}
+		if !seen[lines[i].TaxClass] {
+			seen[lines[i].TaxClass] = true
+			classes = append(classes, lines[i].TaxClass)
+		}
+	}
+	rates, err := u.taxRepo.FetchApplicable(ctx, j, classes)
+	if err != nil {
+		return
+	}
+	byClass := map[string][]domain.TaxRate{}
+	fractions := map[string][]*big.Rat{}
+	for _, r := range rates {
+		f, ok := r.Fraction()
+		if !ok {
+			// an undercharged tax is worse than a failed quote
+			logrus.Errorf("tax rate %d has an invalid rate %q", r.ID, r.Rate)
+			return res, domain.ErrInternalServerError
+		}
+		byClass[r.TaxClass] = append(byClass[r.TaxClass], r)
+		fractions[r.TaxClass] = append(fractions[r.TaxClass], f)
+	}
+
+	res = domain.TaxBreakdown{
+		TaxJurisdiction:  j,
+		PricesIncludeTax: u.pricesIncludeTax,
+		Lines:            make([]domain.TaxedLine, 0, len(lines)),
+	}
+	for _, line := range lines {
+		t := taxLine(line, byClass[line.TaxClass], fractions[line.TaxClass], u.pricesIncludeTax)
+		res.Lines = append(res.Lines, t)
+		res.Net += t.Net
+		res.Tax += t.Tax
+		res.Gross += t.Gross
+	}
+	return
+}
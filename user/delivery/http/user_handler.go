@@ -0,0 +1,201 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+// UserHandler represent the httphandler for user
+type UserHandler struct {
+	UUsecase domain.UserUsecase
+}
+
+// forgotPasswordRequest represent the request body to ask for a password reset token
+type forgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// NewUserHandler will initialize the user endpoint
+func NewUserHandler(e *echo.Echo, us domain.UserUsecase) {
+	handler := &UserHandler{
+		UUsecase: us,
+	}
+	e.POST("/user/register", handler.Register)
+	e.POST("/user/login", handler.Login)
+	e.POST("/user/logout", handler.Logout)
+	e.POST("/user/password/forgot", handler.ForgotPassword)
+	e.POST("/user/password/reset", handler.ResetPassword)
+	e.GET("/user/me", handler.GetProfile)
+	e.PUT("/user/me", handler.UpdateProfile)
+}
+
+// Register will create a customer account by given request body
+func (u *UserHandler) Register(c echo.Context) (err error) {
+	var registration domain.UserRegistration
+	err = c.Bind(&registration)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&registration); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	user, err := u.UUsecase.Register(ctx, &registration)
+	if err != nil {
+		return c.JSON(getStatusCode(err), response.ResponseFailed{Success: false, Message: err.Error()})
+	}
+	return c.JSON(http.StatusCreated, response.ResponseSuccess{Success: true, Data: user})
+}
+
+// Login will open a session by given credentials
+func (u *UserHandler) Login(c echo.Context) (err error) {
+	var cred domain.UserCredential
+	err = c.Bind(&cred)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&cred); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	session, err := u.UUsecase.Login(ctx, &cred)
+	if err != nil {
+		return c.JSON(getStatusCode(err), response.ResponseFailed{Success: false, Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: session})
+}
+
+// Logout will close the session of the bearer token
+func (u *UserHandler) Logout(c echo.Context) error {
+	token := bearerToken(c)
+	if token == "" {
+		return c.JSON(http.StatusUnauthorized, response.ResponseFailed{Success: false, Message: domain.ErrUnauthorized.Error()})
+	}
+	ctx := c.Request().Context()
+	err := u.UUsecase.Logout(ctx, token)
+	if err != nil {
+		return c.JSON(getStatusCode(err), response.ResponseFailed{Success: false, Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: nil})
+}
+
+// ForgotPassword will send a password reset token to the given email
+func (u *UserHandler) ForgotPassword(c echo.Context) (err error) {
+	var req forgotPasswordRequest
+	err = c.Bind(&req)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&req); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	err = u.UUsecase.RequestPasswordReset(ctx, req.Email)
+	if err != nil {
+		return c.JSON(getStatusCode(err), response.ResponseFailed{Success: false, Message: err.Error()})
+	}
+	return c.JSON(http.StatusAccepted, response.ResponseSuccess{Success: true, Data: nil})
+}
+
+// ResetPassword will set a new password by given reset token
+func (u *UserHandler) ResetPassword(c echo.Context) (err error) {
+	var reset domain.PasswordReset
+	err = c.Bind(&reset)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&reset); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	err = u.UUsecase.ResetPassword(ctx, &reset)
+	if err != nil {
+		return c.JSON(getStatusCode(err), response.ResponseFailed{Success: false, Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: nil})
+}
+
+// GetProfile will get the profile of the logged in user
+func (u *UserHandler) GetProfile(c echo.Context) error {
+	ctx := c.Request().Context()
+	user, err := u.UUsecase.Authenticate(ctx, bearerToken(c))
+	if err != nil {
+		return c.JSON(getStatusCode(err), response.ResponseFailed{Success: false, Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: user})
+}
+
+// UpdateProfile will update the profile of the logged in user by given request body
+func (u *UserHandler) UpdateProfile(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+	current, err := u.UUsecase.Authenticate(ctx, bearerToken(c))
+	if err != nil {
+		return c.JSON(getStatusCode(err), response.ResponseFailed{Success: false, Message: err.Error()})
+	}
+	var user domain.User
+	err = c.Bind(&user)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&user); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	err = u.UUsecase.UpdateProfile(ctx, current.ID, &user)
+	if err != nil {
+		return c.JSON(getStatusCode(err), response.ResponseFailed{Success: false, Message: err.Error()})
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: nil})
+}
+
+// bearerToken extract the token of an "Authorization: Bearer <token>" header
+func bearerToken(c echo.Context) string {
+	auth := c.Request().Header.Get(echo.HeaderAuthorization)
+	const prefix = "Bearer "
+	if len(auth) > len(prefix) && strings.EqualFold(auth[:len(prefix)], prefix) {
+		return strings.TrimSpace(auth[len(prefix):])
+	}
+	return ""
+}
+
+func isRequestValid(m interface{}) (bool, error) {
+	validate := validator.New()
+	err := validate.Struct(m)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func getStatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	logrus.Error(err)
+	switch {
+	case errors.Is(err, domain.ErrInternalServerError):
+		return http.StatusInternalServerError
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrBadParamInput):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,180 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/mysqlerr"
+	"github.com/wdwiramadhan/bookhub-api/helper/sqltx"
+)
+
+// mysqlUserRepository represent the connection database struct
+type mysqlUserRepository struct {
+	Conn *sql.DB
+}
+
+// NewMysqlUserRepository will create an object that represent the user.Repository interface
+func NewMysqlUserRepository(Conn *sql.DB) domain.UserRepository {
+	return &mysqlUserRepository{Conn: Conn}
+}
+
+func (m *mysqlUserRepository) fetch(ctx context.Context, query string, args ...interface{}) (result []domain.User, err error) {
+	rows, err := m.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.User, 0)
+	for rows.Next() {
+		t := domain.User{}
+		err = rows.Scan(
+			&t.ID,
+			&t.Name,
+			&t.Email,
+			&t.PasswordHash,
+			&t.Role,
+			&t.UpdatedAt,
+			&t.CreatedAt,
+		)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+func (m *mysqlUserRepository) getOne(ctx context.Context, query string, args ...interface{}) (res domain.User, err error) {
+	list, err := m.fetch(ctx, query, args...)
+	if err != nil {
+		return
+	}
+	if len(list) == 0 {
+		return res, domain.ErrNotFound
+	}
+	return list[0], nil
+}
+
+func (m *mysqlUserRepository) Store(ctx context.Context, u *domain.User) (err error) {
+	query := `INSERT INTO user (name, email, password_hash, role, updated_at, created_at) VALUES(?,?,?,?,?,?)`
+	now := time.Now()
+	result, err := m.Conn.ExecContext(ctx, query, u.Name, u.Email, u.PasswordHash, u.Role, now, now)
+	if err != nil {
+		if mysqlerr.IsDuplicate(err) {
+			return domain.ErrConflict
+		}
+		return
+	}
+	lastID, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+	u.ID = int(lastID)
+	u.UpdatedAt = now
+	u.CreatedAt = now
+	return
+}
+
+func (m *mysqlUserRepository) GetByID(ctx context.Context, id int) (domain.User, error) {
+	query := `SELECT id, name, email, password_hash, role, updated_at, created_at FROM user WHERE id=?`
+	return m.getOne(ctx, query, id)
+}
+
+func (m *mysqlUserRepository) GetByEmail(ctx context.Context, email string) (domain.User, error) {
+	query := `SELECT id, name, email, password_hash, role, updated_at, created_at FROM user WHERE email=?`
+	return m.getOne(ctx, query, email)
+}
+
+func (m *mysqlUserRepository) Update(ctx context.Context, id int, u *domain.User) (err error) {
+	query := `UPDATE user SET name=?, email=?, updated_at=? WHERE id=?`
+	_, err = m.Conn.ExecContext(ctx, query, u.Name, u.Email, time.Now(), id)
+	if err != nil {
+		if mysqlerr.IsDuplicate(err) {
+			return domain.ErrConflict
+		}
+		return
+	}
+	return
+}
+
+func (m *mysqlUserRepository) UpdatePassword(ctx context.Context, id int, passwordHash string) (err error) {
+	query := `UPDATE user SET password_hash=?, updated_at=? WHERE id=?`
+	_, err = sqltx.From(ctx, m.Conn).ExecContext(ctx, query, passwordHash, time.Now(), id)
+	return
+}
+
+func (m *mysqlUserRepository) StoreSession(ctx context.Context, s *domain.Session) (err error) {
+	query := `INSERT INTO user_session (token_hash, user_id, expires_at, created_at) VALUES(?,?,?,?)`
+	_, err = m.Conn.ExecContext(ctx, query, s.TokenHash, s.UserID, s.ExpiresAt, s.CreatedAt)
+	return
+}
+
+func (m *mysqlUserRepository) GetSession(ctx context.Context, tokenHash string) (res domain.Session, err error) {
+	query := `SELECT token_hash, user_id, expires_at, created_at FROM user_session WHERE token_hash=?`
+	err = m.Conn.QueryRowContext(ctx, query, tokenHash).Scan(&res.TokenHash, &res.UserID, &res.ExpiresAt, &res.CreatedAt)
+	if err == sql.ErrNoRows {
+		return res, domain.ErrNotFound
+	}
+	return
+}
+
+func (m *mysqlUserRepository) DeleteSession(ctx context.Context, tokenHash string) (err error) {
+	query := `DELETE FROM user_session WHERE token_hash=?`
+	_, err = m.Conn.ExecContext(ctx, query, tokenHash)
+	return
+}
+
+func (m *mysqlUserRepository) DeleteSessionsByUserID(ctx context.Context, userID int) (err error) {
+	query := `DELETE FROM user_session WHERE user_id=?`
+	_, err = sqltx.From(ctx, m.Conn).ExecContext(ctx, query, userID)
+	return
+}
+
+func (m *mysqlUserRepository) StorePasswordResetToken(ctx context.Context, t *domain.PasswordResetToken) (err error) {
+	query := `INSERT INTO password_reset_token (token_hash, user_id, expires_at, created_at) VALUES(?,?,?,?)`
+	_, err = m.Conn.ExecContext(ctx, query, t.TokenHash, t.UserID, t.ExpiresAt, t.CreatedAt)
+	return
+}
+
+func (m *mysqlUserRepository) GetPasswordResetToken(ctx context.Context, tokenHash string) (res domain.PasswordResetToken, err error) {
+	query := `SELECT token_hash, user_id, expires_at, used_at, created_at FROM password_reset_token WHERE token_hash=?`
+	var usedAt sql.NullTime
+	err = m.Conn.QueryRowContext(ctx, query, tokenHash).Scan(&res.TokenHash, &res.UserID, &res.ExpiresAt, &usedAt, &res.CreatedAt)
+	if err == sql.ErrNoRows {
+		return res, domain.ErrNotFound
+	}
+	if err != nil {
+		return
+	}
+	if usedAt.Valid {
+		res.UsedAt = &usedAt.Time
+	}
+	return
+}
+
+// MarkPasswordResetTokenUsed burn the token, it fails with domain.ErrConflict if the token was already used
+func (m *mysqlUserRepository) MarkPasswordResetTokenUsed(ctx context.Context, tokenHash string) (err error) {
+	query := `UPDATE password_reset_token SET used_at=? WHERE token_hash=? AND used_at IS NULL`
+	result, err := sqltx.From(ctx, m.Conn).ExecContext(ctx, query, time.Now(), tokenHash)
+	if err != nil {
+		return
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+	if affected == 0 {
+		return domain.ErrConflict
+	}
+	return
+}
@@ -0,0 +1,265 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// defaultRole is the role given to every registered customer
+	defaultRole = "customer"
+	// sessionTTL is how long a session token stays valid after login
+	sessionTTL = 24 * time.Hour
+	// passwordResetTTL is how long a password reset token stays valid
+	passwordResetTTL = time.Hour
+)
+
+// UserUsecase represent the user use case struct
+type UserUsecase struct {
+	userRepo       domain.UserRepository
+	mailer         domain.Mailer
+	transactor     domain.Transactor
+	contextTimeout time.Duration
+}
+
+// NewUserUsecase will create new an user usecase object representation of domain.UserUsecase interface
+func NewUserUsecase(u domain.UserRepository, mailer domain.Mailer, t domain.Transactor, timeout time.Duration) domain.UserUsecase {
+	return &UserUsecase{
+		userRepo:       u,
+		mailer:         mailer,
+		transactor:     t,
+		contextTimeout: timeout,
+	}
+}
+
+// newToken generate a random url safe token along with the hash to persist
+func newToken() (token string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// dummyHash is compared against on a login of an unknown email so it costs as
+// much as a wrong password and does not tell which emails are registered
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Register will create a new customer account
+func (u *UserUsecase) Register(c context.Context, r *domain.UserRegistration) (res domain.User, err error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	email := normalizeEmail(r.Email)
+	_, err = u.userRepo.GetByEmail(ctx, email)
+	if err == nil {
+		return res, domain.ErrConflict
+	}
+	if err != domain.ErrNotFound {
+		return
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(r.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return
+	}
+	res = domain.User{
+		Name:         r.Name,
+		Email:        email,
+		PasswordHash: string(hash),
+		Role:         defaultRole,
+	}
+	err = u.userRepo.Store(ctx, &res)
+	return
+}
+
+// Login will check the credentials and open a new session
+func (u *UserUsecase) Login(c context.Context, cred *domain.UserCredential) (res domain.Session, err error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	user, err := u.userRepo.GetByEmail(ctx, normalizeEmail(cred.Email))
+	if err == domain.ErrNotFound {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(cred.Password))
+		return res, domain.ErrUnauthorized
+	}
+	if err != nil {
+		return
+	}
+	if err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(cred.Password)); err != nil {
+		return res, domain.ErrUnauthorized
+	}
+
+	token, hash, err := newToken()
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	res = domain.Session{
+		Token:     token,
+		TokenHash: hash,
+		UserID:    user.ID,
+		ExpiresAt: now.Add(sessionTTL),
+		CreatedAt: now,
+	}
+	err = u.userRepo.StoreSession(ctx, &res)
+	return
+}
+
+// Logout will close the session of the given token
+func (u *UserUsecase) Logout(c context.Context, token string) (err error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	err = u.userRepo.DeleteSession(ctx, hashToken(token))
+	return
+}
+
+// Authenticate will resolve a session token into its user
+func (u *UserUsecase) Authenticate(c context.Context, token string) (res domain.User, err error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	session, err := u.userRepo.GetSession(ctx, hashToken(token))
+	if err == domain.ErrNotFound {
+		return res, domain.ErrUnauthorized
+	}
+	if err != nil {
+		return
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return res, domain.ErrUnauthorized
+	}
+	res, err = u.userRepo.GetByID(ctx, session.UserID)
+	if err == domain.ErrNotFound {
+		return res, domain.ErrUnauthorized
+	}
+	return
+}
+
+// RequestPasswordReset will mail a reset token to the user, it does not tell
+// whether the email is registered: the answer comes before any work is done so
+// that its timing does not tell either
+func (u *UserUsecase) RequestPasswordReset(c context.Context, email string) (err error) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), u.contextTimeout)
+		defer cancel()
+		if err := u.sendPasswordReset(ctx, normalizeEmail(email)); err != nil {
+			logrus.Error(err)
+		}
+	}()
+	return nil
+}
+
+// sendPasswordReset store a reset token of the user of the email and mail it
+func (u *UserUsecase) sendPasswordReset(ctx context.Context, email string) (err error) {
+	user, err := u.userRepo.GetByEmail(ctx, email)
+	if err == domain.ErrNotFound {
+		logrus.Info("password reset requested for an unknown email")
+		return nil
+	}
+	if err != nil {
+		return
+	}
+
+	token, hash, err := newToken()
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	err = u.userRepo.StorePasswordResetToken(ctx, &domain.PasswordResetToken{
+		TokenHash: hash,
+		UserID:    user.ID,
+		ExpiresAt: now.Add(passwordResetTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return
+	}
+	err = u.mailer.Send(ctx, domain.Mail{
+		To:      user.Email,
+		Subject: "Reset your bookhub password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the following token to reset your password: %s\n\nThe token expires in %s.\n",
+			user.Name, token, passwordResetTTL),
+	})
+	return
+}
+
+// ResetPassword will set a new password using a reset token and close every open session of the user
+func (u *UserUsecase) ResetPassword(c context.Context, r *domain.PasswordReset) (err error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	hash := hashToken(r.Token)
+	token, err := u.userRepo.GetPasswordResetToken(ctx, hash)
+	if err == domain.ErrNotFound {
+		return domain.ErrUnauthorized
+	}
+	if err != nil {
+		return
+	}
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return domain.ErrUnauthorized
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(r.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return
+	}
+	// the token is only burnt along with the new password, so that a failed
+	// reset leaves it usable
+	return u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.userRepo.MarkPasswordResetTokenUsed(ctx, hash); err != nil {
+			if err == domain.ErrConflict {
+				return domain.ErrUnauthorized
+			}
+			return err
+		}
+		if err := u.userRepo.UpdatePassword(ctx, token.UserID, string(passwordHash)); err != nil {
+			return err
+		}
+		return u.userRepo.DeleteSessionsByUserID(ctx, token.UserID)
+	})
+}
+
+// GetByID will get user by given id
+func (u *UserUsecase) GetByID(c context.Context, id int) (res domain.User, err error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	res, err = u.userRepo.GetByID(ctx, id)
+	return
+}
+
+// UpdateProfile will update the name and email of the user
+func (u *UserUsecase) UpdateProfile(c context.Context, id int, m *domain.User) (err error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	m.Email = normalizeEmail(m.Email)
+	existing, err := u.userRepo.GetByEmail(ctx, m.Email)
+	if err == nil && existing.ID != id {
+		return domain.ErrConflict
+	}
+	if err != nil && err != domain.ErrNotFound {
+		return
+	}
+	err = u.userRepo.Update(ctx, id, m)
+	return
+}
@@ -0,0 +1,180 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+// WebhookHandler represent the httphandler for webhook
+type WebhookHandler struct {
+	WUsecase domain.WebhookUsecase
+}
+
+// NewWebhookHandler will initialize the webhook endpoint
+func NewWebhookHandler(e *echo.Echo, us domain.WebhookUsecase, auth echo.MiddlewareFunc) {
+	handler := &WebhookHandler{
+		WUsecase: us,
+	}
+	e.GET("/webhook", handler.Fetch, auth)
+	e.POST("/webhook", handler.Store, auth)
+	e.GET("/webhook/:webhookId", handler.GetByID, auth)
+	e.PUT("/webhook/:webhookId", handler.Update, auth)
+	e.DELETE("/webhook/:webhookId", handler.Delete, auth)
+	e.GET("/webhook/:webhookId/delivery", handler.FetchDeliveries, auth)
+	e.POST("/webhook/delivery/:deliveryId/redeliver", handler.Redeliver, auth)
+}
+
+// Fetch will fetch the subscriptions of the caller
+func (w *WebhookHandler) Fetch(c echo.Context) error {
+	ctx := c.Request().Context()
+	subscriptions, err := w.WUsecase.Fetch(ctx)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: subscriptions})
+}
+
+// GetByID will get a subscription by its id
+func (w *WebhookHandler) GetByID(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("webhookId"))
+	ctx := c.Request().Context()
+	subscription, err := w.WUsecase.GetByID(ctx, id)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: subscription})
+}
+
+// Store will subscribe a URL, the response is the only one showing the secret
+func (w *WebhookHandler) Store(c echo.Context) (err error) {
+	var subscription domain.WebhookSubscription
+	if err = c.Bind(&subscription); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&subscription); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	if err = w.WUsecase.Store(ctx, &subscription); err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusCreated, response.ResponseSuccess{Success: true, Data: subscription})
+}
+
+// Update will replace a subscription, a secret given in the body replaces the current one
+func (w *WebhookHandler) Update(c echo.Context) (err error) {
+	id, _ := strconv.Atoi(c.Param("webhookId"))
+	var subscription domain.WebhookSubscription
+	if err = c.Bind(&subscription); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&subscription); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	if err = w.WUsecase.Update(ctx, id, &subscription); err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: subscription})
+}
+
+// Delete will remove a subscription
+func (w *WebhookHandler) Delete(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("webhookId"))
+	ctx := c.Request().Context()
+	if err := w.WUsecase.Delete(ctx, id); err != nil {
+		return failed(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// FetchDeliveries will fetch a page of the delivery log of a subscription,
+// the latest first, optionally of the status query param. The next page
+// starts before the next cursor, passed back in the before query param
+func (w *WebhookHandler) FetchDeliveries(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("webhookId"))
+	filter := domain.WebhookDeliveryFilter{
+		SubscriptionID: id,
+		Status:         domain.WebhookDeliveryStatus(c.QueryParam("status")),
+	}
+	var err error
+	if v := c.QueryParam("before"); v != "" {
+		if filter.Before, err = strconv.Atoi(v); err != nil {
+			return failed(c, domain.ErrBadParamInput)
+		}
+	}
+	if v := c.QueryParam("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			return failed(c, domain.ErrBadParamInput)
+		}
+	}
+	ctx := c.Request().Context()
+	deliveries, err := w.WUsecase.FetchDeliveries(ctx, filter)
+	if err != nil {
+		return failed(c, err)
+	}
+	res := response.ResponsePaginated{Success: true, Data: deliveries}
+	if len(deliveries) > 0 {
+		res.Next = strconv.Itoa(deliveries[len(deliveries)-1].ID)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// Redeliver will have a delivery sent again
+func (w *WebhookHandler) Redeliver(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("deliveryId"))
+	ctx := c.Request().Context()
+	delivery, err := w.WUsecase.Redeliver(ctx, id)
+	if err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusAccepted, response.ResponseSuccess{Success: true, Data: delivery})
+}
+
+func isRequestValid(m interface{}) (bool, error) {
+	validate := validator.New()
+	err := validate.Struct(m)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// failed write the error response of err, a denied permission is described with a problem detail
+func failed(c echo.Context, err error) error {
+	status := getStatusCode(err)
+	if status == http.StatusForbidden {
+		c.Response().Header().Set(echo.HeaderContentType, response.ProblemContentType)
+		return c.JSON(status, response.NewProblem(status, err.Error()))
+	}
+	return c.JSON(status, response.ResponseFailed{Success: false, Message: err.Error()})
+}
+
+func getStatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	logrus.Error(err)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrBadParamInput):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
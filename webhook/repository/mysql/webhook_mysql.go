@@ -0,0 +1,264 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/mysqlerr"
+)
+
+const selectSubscription = `SELECT id, owner, url, event_types, secret, active, updated_at, created_at FROM webhook_subscription`
+
+const selectDelivery = `SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+	last_status_code, last_error, delivered_at, updated_at, created_at FROM webhook_delivery`
+
+// mysqlWebhookRepository represent the connection database struct
+type mysqlWebhookRepository struct {
+	Conn *sql.DB
+}
+
+// NewMysqlWebhookRepository will create an object that represent the webhook.Repository interface
+func NewMysqlWebhookRepository(Conn *sql.DB) domain.WebhookRepository {
+	return &mysqlWebhookRepository{Conn: Conn}
+}
+
+// splitTypes read back the event types joined by commas, none when empty
+func splitTypes(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+func (m *mysqlWebhookRepository) fetch(ctx context.Context, query string, args ...interface{}) (result []domain.WebhookSubscription, err error) {
+	rows, err := m.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.WebhookSubscription, 0)
+	for rows.Next() {
+		t := domain.WebhookSubscription{}
+		var eventTypes string
+		err = rows.Scan(&t.ID, &t.Owner, &t.URL, &eventTypes, &t.Secret, &t.Active, &t.UpdatedAt, &t.CreatedAt)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		t.EventTypes = splitTypes(eventTypes)
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// Fetch list the subscriptions of the owner, of everybody when owner is empty
+func (m *mysqlWebhookRepository) Fetch(ctx context.Context, owner string) ([]domain.WebhookSubscription, error) {
+	if owner == "" {
+		return m.fetch(ctx, selectSubscription+` ORDER BY id`)
+	}
+	return m.fetch(ctx, selectSubscription+` WHERE owner=? ORDER BY id`, owner)
+}
+
+func (m *mysqlWebhookRepository) FetchActive(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	return m.fetch(ctx, selectSubscription+` WHERE active=1 ORDER BY id`)
+}
+
+func (m *mysqlWebhookRepository) GetByID(ctx context.Context, id int) (res domain.WebhookSubscription, err error) {
+	list, err := m.fetch(ctx, selectSubscription+` WHERE id=?`, id)
+	if err != nil {
+		return
+	}
+	if len(list) == 0 {
+		return res, domain.ErrNotFound
+	}
+	return list[0], nil
+}
+
+func (m *mysqlWebhookRepository) Store(ctx context.Context, s *domain.WebhookSubscription) error {
+	query := `INSERT INTO webhook_subscription (owner, url, event_types, secret, active, updated_at, created_at) VALUES(?,?,?,?,?,?,?)`
+	now := time.Now()
+	result, err := m.Conn.ExecContext(ctx, query, s.Owner, s.URL, strings.Join(s.EventTypes, ","), s.Secret, s.Active, now, now)
+	if err != nil {
+		return err
+	}
+	lastID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	s.ID = int(lastID)
+	s.UpdatedAt = now
+	s.CreatedAt = now
+	return nil
+}
+
+func (m *mysqlWebhookRepository) Update(ctx context.Context, s *domain.WebhookSubscription) error {
+	query := `UPDATE webhook_subscription SET url=?, event_types=?, secret=?, active=?, updated_at=? WHERE id=?`
+	s.UpdatedAt = time.Now()
+	_, err := m.Conn.ExecContext(ctx, query, s.URL, strings.Join(s.EventTypes, ","), s.Secret, s.Active, s.UpdatedAt, s.ID)
+	return err
+}
+
+// withTx run fn in a transaction, rolled back when fn fails
+func (m *mysqlWebhookRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) (err error) {
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(); errRollback != nil {
+				logrus.Error(errRollback)
+			}
+		}
+	}()
+	if err = fn(tx); err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+// Delete remove the subscription along with its delivery log
+func (m *mysqlWebhookRepository) Delete(ctx context.Context, id int) error {
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM webhook_delivery WHERE subscription_id=?`, id); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM webhook_subscription WHERE id=?`, id)
+		return err
+	})
+}
+
+func (m *mysqlWebhookRepository) fetchDeliveries(ctx context.Context, query string, args ...interface{}) (result []domain.WebhookDelivery, err error) {
+	rows, err := m.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.WebhookDelivery, 0)
+	for rows.Next() {
+		t := domain.WebhookDelivery{}
+		var payload []byte
+		var deliveredAt sql.NullTime
+		err = rows.Scan(
+			&t.ID,
+			&t.SubscriptionID,
+			&t.EventID,
+			&t.EventType,
+			&payload,
+			&t.Status,
+			&t.Attempts,
+			&t.NextAttemptAt,
+			&t.LastStatusCode,
+			&t.LastError,
+			&deliveredAt,
+			&t.UpdatedAt,
+			&t.CreatedAt,
+		)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		t.Payload = payload
+		if deliveredAt.Valid {
+			t.DeliveredAt = &deliveredAt.Time
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+func (m *mysqlWebhookRepository) FetchDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, error) {
+	query := selectDelivery + ` WHERE subscription_id=?`
+	args := []interface{}{filter.SubscriptionID}
+	if filter.Status != "" {
+		query += ` AND status=?`
+		args = append(args, filter.Status)
+	}
+	if filter.Before > 0 {
+		query += ` AND id<?`
+		args = append(args, filter.Before)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, filter.Limit)
+	return m.fetchDeliveries(ctx, query, args...)
+}
+
+func (m *mysqlWebhookRepository) GetDelivery(ctx context.Context, id int) (res domain.WebhookDelivery, err error) {
+	list, err := m.fetchDeliveries(ctx, selectDelivery+` WHERE id=?`, id)
+	if err != nil {
+		return
+	}
+	if len(list) == 0 {
+		return res, domain.ErrNotFound
+	}
+	return list[0], nil
+}
+
+func (m *mysqlWebhookRepository) StoreDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	query := `INSERT INTO webhook_delivery (subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+		last_status_code, last_error, updated_at, created_at) VALUES(?,?,?,?,?,0,?,0,'',?,?)`
+	now := time.Now()
+	result, err := m.Conn.ExecContext(ctx, query, d.SubscriptionID, d.EventID, d.EventType, []byte(d.Payload), d.Status, d.NextAttemptAt, now, now)
+	if err != nil {
+		if mysqlerr.IsDuplicate(err) {
+			return domain.ErrConflict
+		}
+		return err
+	}
+	lastID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	d.ID = int(lastID)
+	d.UpdatedAt = now
+	d.CreatedAt = now
+	return nil
+}
+
+func (m *mysqlWebhookRepository) FetchDue(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	query := selectDelivery + ` WHERE status=? AND next_attempt_at<=? ORDER BY next_attempt_at, id LIMIT ?`
+	return m.fetchDeliveries(ctx, query, domain.WebhookDeliveryPending, now, limit)
+}
+
+// Claim move the next attempt in a single statement, so that only one
+// dispatcher sends the delivery
+func (m *mysqlWebhookRepository) Claim(ctx context.Context, id int, dueAt time.Time, until time.Time) error {
+	query := `UPDATE webhook_delivery SET next_attempt_at=? WHERE id=? AND status=? AND next_attempt_at=?`
+	result, err := m.Conn.ExecContext(ctx, query, until, id, domain.WebhookDeliveryPending, dueAt)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrConflict
+	}
+	return nil
+}
+
+func (m *mysqlWebhookRepository) UpdateDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	query := `UPDATE webhook_delivery SET status=?, attempts=?, next_attempt_at=?, last_status_code=?, last_error=?,
+		delivered_at=?, updated_at=? WHERE id=?`
+	d.UpdatedAt = time.Now()
+	_, err := m.Conn.ExecContext(ctx, query, d.Status, d.Attempts, d.NextAttemptAt, d.LastStatusCode, d.LastError,
+		d.DeliveredAt, d.UpdatedAt, d.ID)
+	return err
+}
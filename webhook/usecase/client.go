@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// errBlockedAddress is returned when a delivery would reach a non-public address
+var errBlockedAddress = errors.New("webhook address is not public")
+
+// blockedNetworks are the ranges the deliveries may not reach: the loopback,
+// private, shared, link-local (cloud metadata included) and reserved ones
+var blockedNetworks = func() []*net.IPNet {
+	cidrs := []string{
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"127.0.0.0/8",
+		"169.254.0.0/16",
+		"172.16.0.0/12",
+		"192.0.0.0/24",
+		"192.168.0.0/16",
+		"198.18.0.0/15",
+		"224.0.0.0/4",
+		"240.0.0.0/4",
+		"::/128",
+		"::1/128",
+		"64:ff9b::/96",
+		"fc00::/7",
+		"fe80::/10",
+		"ff00::/8",
+	}
+	res := make([]*net.IPNet, len(cidrs))
+	for i, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		res[i] = n
+	}
+	return res
+}()
+
+// isPublicIP report whether the deliveries may reach ip
+func isPublicIP(ip net.IP) bool {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	for _, n := range blockedNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// dialControl refuse the connections to non-public addresses. It runs on the
+// address the host resolved to, right before connecting, so that a host
+// resolving to a public address when subscribed and a private one later on
+// is refused as well
+func dialControl(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return errBlockedAddress
+	}
+	return nil
+}
+
+// NewWebhookClient will create the client posting the deliveries. It only
+// connects to public addresses, ignores the proxy settings and does not
+// follow redirects, a redirect is an unexpected status like any other
+func NewWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		Control:   dialControl,
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConnsPerHost:   2,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// WebhookDispatcher plan the deliveries of the published events and attempt
+// them as they become due
+type WebhookDispatcher struct {
+	usecase  domain.WebhookUsecase
+	interval time.Duration
+	wake     chan struct{}
+}
+
+// NewWebhookDispatcher will create a dispatcher looking for due deliveries
+// every interval, or as soon as an event is planned
+func NewWebhookDispatcher(u domain.WebhookUsecase, interval time.Duration) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		usecase:  u,
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Handle plan the deliveries of a published event, it is the domain.EventHandler
+// subscribed to the broker. A failure has the event handed again to it later
+func (d *WebhookDispatcher) Handle(ctx context.Context, e domain.Event) error {
+	ctx = domain.NewContextWithPrincipal(ctx, domain.SystemPrincipal("webhook-dispatcher"))
+	if err := d.usecase.Enqueue(ctx, e); err != nil {
+		return err
+	}
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run attempt the due deliveries until ctx is done
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ctx = domain.NewContextWithPrincipal(ctx, domain.SystemPrincipal("webhook-dispatcher"))
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		delivered, err := d.usecase.DeliverDue(ctx, time.Now())
+		if err != nil {
+			logrus.Error(err)
+		} else if delivered > 0 {
+			logrus.Infof("delivered %d webhooks", delivered)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
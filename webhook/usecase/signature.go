@@ -0,0 +1,29 @@
+package usecase
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+const (
+	// SignatureHeader carry the signature of a delivery, as "t=<unix time>,v1=<hex HMAC>"
+	SignatureHeader = "X-Bookhub-Signature"
+	// EventHeader carry the type of the delivered event
+	EventHeader = "X-Bookhub-Event"
+	// DeliveryHeader carry the id of the delivery, the same for every attempt
+	DeliveryHeader = "X-Bookhub-Delivery"
+)
+
+// Sign compute the signature of a body sent at timestamp: the HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the secret of the subscription. Receivers
+// recompute it and reject old timestamps so that a delivery cannot be replayed
+func Sign(secret string, timestamp int64, body []byte) string {
+	t := strconv.FormatInt(timestamp, 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,425 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	mathrand "math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+const (
+	// defaultDeliveryLimit is the page size of the delivery log when none is asked
+	defaultDeliveryLimit = 50
+	// maxDeliveryLimit bound the page size of the delivery log
+	maxDeliveryLimit = 200
+
+	// maxAttempts is the number of attempts before a delivery is dead
+	maxAttempts = 10
+	// baseRetryDelay is the wait after the first failed attempt, doubled after every other one
+	baseRetryDelay = 30 * time.Second
+	// maxRetryDelay bound the wait between two attempts
+	maxRetryDelay = 6 * time.Hour
+
+	// deliveryBatchSize is the number of due deliveries attempted by a run
+	deliveryBatchSize = 100
+	// deliveryWorkers is the number of deliveries attempted at once, so that a
+	// slow endpoint does not hold up the others
+	deliveryWorkers = 4
+)
+
+// deliveryBody is the body posted to the subscribers, the event without who
+// made it nor the request it came from
+type deliveryBody struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	EntityID   string          `json:"entity_id"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// WebhookUsecase represent the webhook use case struct
+type WebhookUsecase struct {
+	webhookRepo    domain.WebhookRepository
+	client         *http.Client
+	contextTimeout time.Duration
+}
+
+// NewWebhookUsecase will create new a webhook usecase object representation of domain.WebhookUsecase interface,
+// the deliveries are posted with client
+func NewWebhookUsecase(w domain.WebhookRepository, client *http.Client, timeout time.Duration) domain.WebhookUsecase {
+	return &WebhookUsecase{
+		webhookRepo:    w,
+		client:         client,
+		contextTimeout: timeout,
+	}
+}
+
+// owner return the subject owning the subscriptions the caller manages,
+// empty for those managing every subscription
+func owner(ctx context.Context) (string, error) {
+	if err := domain.Authorize(ctx, domain.PermissionWebhookManage); err != nil {
+		return "", err
+	}
+	p, _ := domain.PrincipalFromContext(ctx)
+	if p.Can(domain.PermissionWebhookAdmin) {
+		return "", nil
+	}
+	return p.Subject, nil
+}
+
+// validate check the URL and the event types of a subscription. The URL is
+// https, and its host is not an address the deliveries may not reach; the
+// hosts resolving to one are refused when connecting
+func validate(s *domain.WebhookSubscription) error {
+	u, err := url.Parse(s.URL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return domain.ErrBadParamInput
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return domain.ErrBadParamInput
+	}
+	if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+		return domain.ErrBadParamInput
+	}
+	for _, t := range s.EventTypes {
+		if !domain.IsValidEventType(t) {
+			return domain.ErrBadParamInput
+		}
+	}
+	if s.EventTypes == nil {
+		s.EventTypes = []string{}
+	}
+	return nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// get return the subscription when the caller manages it, others are not found
+func (u *WebhookUsecase) get(ctx context.Context, id int) (res domain.WebhookSubscription, err error) {
+	o, err := owner(ctx)
+	if err != nil {
+		return
+	}
+	res, err = u.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		return
+	}
+	if o != "" && res.Owner != o {
+		return domain.WebhookSubscription{}, domain.ErrNotFound
+	}
+	return
+}
+
+// Fetch will get the subscriptions of the caller, the secrets left out
+func (u *WebhookUsecase) Fetch(c context.Context) (res []domain.WebhookSubscription, err error) {
+	o, err := owner(c)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	res, err = u.webhookRepo.Fetch(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+	for i := range res {
+		res[i].Secret = ""
+	}
+	return
+}
+
+// Store will subscribe a URL for the caller, active right away. A secret is
+// generated unless given
+func (u *WebhookUsecase) Store(c context.Context, s *domain.WebhookSubscription) (err error) {
+	if err = domain.Authorize(c, domain.PermissionWebhookManage); err != nil {
+		return
+	}
+	if err = validate(s); err != nil {
+		return
+	}
+	if s.Secret == "" {
+		if s.Secret, err = newSecret(); err != nil {
+			return
+		}
+	}
+	p, _ := domain.PrincipalFromContext(c)
+	s.Owner = p.Subject
+	s.Active = true
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	return u.webhookRepo.Store(ctx, s)
+}
+
+// GetByID will get a subscription of the caller by its id, the secret left out
+func (u *WebhookUsecase) GetByID(c context.Context, id int) (res domain.WebhookSubscription, err error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	res, err = u.get(ctx, id)
+	res.Secret = ""
+	return
+}
+
+// Update will replace the URL, the event types and the state of a
+// subscription, its secret is only changed when a new one is given
+func (u *WebhookUsecase) Update(c context.Context, id int, s *domain.WebhookSubscription) (err error) {
+	if err = validate(s); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	existing, err := u.get(ctx, id)
+	if err != nil {
+		return
+	}
+	s.ID = existing.ID
+	s.Owner = existing.Owner
+	s.CreatedAt = existing.CreatedAt
+	rotated := s.Secret != ""
+	if !rotated {
+		s.Secret = existing.Secret
+	}
+	if err = u.webhookRepo.Update(ctx, s); err != nil {
+		return
+	}
+	if !rotated {
+		s.Secret = ""
+	}
+	return
+}
+
+// Delete will unsubscribe a URL, its delivery log goes with it
+func (u *WebhookUsecase) Delete(c context.Context, id int) (err error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	if _, err = u.get(ctx, id); err != nil {
+		return
+	}
+	return u.webhookRepo.Delete(ctx, id)
+}
+
+// FetchDeliveries will get a page of the deliveries of a subscription of the caller, the latest first
+func (u *WebhookUsecase) FetchDeliveries(c context.Context, filter domain.WebhookDeliveryFilter) (res []domain.WebhookDelivery, err error) {
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, domain.ErrBadParamInput
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultDeliveryLimit
+	}
+	if filter.Limit > maxDeliveryLimit {
+		filter.Limit = maxDeliveryLimit
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	if _, err = u.get(ctx, filter.SubscriptionID); err != nil {
+		return
+	}
+	return u.webhookRepo.FetchDeliveries(ctx, filter)
+}
+
+// Redeliver will have a delivery attempted again right away, with a fresh
+// set of attempts
+func (u *WebhookUsecase) Redeliver(c context.Context, deliveryID int) (res domain.WebhookDelivery, err error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	res, err = u.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return
+	}
+	if _, err = u.get(ctx, res.SubscriptionID); err != nil {
+		return
+	}
+	res.Status = domain.WebhookDeliveryPending
+	res.Attempts = 0
+	res.NextAttemptAt = time.Now()
+	err = u.webhookRepo.UpdateDelivery(ctx, &res)
+	return
+}
+
+// Enqueue will plan a delivery of the event to every active subscription
+// wanting it, an event already planned for a subscription is left alone
+func (u *WebhookUsecase) Enqueue(c context.Context, e domain.Event) (err error) {
+	if err = domain.Authorize(c, domain.PermissionWebhookAdmin); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	subscriptions, err := u.webhookRepo.FetchActive(ctx)
+	if err != nil {
+		return
+	}
+	payload, err := json.Marshal(deliveryBody{
+		ID:         e.ID,
+		Type:       e.Type,
+		EntityID:   e.EntityID,
+		Payload:    e.Payload,
+		OccurredAt: e.OccurredAt,
+	})
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	for _, s := range subscriptions {
+		if !s.Wants(e) {
+			continue
+		}
+		err = u.webhookRepo.StoreDelivery(ctx, &domain.WebhookDelivery{
+			SubscriptionID: s.ID,
+			EventID:        e.ID,
+			EventType:      e.Type,
+			Payload:        payload,
+			Status:         domain.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+		})
+		if err != nil && !errors.Is(err, domain.ErrConflict) {
+			return
+		}
+	}
+	return nil
+}
+
+// DeliverDue will attempt the pending deliveries due at now, a few at a time
+func (u *WebhookUsecase) DeliverDue(c context.Context, now time.Time) (delivered int, err error) {
+	if err = domain.Authorize(c, domain.PermissionWebhookAdmin); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	due, err := u.webhookRepo.FetchDue(ctx, now, deliveryBatchSize)
+	cancel()
+	if err != nil {
+		return
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan domain.WebhookDelivery)
+	for i := 0; i < deliveryWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range queue {
+				ok, errAttempt := u.attempt(c, d)
+				if errAttempt != nil {
+					logrus.Error(errAttempt)
+				}
+				if ok {
+					mu.Lock()
+					delivered++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, d := range due {
+		queue <- d
+	}
+	close(queue)
+	wg.Wait()
+	return
+}
+
+// attempt post a delivery and record how it went, it reports whether the
+// subscriber acknowledged it
+func (u *WebhookUsecase) attempt(c context.Context, d domain.WebhookDelivery) (ok bool, err error) {
+	// the delivery is held for as long as an attempt may take, it is retried
+	// after that should this dispatcher stop meanwhile
+	lease := 2*u.contextTimeout + u.client.Timeout
+	ctx, cancel := context.WithTimeout(c, lease)
+	defer cancel()
+	if err = u.webhookRepo.Claim(ctx, d.ID, d.NextAttemptAt, time.Now().Add(lease)); err != nil {
+		if errors.Is(err, domain.ErrConflict) {
+			return false, nil
+		}
+		return
+	}
+	s, err := u.webhookRepo.GetByID(ctx, d.SubscriptionID)
+	if err != nil {
+		return
+	}
+
+	d.Attempts++
+	d.LastStatusCode, err = u.post(ctx, s, d)
+	now := time.Now()
+	switch {
+	case err == nil && d.LastStatusCode >= 200 && d.LastStatusCode < 300:
+		d.Status = domain.WebhookDeliverySucceeded
+		d.LastError = ""
+		d.DeliveredAt = &now
+		ok = true
+	case d.Attempts >= maxAttempts:
+		d.Status = domain.WebhookDeliveryDead
+	default:
+		d.NextAttemptAt = now.Add(retryDelay(d.Attempts))
+	}
+	if err != nil {
+		// the cause stays in the logs, it would tell the subscriber about our network
+		logrus.WithField("delivery", d.ID).Warn(err)
+		d.LastError = "no response"
+	} else if !ok {
+		d.LastError = fmt.Sprintf("unexpected status %d", d.LastStatusCode)
+	}
+	return ok, u.webhookRepo.UpdateDelivery(ctx, &d)
+}
+
+// post send the event of the delivery to the subscription, signed with its secret
+func (u *WebhookUsecase) post(ctx context.Context, s domain.WebhookSubscription, d domain.WebhookDelivery) (int, error) {
+	// the subscriptions made before https was required are not posted to
+	if !strings.HasPrefix(s.URL, "https://") {
+		return 0, errBlockedAddress
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "bookhub-webhook")
+	req.Header.Set(EventHeader, d.EventType)
+	req.Header.Set(DeliveryHeader, strconv.Itoa(d.ID))
+	req.Header.Set(SignatureHeader, Sign(s.Secret, time.Now().Unix(), d.Payload))
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// the body is read so that the connection is reused, it is not kept
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64*1024))
+	return resp.StatusCode, nil
+}
+
+// retryDelay is the wait after the given number of failed attempts, doubling
+// each time up to maxRetryDelay and spread by up to a tenth so that the
+// deliveries failed together are not all retried at once
+func retryDelay(attempts int) time.Duration {
+	delay := maxRetryDelay
+	if attempts <= 16 {
+		if d := baseRetryDelay << uint(attempts-1); d < maxRetryDelay {
+			delay = d
+		}
+	}
+	return delay + time.Duration(mathrand.Int63n(int64(delay/10)+1))
+}
@@ -0,0 +1,237 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/negotiate"
+	"github.com/wdwiramadhan/bookhub-api/helper/response"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+// WishlistHandler represent the httphandler for wishlist
+type WishlistHandler struct {
+	WUsecase domain.WishlistUsecase
+	CUsecase domain.CurrencyUsecase
+}
+
+// NewWishlistHandler will initialize the wishlist endpoint, the public lists
+// are readable without credentials when auth is optional. The prices of the
+// listed products are converted with cu into the requested currency
+func NewWishlistHandler(e *echo.Echo, us domain.WishlistUsecase, cu domain.CurrencyUsecase, auth echo.MiddlewareFunc, optionalAuth echo.MiddlewareFunc) {
+	handler := &WishlistHandler{
+		WUsecase: us,
+		CUsecase: cu,
+	}
+	e.GET("/user/me/wishlist", handler.FetchMine, auth)
+	e.POST("/wishlist", handler.Store, auth)
+	e.GET("/wishlist/shared/:slug", handler.GetBySlug)
+	e.GET("/wishlist/:wishlistId", handler.GetByID, optionalAuth)
+	e.PUT("/wishlist/:wishlistId", handler.Update, auth)
+	e.DELETE("/wishlist/:wishlistId", handler.Delete, auth)
+	e.POST("/wishlist/:wishlistId/item", handler.AddItem, auth)
+	e.PUT("/wishlist/:wishlistId/item/:productId", handler.UpdateItem, auth)
+	e.DELETE("/wishlist/:wishlistId/item/:productId", handler.RemoveItem, auth)
+}
+
+// FetchMine will fetch the wishlists of the authenticated user
+func (w *WishlistHandler) FetchMine(c echo.Context) error {
+	ctx := c.Request().Context()
+	wishlists, err := w.WUsecase.FetchMine(ctx)
+	if err != nil {
+		return failed(c, err)
+	}
+	if err = w.localize(c, wishlists...); err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: wishlists})
+}
+
+// Store will create a wishlist by given request body
+func (w *WishlistHandler) Store(c echo.Context) (err error) {
+	var wishlist domain.Wishlist
+	if err = c.Bind(&wishlist); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&wishlist); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	if err = w.WUsecase.Store(ctx, &wishlist); err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusCreated, response.ResponseSuccess{Success: true, Data: wishlist})
+}
+
+// GetByID will get a wishlist with its items by given id
+func (w *WishlistHandler) GetByID(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("wishlistId"))
+	ctx := c.Request().Context()
+	wishlist, err := w.WUsecase.GetByID(ctx, id)
+	if err != nil {
+		return failed(c, err)
+	}
+	if err = w.localize(c, wishlist); err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: wishlist})
+}
+
+// GetBySlug will get a public wishlist with its items by its shareable slug
+func (w *WishlistHandler) GetBySlug(c echo.Context) error {
+	ctx := c.Request().Context()
+	wishlist, err := w.WUsecase.GetBySlug(ctx, c.Param("slug"))
+	if err != nil {
+		return failed(c, err)
+	}
+	if err = w.localize(c, wishlist); err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: wishlist})
+}
+
+// Update will rename a wishlist or change its visibility by given request body
+func (w *WishlistHandler) Update(c echo.Context) (err error) {
+	id, _ := strconv.Atoi(c.Param("wishlistId"))
+	var wishlist domain.Wishlist
+	if err = c.Bind(&wishlist); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&wishlist); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	if err = w.WUsecase.Update(ctx, id, &wishlist); err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: wishlist})
+}
+
+// Delete will remove a wishlist by given id
+func (w *WishlistHandler) Delete(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("wishlistId"))
+	ctx := c.Request().Context()
+	if err := w.WUsecase.Delete(ctx, id); err != nil {
+		return failed(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// AddItem will add the product given in the request body to a wishlist
+func (w *WishlistHandler) AddItem(c echo.Context) (err error) {
+	id, _ := strconv.Atoi(c.Param("wishlistId"))
+	var item domain.WishlistItem
+	if err = c.Bind(&item); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	var ok bool
+	if ok, err = isRequestValid(&item); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	if err = w.WUsecase.AddItem(ctx, id, &item); err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusCreated, response.ResponseSuccess{Success: true, Data: item})
+}
+
+// UpdateItem will change the note or the position of a product in a wishlist
+func (w *WishlistHandler) UpdateItem(c echo.Context) (err error) {
+	id, _ := strconv.Atoi(c.Param("wishlistId"))
+	var item domain.WishlistItem
+	if err = c.Bind(&item); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	item.ProductID, _ = strconv.Atoi(c.Param("productId"))
+	var ok bool
+	if ok, err = isRequestValid(&item); !ok {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	if err = w.WUsecase.UpdateItem(ctx, id, &item); err != nil {
+		return failed(c, err)
+	}
+	return c.JSON(http.StatusOK, response.ResponseSuccess{Success: true, Data: item})
+}
+
+// RemoveItem will remove a product from a wishlist
+func (w *WishlistHandler) RemoveItem(c echo.Context) error {
+	id, _ := strconv.Atoi(c.Param("wishlistId"))
+	productID, _ := strconv.Atoi(c.Param("productId"))
+	ctx := c.Request().Context()
+	if err := w.WUsecase.RemoveItem(ctx, id, productID); err != nil {
+		return failed(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// localize convert the price of the products of the wishlists
+func (w *WishlistHandler) localize(c echo.Context, wishlists ...domain.Wishlist) error {
+	products := []domain.Product{}
+	for _, wishlist := range wishlists {
+		for _, item := range wishlist.Items {
+			if item.Product != nil {
+				products = append(products, *item.Product)
+			}
+		}
+	}
+	if _, err := negotiate.Localize(c, w.CUsecase, products); err != nil {
+		return err
+	}
+	for _, wishlist := range wishlists {
+		for i := range wishlist.Items {
+			if wishlist.Items[i].Product != nil {
+				wishlist.Items[i].Product = &products[0]
+				products = products[1:]
+			}
+		}
+	}
+	return nil
+}
+
+func isRequestValid(m interface{}) (bool, error) {
+	validate := validator.New()
+	err := validate.Struct(m)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// failed write the error response of err, a denied permission is described with a problem detail
+func failed(c echo.Context, err error) error {
+	status := getStatusCode(err)
+	if status == http.StatusForbidden {
+		c.Response().Header().Set(echo.HeaderContentType, response.ProblemContentType)
+		return c.JSON(status, response.NewProblem(status, err.Error()))
+	}
+	return c.JSON(status, response.ResponseFailed{Success: false, Message: err.Error()})
+}
+
+func getStatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	logrus.Error(err)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrBadParamInput):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,36 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// mailPriceDropNotifier email the owner of the wishlist about the price drop
+type mailPriceDropNotifier struct {
+	userRepo domain.UserRepository
+	mailer   domain.Mailer
+	currency string
+}
+
+// NewMailPriceDropNotifier will create an object that represent the domain.PriceDropNotifier interface,
+// currency is the one the products are priced in
+func NewMailPriceDropNotifier(u domain.UserRepository, m domain.Mailer, currency string) domain.PriceDropNotifier {
+	return &mailPriceDropNotifier{userRepo: u, mailer: m, currency: strings.ToUpper(currency)}
+}
+
+func (n *mailPriceDropNotifier) PriceDropped(ctx context.Context, drop domain.PriceDrop) error {
+	user, err := n.userRepo.GetByID(ctx, drop.UserID)
+	if err != nil {
+		return err
+	}
+	return n.mailer.Send(ctx, domain.Mail{
+		To:      user.Email,
+		Subject: fmt.Sprintf("Price drop on %s", drop.Product.Name),
+		Body: fmt.Sprintf("Hi %s,\n\n%s from your list \"%s\" is now %s, down from %s.\n",
+			user.Name, drop.Product.Name, drop.WishlistName,
+			domain.Money{Amount: drop.NewPrice, Currency: n.currency}, domain.Money{Amount: drop.OldPrice, Currency: n.currency}),
+	})
+}
@@ -0,0 +1,341 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+	"github.com/wdwiramadhan/bookhub-api/helper/mysqlerr"
+	"github.com/wdwiramadhan/bookhub-api/helper/sqltx"
+)
+
+const selectWishlist = `SELECT id, user_id, name, description, visibility, slug, updated_at, created_at FROM wishlist`
+
+// mysqlWishlistRepository represent the connection database struct
+type mysqlWishlistRepository struct {
+	Conn *sql.DB
+}
+
+// NewMysqlWishlistRepository will create an object that represent the wishlist.Repository interface
+func NewMysqlWishlistRepository(Conn *sql.DB) domain.WishlistRepository {
+	return &mysqlWishlistRepository{Conn: Conn}
+}
+
+func (m *mysqlWishlistRepository) fetch(ctx context.Context, query string, args ...interface{}) (result []domain.Wishlist, err error) {
+	rows, err := sqltx.From(ctx, m.Conn).QueryContext(ctx, query, args...)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.Wishlist, 0)
+	for rows.Next() {
+		t := domain.Wishlist{}
+		err = rows.Scan(
+			&t.ID,
+			&t.UserID,
+			&t.Name,
+			&t.Description,
+			&t.Visibility,
+			&t.Slug,
+			&t.UpdatedAt,
+			&t.CreatedAt,
+		)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+func (m *mysqlWishlistRepository) getOne(ctx context.Context, query string, args ...interface{}) (res domain.Wishlist, err error) {
+	list, err := m.fetch(ctx, query, args...)
+	if err != nil {
+		return
+	}
+	if len(list) == 0 {
+		return res, domain.ErrNotFound
+	}
+	return list[0], nil
+}
+
+func (m *mysqlWishlistRepository) FetchByUser(ctx context.Context, userID int) ([]domain.Wishlist, error) {
+	return m.fetch(ctx, selectWishlist+` WHERE user_id=? ORDER BY created_at`, userID)
+}
+
+func (m *mysqlWishlistRepository) GetByID(ctx context.Context, id int) (domain.Wishlist, error) {
+	return m.getOne(ctx, selectWishlist+` WHERE id=?`, id)
+}
+
+func (m *mysqlWishlistRepository) GetBySlug(ctx context.Context, slug string) (domain.Wishlist, error) {
+	return m.getOne(ctx, selectWishlist+` WHERE slug=?`, slug)
+}
+
+func (m *mysqlWishlistRepository) Store(ctx context.Context, w *domain.Wishlist) error {
+	query := `INSERT INTO wishlist (user_id, name, description, visibility, slug, updated_at, created_at) VALUES(?,?,?,?,?,?,?)`
+	now := time.Now()
+	result, err := sqltx.From(ctx, m.Conn).ExecContext(ctx, query, w.UserID, w.Name, w.Description, w.Visibility, w.Slug, now, now)
+	if err != nil {
+		if mysqlerr.IsDuplicate(err) {
+			return domain.ErrConflict
+		}
+		return err
+	}
+	lastID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	w.ID = int(lastID)
+	w.UpdatedAt = now
+	w.CreatedAt = now
+	return nil
+}
+
+func (m *mysqlWishlistRepository) Update(ctx context.Context, w *domain.Wishlist) error {
+	query := `UPDATE wishlist SET name=?, description=?, visibility=?, updated_at=? WHERE id=?`
+	w.UpdatedAt = time.Now()
+	_, err := sqltx.From(ctx, m.Conn).ExecContext(ctx, query, w.Name, w.Description, w.Visibility, w.UpdatedAt, w.ID)
+	return err
+}
+
+// withTx run fn in a transaction, rolled back when fn fails. fn joins the
+// transaction of ctx when there is one
+func (m *mysqlWishlistRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) (err error) {
+	if tx, ok := sqltx.Tx(ctx); ok {
+		return fn(tx)
+	}
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			if errRollback := tx.Rollback(); errRollback != nil {
+				logrus.Error(errRollback)
+			}
+		}
+	}()
+	if err = fn(tx); err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+func (m *mysqlWishlistRepository) Delete(ctx context.Context, id int) error {
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM wishlist_item WHERE wishlist_id=?`, id); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM wishlist WHERE id=?`, id)
+		return err
+	})
+}
+
+func (m *mysqlWishlistRepository) FetchItems(ctx context.Context, wishlistID int) (result []domain.WishlistItem, err error) {
+	query := `SELECT wishlist_id, product_id, position, note, last_price, added_at FROM wishlist_item WHERE wishlist_id=? ORDER BY position`
+	rows, err := sqltx.From(ctx, m.Conn).QueryContext(ctx, query, wishlistID)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			logrus.Error(errRow)
+		}
+	}()
+	result = make([]domain.WishlistItem, 0)
+	for rows.Next() {
+		t := domain.WishlistItem{Position: new(int)}
+		err = rows.Scan(&t.WishlistID, &t.ProductID, t.Position, &t.Note, &t.LastPrice, &t.AddedAt)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// lockItems lock the wishlist until the end of the transaction, so that its
+// items are renumbered by one transaction at a time, and count its items
+func lockItems(ctx context.Context, tx *sql.Tx, wishlistID int) (count int, err error) {
+	var id int
+	err = tx.QueryRowContext(ctx, `SELECT id FROM wishlist WHERE id=? FOR UPDATE`, wishlistID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, domain.ErrNotFound
+	}
+	if err != nil {
+		return
+	}
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM wishlist_item WHERE wishlist_id=?`, wishlistID).Scan(&count)
+	return
+}
+
+func positionOf(ctx context.Context, tx *sql.Tx, wishlistID int, productID int) (position int, err error) {
+	query := `SELECT position FROM wishlist_item WHERE wishlist_id=? AND product_id=?`
+	err = tx.QueryRowContext(ctx, query, wishlistID, productID).Scan(&position)
+	if err == sql.ErrNoRows {
+		return 0, domain.ErrNotFound
+	}
+	return
+}
+
+// move put the product at the position to, shifting the items in between by one
+func move(ctx context.Context, tx *sql.Tx, wishlistID int, productID int, from int, to int) (err error) {
+	switch {
+	case to < from:
+		query := `UPDATE wishlist_item SET position=position+1 WHERE wishlist_id=? AND position>=? AND position<?`
+		_, err = tx.ExecContext(ctx, query, wishlistID, to, from)
+	case to > from:
+		query := `UPDATE wishlist_item SET position=position-1 WHERE wishlist_id=? AND position>? AND position<=?`
+		_, err = tx.ExecContext(ctx, query, wishlistID, from, to)
+	default:
+		return nil
+	}
+	if err != nil {
+		return
+	}
+	query := `UPDATE wishlist_item SET position=? WHERE wishlist_id=? AND product_id=?`
+	_, err = tx.ExecContext(ctx, query, to, wishlistID, productID)
+	return
+}
+
+// clamp keep a requested position within the count items of a wishlist
+func clamp(position *int, count int) int {
+	if position == nil || *position >= count {
+		return count - 1
+	}
+	if *position < 0 {
+		return 0
+	}
+	return *position
+}
+
+func touch(ctx context.Context, tx *sql.Tx, wishlistID int) error {
+	_, err := tx.ExecContext(ctx, `UPDATE wishlist SET updated_at=? WHERE id=?`, time.Now(), wishlistID)
+	return err
+}
+
+func (m *mysqlWishlistRepository) AddItem(ctx context.Context, item *domain.WishlistItem) error {
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		count, err := lockItems(ctx, tx, item.WishlistID)
+		if err != nil {
+			return err
+		}
+		item.AddedAt = time.Now()
+		query := `INSERT INTO wishlist_item (wishlist_id, product_id, position, note, last_price, added_at) VALUES(?,?,?,?,?,?)`
+		_, err = tx.ExecContext(ctx, query, item.WishlistID, item.ProductID, count, item.Note, item.LastPrice, item.AddedAt)
+		if err != nil {
+			if mysqlerr.IsDuplicate(err) {
+				return domain.ErrConflict
+			}
+			return err
+		}
+		to := clamp(item.Position, count+1)
+		if err = move(ctx, tx, item.WishlistID, item.ProductID, count, to); err != nil {
+			return err
+		}
+		item.Position = &to
+		return touch(ctx, tx, item.WishlistID)
+	})
+}
+
+func (m *mysqlWishlistRepository) UpdateItem(ctx context.Context, item *domain.WishlistItem) error {
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		count, err := lockItems(ctx, tx, item.WishlistID)
+		if err != nil {
+			return err
+		}
+		from, err := positionOf(ctx, tx, item.WishlistID, item.ProductID)
+		if err != nil {
+			return err
+		}
+		to := from
+		if item.Position != nil {
+			to = clamp(item.Position, count)
+		}
+		if err = move(ctx, tx, item.WishlistID, item.ProductID, from, to); err != nil {
+			return err
+		}
+		query := `UPDATE wishlist_item SET note=? WHERE wishlist_id=? AND product_id=?`
+		if _, err = tx.ExecContext(ctx, query, item.Note, item.WishlistID, item.ProductID); err != nil {
+			return err
+		}
+		item.Position = &to
+		return touch(ctx, tx, item.WishlistID)
+	})
+}
+
+func (m *mysqlWishlistRepository) RemoveItem(ctx context.Context, wishlistID int, productID int) error {
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := lockItems(ctx, tx, wishlistID); err != nil {
+			return err
+		}
+		position, err := positionOf(ctx, tx, wishlistID, productID)
+		if err != nil {
+			return err
+		}
+		query := `DELETE FROM wishlist_item WHERE wishlist_id=? AND product_id=?`
+		if _, err = tx.ExecContext(ctx, query, wishlistID, productID); err != nil {
+			return err
+		}
+		query = `UPDATE wishlist_item SET position=position-1 WHERE wishlist_id=? AND position>?`
+		if _, err = tx.ExecContext(ctx, query, wishlistID, position); err != nil {
+			return err
+		}
+		return touch(ctx, tx, wishlistID)
+	})
+}
+
+// ClaimPriceDrops lock the items last seen above the price before lowering
+// them, a concurrent claim waits for the lock and then finds none of them
+func (m *mysqlWishlistRepository) ClaimPriceDrops(ctx context.Context, productID int, price int64) (result []domain.PriceDrop, err error) {
+	err = m.withTx(ctx, func(tx *sql.Tx) error {
+		query := `SELECT wishlist.id, wishlist.user_id, wishlist.name, wishlist_item.last_price
+			FROM wishlist_item JOIN wishlist ON wishlist_item.wishlist_id = wishlist.id
+			WHERE wishlist_item.product_id=? AND wishlist_item.last_price>? FOR UPDATE`
+		rows, err := tx.QueryContext(ctx, query, productID, price)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			errRow := rows.Close()
+			if errRow != nil {
+				logrus.Error(errRow)
+			}
+		}()
+		result = make([]domain.PriceDrop, 0)
+		for rows.Next() {
+			t := domain.PriceDrop{NewPrice: price}
+			if err = rows.Scan(&t.WishlistID, &t.UserID, &t.WishlistName, &t.OldPrice); err != nil {
+				return err
+			}
+			result = append(result, t)
+		}
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE wishlist_item SET last_price=? WHERE product_id=? AND last_price>?`, price, productID, price)
+		return err
+	})
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (m *mysqlWishlistRepository) UpdateLastPrice(ctx context.Context, productID int, price int64) error {
+	_, err := sqltx.From(ctx, m.Conn).ExecContext(ctx, `UPDATE wishlist_item SET last_price=? WHERE product_id=? AND last_price<?`, price, productID, price)
+	return err
+}
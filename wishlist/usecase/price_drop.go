@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+// PriceDropWatcher compare the price of every changed product with the price
+// it was last seen at in the wishlists, and tell the notifier about the drops.
+// It is a domain.CatalogListener
+type PriceDropWatcher struct {
+	wishlistRepo domain.WishlistRepository
+	productRepo  domain.ProductRepository
+	notifier     domain.PriceDropNotifier
+	timeout      time.Duration
+
+	mu sync.Mutex
+	// pending are the changed products waiting for a check, in order
+	pending []int
+	queued  map[int]bool
+	wake    chan struct{}
+}
+
+// NewPriceDropWatcher will create a watcher reading the products from p, it
+// must not be a cached repository as it runs right after the product changes
+func NewPriceDropWatcher(w domain.WishlistRepository, p domain.ProductRepository, n domain.PriceDropNotifier, timeout time.Duration) *PriceDropWatcher {
+	return &PriceDropWatcher{
+		wishlistRepo: w,
+		productRepo:  p,
+		notifier:     n,
+		timeout:      timeout,
+		queued:       map[int]bool{},
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// ProductChanged implements domain.CatalogListener, the product is queued for
+// Run to check so that the product mutation is not slowed down by the
+// notifications. A product already waiting is not queued twice, the check
+// reads its latest price
+func (w *PriceDropWatcher) ProductChanged(ctx context.Context, id int) {
+	w.mu.Lock()
+	if !w.queued[id] {
+		w.queued[id] = true
+		w.pending = append(w.pending, id)
+	}
+	w.mu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// AuthorChanged implements domain.CatalogListener
+func (w *PriceDropWatcher) AuthorChanged(ctx context.Context, id int) {}
+
+// Run check the queued products one at a time until ctx is done, so that
+// the checks of a product never overlap
+func (w *PriceDropWatcher) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.wake:
+		}
+		for {
+			id, ok := w.next()
+			if !ok {
+				break
+			}
+			checkCtx, cancel := context.WithTimeout(ctx, w.timeout)
+			if err := w.check(checkCtx, id); err != nil {
+				logrus.Error(err)
+			}
+			cancel()
+		}
+	}
+}
+
+func (w *PriceDropWatcher) next() (int, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.pending) == 0 {
+		return 0, false
+	}
+	id := w.pending[0]
+	w.pending = w.pending[1:]
+	delete(w.queued, id)
+	return id, true
+}
+
+func (w *PriceDropWatcher) check(ctx context.Context, id int) error {
+	product, err := w.productRepo.GetByID(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// the items are claimed before the notifications, a drop is told once
+	drops, err := w.wishlistRepo.ClaimPriceDrops(ctx, id, product.Price)
+	if err != nil {
+		return err
+	}
+	for _, drop := range drops {
+		drop.Product = product
+		if err = w.notifier.PriceDropped(ctx, drop); err != nil {
+			logrus.Error(err)
+		}
+	}
+	// a price going up is remembered too, the next drop is measured from it
+	return w.wishlistRepo.UpdateLastPrice(ctx, id, product.Price)
+}
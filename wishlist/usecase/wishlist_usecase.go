@@ -0,0 +1,250 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/wdwiramadhan/bookhub-api/domain"
+)
+
+const (
+	// slugBytes is the entropy of a shareable slug, hard enough to guess to keep the unshared lists unseen
+	slugBytes = 9
+	// slugAttempts bound the retries on the very unlikely slug collision
+	slugAttempts = 3
+)
+
+// WishlistUsecase represent the wishlist use case struct
+type WishlistUsecase struct {
+	wishlistRepo   domain.WishlistRepository
+	productRepo    domain.ProductRepository
+	contextTimeout time.Duration
+}
+
+// NewWishlistUsecase will create new a wishlist usecase object representation of domain.WishlistUsecase interface
+func NewWishlistUsecase(w domain.WishlistRepository, p domain.ProductRepository, timeout time.Duration) domain.WishlistUsecase {
+	return &WishlistUsecase{
+		wishlistRepo:   w,
+		productRepo:    p,
+		contextTimeout: timeout,
+	}
+}
+
+// currentUser return the id of the registered user calling, only users keep wishlists
+func currentUser(ctx context.Context) (int, error) {
+	p, ok := domain.PrincipalFromContext(ctx)
+	if !ok {
+		return 0, domain.ErrUnauthorized
+	}
+	id, ok := p.UserID()
+	if !ok {
+		return 0, domain.ErrForbidden
+	}
+	return id, nil
+}
+
+func newSlug() (string, error) {
+	b := make([]byte, slugBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// withItems load the items of the wishlist along with their product, the
+// products deleted since they were added are listed without details
+func (u *WishlistUsecase) withItems(ctx context.Context, w *domain.Wishlist) (err error) {
+	w.Items, err = u.wishlistRepo.FetchItems(ctx, w.ID)
+	if err != nil {
+		return
+	}
+	for i := range w.Items {
+		product, errProduct := u.productRepo.GetByID(ctx, w.Items[i].ProductID)
+		if errors.Is(errProduct, domain.ErrNotFound) {
+			continue
+		}
+		if errProduct != nil {
+			return errProduct
+		}
+		product.Thumbnails = domain.CoverThumbnails(product.Image)
+		w.Items[i].Product = &product
+	}
+	return
+}
+
+// owned return the wishlist if the calling user owns it
+func (u *WishlistUsecase) owned(ctx context.Context, id int) (res domain.Wishlist, err error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return
+	}
+	res, err = u.wishlistRepo.GetByID(ctx, id)
+	if err != nil {
+		return
+	}
+	if res.UserID != userID {
+		// somebody else's list is as good as missing
+		return domain.Wishlist{}, domain.ErrNotFound
+	}
+	return
+}
+
+// FetchMine will get the wishlists of the calling user, without their items
+func (u *WishlistUsecase) FetchMine(c context.Context) ([]domain.Wishlist, error) {
+	userID, err := currentUser(c)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+	return u.wishlistRepo.FetchByUser(ctx, userID)
+}
+
+// Store will create a wishlist of the calling user, private unless told otherwise
+func (u *WishlistUsecase) Store(c context.Context, w *domain.Wishlist) (err error) {
+	userID, err := currentUser(c)
+	if err != nil {
+		return
+	}
+	if w.Visibility == "" {
+		w.Visibility = domain.WishlistPrivate
+	}
+	if !w.Visibility.IsValid() {
+		return domain.ErrBadParamInput
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	w.UserID = userID
+	w.Items = []domain.WishlistItem{}
+	for attempt := 0; attempt < slugAttempts; attempt++ {
+		if w.Slug, err = newSlug(); err != nil {
+			return
+		}
+		if err = u.wishlistRepo.Store(ctx, w); !errors.Is(err, domain.ErrConflict) {
+			return
+		}
+	}
+	return
+}
+
+// GetByID will get a wishlist with its items, for its owner or anybody if it is public
+func (u *WishlistUsecase) GetByID(c context.Context, id int) (res domain.Wishlist, err error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	res, err = u.wishlistRepo.GetByID(ctx, id)
+	if err != nil {
+		return
+	}
+	if res.Visibility != domain.WishlistPublic {
+		if userID, errUser := currentUser(c); errUser != nil || userID != res.UserID {
+			return domain.Wishlist{}, domain.ErrNotFound
+		}
+	}
+	err = u.withItems(ctx, &res)
+	return
+}
+
+// GetBySlug will get a public wishlist by its shareable slug
+func (u *WishlistUsecase) GetBySlug(c context.Context, slug string) (res domain.Wishlist, err error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	res, err = u.wishlistRepo.GetBySlug(ctx, slug)
+	if err != nil {
+		return
+	}
+	if res.Visibility != domain.WishlistPublic {
+		return domain.Wishlist{}, domain.ErrNotFound
+	}
+	err = u.withItems(ctx, &res)
+	return
+}
+
+// Update will rename the wishlist of the calling user or change its visibility
+func (u *WishlistUsecase) Update(c context.Context, id int, w *domain.Wishlist) (err error) {
+	if w.Visibility != "" && !w.Visibility.IsValid() {
+		return domain.ErrBadParamInput
+	}
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	existing, err := u.owned(ctx, id)
+	if err != nil {
+		return
+	}
+	existing.Name = w.Name
+	existing.Description = w.Description
+	if w.Visibility != "" {
+		existing.Visibility = w.Visibility
+	}
+	if err = u.wishlistRepo.Update(ctx, &existing); err != nil {
+		return
+	}
+	*w = existing
+	return
+}
+
+// Delete will remove the wishlist of the calling user
+func (u *WishlistUsecase) Delete(c context.Context, id int) (err error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if _, err = u.owned(ctx, id); err != nil {
+		return
+	}
+	return u.wishlistRepo.Delete(ctx, id)
+}
+
+// AddItem will add a product to the wishlist, at the end unless a position is given
+func (u *WishlistUsecase) AddItem(c context.Context, wishlistID int, item *domain.WishlistItem) (err error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if _, err = u.owned(ctx, wishlistID); err != nil {
+		return
+	}
+	product, err := u.productRepo.GetByID(ctx, item.ProductID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.ErrBadParamInput
+	}
+	if err != nil {
+		return
+	}
+	item.WishlistID = wishlistID
+	// the price drops are measured from the price the product was added at
+	item.LastPrice = product.Price
+	if err = u.wishlistRepo.AddItem(ctx, item); err != nil {
+		return
+	}
+	product.Thumbnails = domain.CoverThumbnails(product.Image)
+	item.Product = &product
+	return
+}
+
+// UpdateItem will change the note of an item and move it when a position is given
+func (u *WishlistUsecase) UpdateItem(c context.Context, wishlistID int, item *domain.WishlistItem) (err error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if _, err = u.owned(ctx, wishlistID); err != nil {
+		return
+	}
+	item.WishlistID = wishlistID
+	return u.wishlistRepo.UpdateItem(ctx, item)
+}
+
+// RemoveItem will remove a product from the wishlist, the following items move up
+func (u *WishlistUsecase) RemoveItem(c context.Context, wishlistID int, productID int) (err error) {
+	ctx, cancel := context.WithTimeout(c, u.contextTimeout)
+	defer cancel()
+
+	if _, err = u.owned(ctx, wishlistID); err != nil {
+		return
+	}
+	return u.wishlistRepo.RemoveItem(ctx, wishlistID, productID)
+}